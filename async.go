@@ -0,0 +1,291 @@
+package slogger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy selects what an AsyncHandler does with a record when its queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowDropNewest discards the record being logged.
+	OverflowDropNewest OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest queued record to make room.
+	OverflowDropOldest
+	// OverflowBlock makes the caller wait for room, trading latency for completeness.
+	OverflowBlock
+	// OverflowSample keeps one record in SampleEvery, for which the caller waits for
+	// room, and discards the others, so a flood stays visible at a bounded cost.
+	OverflowSample
+)
+
+// AsyncOptions configures an AsyncHandler.
+type AsyncOptions struct {
+	// Capacity is the number of records the queue holds. Defaults to 1024.
+	Capacity int
+
+	// Policy selects what happens to a record when the queue is full. Defaults to
+	// OverflowDropNewest.
+	Policy OverflowPolicy
+
+	// SampleEvery is the sampling rate of OverflowSample. Defaults to 10.
+	SampleEvery int
+
+	// OnError, if not nil, receives the errors of the wrapped handler, which the caller
+	// logging the record no longer sees.
+	OnError func(error)
+}
+
+// AsyncHandler queues records and passes them to the wrapped handler from a worker
+// goroutine, so logging doesn't wait for a slow output. The queue is bounded: when it is
+// full, the policy decides between dropping records and making the caller wait, and
+// Stats counts each outcome. Call Close on shutdown to write the queued records.
+type AsyncHandler struct {
+	next slog.Handler
+	q    *asyncQueue
+	gid  bool // a handler below logs goroutine IDs, which Handle records
+}
+
+// asyncQueue is the state shared by an AsyncHandler and the handlers derived from it.
+type asyncQueue struct {
+	opts AsyncOptions
+
+	mu       sync.Mutex
+	cond     *sync.Cond  // signals queued records to the worker and progress to Flush
+	ring     []asyncItem // circular buffer of n records starting at head
+	head, n  int
+	enqueued uint64 // records queued so far
+	finished uint64 // records handled or dropped from the queue so far
+	closed   bool
+	overflow uint64 // records that found the queue full, for sampling
+
+	queued, blocked, dropped, sampled, written, failed atomic.Uint64
+
+	done chan struct{}
+}
+
+// AsyncStats counts the outcomes of the records logged through an AsyncHandler.
+type AsyncStats struct {
+	Queued  uint64 // queued without waiting
+	Blocked uint64 // queued after the caller waited for room, with OverflowBlock
+	Sampled uint64 // queued after the caller waited for room, with OverflowSample
+	Dropped uint64 // dropped because the queue was full
+	Written uint64 // passed to the wrapped handler, which succeeded
+	Failed  uint64 // passed to the wrapped handler, which failed
+	Len     int    // currently queued
+}
+
+// asyncItem is a queued record with the handler and context it was logged with.
+type asyncItem struct {
+	ctx context.Context
+	h   slog.Handler
+	r   slog.Record
+}
+
+// NewAsyncHandler wraps next with a queue and starts its worker.
+func NewAsyncHandler(next slog.Handler, opts AsyncOptions) *AsyncHandler {
+	if opts.Capacity <= 0 {
+		opts.Capacity = 1024
+	}
+	if opts.SampleEvery <= 0 {
+		opts.SampleEvery = 10
+	}
+	q := &asyncQueue{
+		opts: opts,
+		ring: make([]asyncItem, opts.Capacity),
+		done: make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	go q.run()
+	return &AsyncHandler{next: next, q: q, gid: logsGoroutineID(next)}
+}
+
+// logsGoroutineID reports whether a PrettyHandler below h logs goroutine IDs.
+func logsGoroutineID(h slog.Handler) bool {
+	for _, n := range lifecycleNodes(h) {
+		if ph, ok := n.(*PrettyHandler); ok && ph.opts.GoroutineID {
+			return true
+		}
+	}
+	return false
+}
+
+// Dropped returns the number of records dropped because the queue was full.
+func (h *AsyncHandler) Dropped() uint64 {
+	return h.q.dropped.Load()
+}
+
+// Len returns the number of queued records.
+func (h *AsyncHandler) Len() int {
+	h.q.mu.Lock()
+	defer h.q.mu.Unlock()
+	return h.q.n
+}
+
+// Stats returns the counters of the handler, shared with the handlers derived from it.
+func (h *AsyncHandler) Stats() AsyncStats {
+	q := h.q
+	return AsyncStats{
+		Queued:  q.queued.Load(),
+		Blocked: q.blocked.Load(),
+		Sampled: q.sampled.Load(),
+		Dropped: q.dropped.Load(),
+		Written: q.written.Load(),
+		Failed:  q.failed.Load(),
+		Len:     h.Len(),
+	}
+}
+
+// Flush waits until the records queued before the call are written, or until ctx is done.
+func (h *AsyncHandler) Flush(ctx context.Context) error {
+	q := h.q
+	q.mu.Lock()
+	target := q.enqueued
+	q.mu.Unlock()
+
+	wait := make(chan struct{})
+	go func() {
+		q.mu.Lock()
+		for q.finished < target {
+			q.cond.Wait()
+		}
+		q.mu.Unlock()
+		close(wait)
+	}()
+	select {
+	case <-wait:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close writes the queued records and stops the worker. Records logged afterwards are
+// passed to the wrapped handler directly. The wrapped handler is not closed.
+func (h *AsyncHandler) Close() error {
+	q := h.q
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	<-q.done
+	return nil
+}
+
+func (h *AsyncHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *AsyncHandler) Handle(ctx context.Context, r slog.Record) error {
+	// The handlers below log the ID of this goroutine rather than that of the worker,
+	// unless an AsyncHandler above this one recorded it already.
+	if h.gid {
+		if _, ok := ctx.Value(goroutineKey{}).(uint64); !ok {
+			ctx = context.WithValue(ctx, goroutineKey{}, goroutineID())
+		}
+	}
+	// The record is handled after Handle returns, so it must not share the caller's
+	// attribute storage.
+	item := asyncItem{ctx: ctx, h: h.next, r: r.Clone()}
+	if !h.q.push(item) {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (h *AsyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &AsyncHandler{next: h.next.WithAttrs(attrs), q: h.q, gid: h.gid}
+}
+
+func (h *AsyncHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &AsyncHandler{next: h.next.WithGroup(name), q: h.q, gid: h.gid}
+}
+
+// push queues item, applying the overflow policy. It reports false once the queue is
+// closed, also while waiting for room.
+func (q *asyncQueue) push(item asyncItem) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return false
+	}
+	counter := &q.queued
+	if q.n == len(q.ring) {
+		q.overflow++
+		switch {
+		case q.opts.Policy == OverflowDropOldest:
+			q.pop()
+			q.finished++
+			q.dropped.Add(1)
+		case q.opts.Policy == OverflowBlock,
+			q.opts.Policy == OverflowSample && q.overflow%uint64(q.opts.SampleEvery) == 1:
+			for q.n == len(q.ring) && !q.closed {
+				q.cond.Wait()
+			}
+			if q.closed {
+				return false
+			}
+			counter = &q.blocked
+			if q.opts.Policy == OverflowSample {
+				counter = &q.sampled
+			}
+		default:
+			q.dropped.Add(1)
+			return true
+		}
+	}
+	counter.Add(1)
+	q.ring[(q.head+q.n)%len(q.ring)] = item
+	q.n++
+	q.enqueued++
+	q.cond.Broadcast()
+	return true
+}
+
+// run handles queued records until the queue is closed and empty.
+func (q *asyncQueue) run() {
+	defer close(q.done)
+	for {
+		q.mu.Lock()
+		for q.n == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if q.n == 0 {
+			q.mu.Unlock()
+			return
+		}
+		item := q.pop()
+		q.cond.Broadcast() // wake callers waiting for room
+		q.mu.Unlock()
+
+		if err := handleIsolated(item.ctx, item.h, item.r); err != nil {
+			q.failed.Add(1)
+			if q.opts.OnError != nil {
+				q.opts.OnError(fmt.Errorf("slogger: async: %w", err))
+			}
+		} else {
+			q.written.Add(1)
+		}
+
+		q.mu.Lock()
+		q.finished++
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	}
+}
+
+// pop removes and returns the oldest queued record. q.mu must be held.
+func (q *asyncQueue) pop() asyncItem {
+	item := q.ring[q.head]
+	q.ring[q.head] = asyncItem{}
+	q.head = (q.head + 1) % len(q.ring)
+	q.n--
+	return item
+}
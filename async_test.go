@@ -0,0 +1,195 @@
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gateHandler records the messages it handles, holding every Handle until release is
+// closed. started receives a value as each Handle begins. A record with the message
+// "fail" fails.
+type gateHandler struct {
+	started chan struct{}
+	release chan struct{}
+
+	mu   sync.Mutex
+	msgs []string
+}
+
+func newGateHandler() *gateHandler {
+	return &gateHandler{started: make(chan struct{}, 100), release: make(chan struct{})}
+}
+
+func (g *gateHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (g *gateHandler) WithAttrs([]slog.Attr) slog.Handler       { return g }
+func (g *gateHandler) WithGroup(string) slog.Handler            { return g }
+
+func (g *gateHandler) Handle(_ context.Context, r slog.Record) error {
+	g.started <- struct{}{}
+	<-g.release
+	if r.Message == "fail" {
+		return errors.New("failed")
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.msgs = append(g.msgs, r.Message)
+	return nil
+}
+
+func (g *gateHandler) written() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return strings.Join(g.msgs, " ")
+}
+
+// fillAsync returns an AsyncHandler with a queue of two whose worker is held handling
+// record 0, with records 1 and 2 queued behind it.
+func fillAsync(t *testing.T, opts AsyncOptions) (*AsyncHandler, *gateHandler, *slog.Logger) {
+	t.Helper()
+	g := newGateHandler()
+	opts.Capacity = 2
+	h := NewAsyncHandler(g, opts)
+	logger := slog.New(h)
+	logger.Info("0")
+	select {
+	case <-g.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker did not start")
+	}
+	logger.Info("1")
+	logger.Info("2")
+	return h, g, logger
+}
+
+// checkStats compares the Stats of h with want.
+func checkStats(t *testing.T, h *AsyncHandler, want AsyncStats) {
+	t.Helper()
+	if got := h.Stats(); got != want {
+		t.Errorf("Stats = %+v, want %+v", got, want)
+	}
+	if got := h.Dropped(); got != want.Dropped {
+		t.Errorf("Dropped = %d, want %d", got, want.Dropped)
+	}
+}
+
+// returnsWhileHeld logs msg and reports whether the call returned while the worker is held.
+func returnsWhileHeld(logger *slog.Logger, msg string) (returned bool, wait func()) {
+	done := make(chan struct{})
+	go func() {
+		logger.Info(msg)
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true, func() {}
+	case <-time.After(50 * time.Millisecond):
+		return false, func() { <-done }
+	}
+}
+
+func TestAsyncOverflowDropNewest(t *testing.T) {
+	h, g, logger := fillAsync(t, AsyncOptions{Policy: OverflowDropNewest})
+	logger.Info("3")
+	logger.Info("4")
+	close(g.release)
+	h.Close()
+	if got := g.written(); got != "0 1 2" {
+		t.Errorf("written = %s, want the records queued before the overflow", got)
+	}
+	checkStats(t, h, AsyncStats{Queued: 3, Dropped: 2, Written: 3})
+}
+
+func TestAsyncOverflowDropOldest(t *testing.T) {
+	h, g, logger := fillAsync(t, AsyncOptions{Policy: OverflowDropOldest})
+	logger.Info("3")
+	logger.Info("4")
+	close(g.release)
+	h.Close()
+	if got := g.written(); got != "0 3 4" {
+		t.Errorf("written = %s, want the newest records", got)
+	}
+	checkStats(t, h, AsyncStats{Queued: 5, Dropped: 2, Written: 3})
+}
+
+func TestAsyncOverflowBlock(t *testing.T) {
+	h, g, logger := fillAsync(t, AsyncOptions{Policy: OverflowBlock})
+	returned, wait := returnsWhileHeld(logger, "3")
+	if returned {
+		t.Error("logging into a full queue returned without waiting")
+	}
+	close(g.release)
+	wait()
+	h.Close()
+	if got := g.written(); got != "0 1 2 3" {
+		t.Errorf("written = %s, want every record", got)
+	}
+	checkStats(t, h, AsyncStats{Queued: 3, Blocked: 1, Written: 4})
+}
+
+func TestAsyncOverflowSample(t *testing.T) {
+	h, g, logger := fillAsync(t, AsyncOptions{Policy: OverflowSample, SampleEvery: 2})
+	returned, wait := returnsWhileHeld(logger, "3")
+	if returned {
+		t.Error("the sampled record did not wait for room")
+	}
+	// The next record to overflow is not sampled: it is dropped without waiting.
+	if returned, _ := returnsWhileHeld(logger, "4"); !returned {
+		t.Error("a record not sampled waited for room")
+	}
+	close(g.release)
+	wait()
+	h.Close()
+	if got := g.written(); got != "0 1 2 3" {
+		t.Errorf("written = %s, want the sampled record only", got)
+	}
+	checkStats(t, h, AsyncStats{Queued: 3, Sampled: 1, Dropped: 1, Written: 4})
+}
+
+func TestAsyncStatsFailed(t *testing.T) {
+	g := newGateHandler()
+	close(g.release)
+	var errs []string
+	h := NewAsyncHandler(g, AsyncOptions{OnError: func(err error) { errs = append(errs, err.Error()) }})
+	logger := slog.New(h)
+	logger.Info("ok")
+	logger.Info("fail")
+	if err := h.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	checkStats(t, h, AsyncStats{Queued: 2, Written: 1, Failed: 1})
+	h.Close()
+	if len(errs) != 1 || errs[0] != "slogger: async: failed" {
+		t.Errorf("errors = %q", errs)
+	}
+}
+
+func TestAsyncGoroutineIDOfCaller(t *testing.T) {
+	var buf bytes.Buffer
+	inner := NewAsyncHandler(NewPrettyHandler(&buf, WithFormat(FormatJSON), WithGoroutineID(true)), AsyncOptions{})
+	h := NewAsyncHandler(inner, AsyncOptions{})
+	logger := slog.New(h).With("k", "v")
+
+	ids := make(chan uint64, 1)
+	go func() {
+		ids <- goroutineID()
+		logger.Info("from a worker")
+	}()
+	want := <-ids
+	h.Close()
+	inner.Close()
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if got, _ := rec[GoroutineIDKey].(float64); uint64(got) != want {
+		t.Errorf("%s = %v, want %d, the goroutine that logged the record", GoroutineIDKey, rec[GoroutineIDKey], want)
+	}
+}
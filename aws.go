@@ -0,0 +1,357 @@
+package slogger
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AWSCredentials are the keys used to sign AWS requests.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Expires is when temporary credentials stop working. Zero means never.
+	Expires time.Time
+}
+
+// awsCredentialChain resolves credentials the way the AWS SDKs do: from the environment,
+// the shared credentials file, a web identity token (EKS), the ECS container endpoint or
+// the EC2 instance metadata service. Temporary credentials are cached until shortly
+// before they expire.
+type awsCredentialChain struct {
+	client *http.Client
+	region string
+
+	mu    sync.Mutex
+	creds AWSCredentials
+}
+
+// get returns cached credentials or resolves new ones.
+func (c *awsCredentialChain) get(ctx context.Context) (AWSCredentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.creds.AccessKeyID != "" && (c.creds.Expires.IsZero() || time.Until(c.creds.Expires) > 5*time.Minute) {
+		return c.creds, nil
+	}
+
+	sources := []func(context.Context) (AWSCredentials, bool, error){
+		c.fromEnv, c.fromFile, c.fromWebIdentity, c.fromContainer, c.fromInstance,
+	}
+	for _, source := range sources {
+		creds, ok, err := source(ctx)
+		if err != nil {
+			return creds, fmt.Errorf("slogger: aws credentials: %w", err)
+		}
+		if ok {
+			c.creds = creds
+			return creds, nil
+		}
+	}
+	return AWSCredentials{}, errors.New("slogger: aws credentials: none found")
+}
+
+func (c *awsCredentialChain) fromEnv(context.Context) (AWSCredentials, bool, error) {
+	creds := AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	return creds, creds.AccessKeyID != "" && creds.SecretAccessKey != "", nil
+}
+
+// fromFile reads the profile named by AWS_PROFILE, or "default", from the shared
+// credentials file.
+func (c *awsCredentialChain) fromFile(context.Context) (AWSCredentials, bool, error) {
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return AWSCredentials{}, false, nil
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return AWSCredentials{}, false, nil
+	}
+	defer f.Close()
+
+	var creds AWSCredentials
+	section := ""
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || section != profile {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "aws_access_key_id":
+			creds.AccessKeyID = value
+		case "aws_secret_access_key":
+			creds.SecretAccessKey = value
+		case "aws_session_token":
+			creds.SessionToken = value
+		}
+	}
+	return creds, creds.AccessKeyID != "" && creds.SecretAccessKey != "", sc.Err()
+}
+
+// fromWebIdentity exchanges the token named by AWS_WEB_IDENTITY_TOKEN_FILE for
+// credentials of AWS_ROLE_ARN, as set up for EKS service accounts.
+func (c *awsCredentialChain) fromWebIdentity(ctx context.Context) (AWSCredentials, bool, error) {
+	tokenFile, role := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), os.Getenv("AWS_ROLE_ARN")
+	if tokenFile == "" || role == "" {
+		return AWSCredentials{}, false, nil
+	}
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return AWSCredentials{}, false, err
+	}
+	session := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if session == "" {
+		session = "slogger"
+	}
+
+	q := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {role},
+		"RoleSessionName":  {session},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	endpoint := "https://sts.amazonaws.com/"
+	if c.region != "" {
+		endpoint = "https://sts." + c.region + ".amazonaws.com/"
+	}
+	body, err := awsGet(ctx, c.client, endpoint+"?"+q.Encode(), nil)
+	if err != nil {
+		return AWSCredentials{}, false, err
+	}
+
+	var resp struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.Unmarshal(body, &resp); err != nil {
+		return AWSCredentials{}, false, err
+	}
+	cr := resp.Credentials
+	return AWSCredentials{cr.AccessKeyID, cr.SecretAccessKey, cr.SessionToken, cr.Expiration}, true, nil
+}
+
+// fromContainer asks the ECS or EKS Pod Identity credentials endpoint.
+func (c *awsCredentialChain) fromContainer(ctx context.Context) (AWSCredentials, bool, error) {
+	endpoint := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if rel := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); rel != "" {
+		endpoint = "http://169.254.170.2" + rel
+	}
+	if endpoint == "" {
+		return AWSCredentials{}, false, nil
+	}
+	headers := map[string]string{}
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if file := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return AWSCredentials{}, false, err
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	if token != "" {
+		headers["Authorization"] = token
+	}
+	body, err := awsGet(ctx, c.client, endpoint, headers)
+	if err != nil {
+		return AWSCredentials{}, false, err
+	}
+	creds, err := parseAWSCredentials(body)
+	return creds, err == nil, err
+}
+
+// instanceMetadata is the address of the EC2 instance metadata service.
+const instanceMetadata = "http://169.254.169.254"
+
+// fromInstance asks the EC2 instance metadata service for the credentials of the instance
+// role, using an IMDSv2 session token.
+func (c *awsCredentialChain) fromInstance(ctx context.Context) (AWSCredentials, bool, error) {
+	if strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return AWSCredentials{}, false, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, instanceMetadata+"/latest/api/token", nil)
+	if err != nil {
+		return AWSCredentials{}, false, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "21600")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return AWSCredentials{}, false, nil // not on EC2
+	}
+	token, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return AWSCredentials{}, false, nil
+	}
+
+	headers := map[string]string{"X-aws-ec2-metadata-token": string(token)}
+	base := instanceMetadata + "/latest/meta-data/iam/security-credentials/"
+	role, err := awsGet(ctx, c.client, base, headers)
+	if err != nil {
+		return AWSCredentials{}, false, nil // no instance role
+	}
+	name, _, _ := strings.Cut(strings.TrimSpace(string(role)), "\n")
+	body, err := awsGet(ctx, c.client, base+name, headers)
+	if err != nil {
+		return AWSCredentials{}, false, err
+	}
+	creds, err := parseAWSCredentials(body)
+	return creds, err == nil, err
+}
+
+// parseAWSCredentials decodes the JSON returned by the container and instance endpoints.
+func parseAWSCredentials(body []byte) (AWSCredentials, error) {
+	var v struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		Token           string    `json:"Token"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return AWSCredentials{}, err
+	}
+	if v.AccessKeyID == "" {
+		return AWSCredentials{}, errors.New("empty credentials")
+	}
+	return AWSCredentials{v.AccessKeyID, v.SecretAccessKey, v.Token, v.Expiration}, nil
+}
+
+// awsGet fetches url and returns the body of a successful response.
+func awsGet(ctx context.Context, client *http.Client, url string, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Status, body[:min(len(body), 512)])
+	}
+	return body, nil
+}
+
+// awsRegion returns the region from AWS_REGION or AWS_DEFAULT_REGION.
+func awsRegion() string {
+	if r := os.Getenv("AWS_REGION"); r != "" {
+		return r
+	}
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
+
+// signAWS signs req with Signature Version 4 for the given region and service.
+func signAWS(req *http.Request, body []byte, creds AWSCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	payload := sha256.Sum256(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{"host": host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonical := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signed,
+		hex.EncodeToString(payload[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	hash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := []byte("AWS4" + creds.SecretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signed+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
@@ -0,0 +1,106 @@
+package slogger
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The requests of the Signature Version 4 test suite published by AWS, and the example
+// of its documentation.
+func TestSignAWS(t *testing.T) {
+	suite := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name, method, url string
+		header            map[string]string
+		service           string
+		want              string
+	}{
+		{
+			name:    "get-vanilla",
+			method:  http.MethodGet,
+			url:     "https://example.amazonaws.com/",
+			service: "service",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:    "get-vanilla-query-order-key-case",
+			method:  http.MethodGet,
+			url:     "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			service: "service",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name:    "iam-list-users",
+			method:  http.MethodGet,
+			url:     "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08",
+			header:  map[string]string{"Content-Type": "application/x-www-form-urlencoded; charset=utf-8"},
+			service: "iam",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+				"SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			signAWS(req, nil, suite, "us-east-1", tt.service, now)
+			if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+				t.Errorf("X-Amz-Date = %q", got)
+			}
+			if got := req.Header.Get("Authorization"); got != tt.want {
+				t.Errorf("Authorization = %s\nwant            %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignAWSSessionToken(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "https://logs.eu-west-1.amazonaws.com/", nil)
+	creds := AWSCredentials{AccessKeyID: "ASIAEXAMPLE", SecretAccessKey: "secret", SessionToken: "token"}
+	signAWS(req, []byte(`{}`), creds, "eu-west-1", "logs", time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600)))
+
+	if got := req.Header.Get("X-Amz-Security-Token"); got != "token" {
+		t.Errorf("X-Amz-Security-Token = %q", got)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20240102T020405Z" {
+		t.Errorf("X-Amz-Date = %q, want the time in UTC", got)
+	}
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=ASIAEXAMPLE/20240102/eu-west-1/logs/aws4_request, SignedHeaders=host;x-amz-date;x-amz-security-token, Signature=") {
+		t.Errorf("Authorization = %s", auth)
+	}
+}
+
+func TestParseAWSCredentials(t *testing.T) {
+	creds, err := parseAWSCredentials([]byte(`{
+		"Code": "Success",
+		"LastUpdated": "2024-01-02T03:04:05Z",
+		"Type": "AWS-HMAC",
+		"AccessKeyId": "ASIAEXAMPLE",
+		"SecretAccessKey": "secret",
+		"Token": "token",
+		"Expiration": "2024-01-02T09:04:05Z"
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := AWSCredentials{"ASIAEXAMPLE", "secret", "token", time.Date(2024, 1, 2, 9, 4, 5, 0, time.UTC)}
+	if creds != want {
+		t.Errorf("credentials = %+v, want %+v", creds, want)
+	}
+
+	for _, body := range []string{``, `{`, `{"Code":"Success"}`, `{"AccessKeyId":"A","Expiration":"tomorrow"}`} {
+		if _, err := parseAWSCredentials([]byte(body)); err == nil {
+			t.Errorf("parseAWSCredentials(%q): no error", body)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AzureMonitorOptions configures an AzureMonitorSink.
+type AzureMonitorOptions struct {
+	// WorkspaceID and SharedKey identify the Log Analytics workspace; the key is the
+	// base64 primary or secondary key of the workspace.
+	WorkspaceID string
+	SharedKey   string
+
+	// LogType names the custom log; Log Analytics stores records in the table
+	// <LogType>_CL. Characters other than letters, digits and underscores are replaced
+	// with underscores. Defaults to "slogger".
+	LogType string
+
+	// ResourceID, if set, associates the records with an Azure resource for
+	// resource-context queries.
+	ResourceID string
+
+	// Endpoint overrides the API URL. Defaults to
+	// https://<WorkspaceID>.ods.opinsights.azure.com.
+	Endpoint string
+
+	// BatchSize, FlushInterval, MaxInFlight, MaxRetries, MaxBuffered, DeadLetter and Breaker
+	// tune batching as documented on WebhookOptions. Throttling and server errors are
+	// retried with backoff.
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxInFlight   int
+	MaxRetries    int
+	MaxBuffered   int
+	DeadLetter    *DeadLetter
+	Breaker       *CircuitBreaker
+
+	// Client sends the requests. Defaults to a client with a ten second timeout.
+	Client *http.Client
+
+	// OnError, if not nil, receives failed deliveries.
+	OnError func(error)
+}
+
+// AzureMonitorSink is an io.Writer posting the JSON records written by a FormatJSON
+// handler to the Azure Monitor HTTP Data Collector API. The record time becomes
+// TimeGenerated. Call Close to send the remaining records.
+type AzureMonitorSink struct {
+	*batcher
+	opts AzureMonitorOptions
+	key  []byte
+}
+
+// NewAzureMonitorHandler creates a JSON handler posting records to Log Analytics and
+// returns the sink so it can be closed on shutdown. Handler options are applied as for
+// NewPrettyHandler.
+func NewAzureMonitorHandler(az AzureMonitorOptions, opts ...Option) (*PrettyHandler, *AzureMonitorSink, error) {
+	s, err := NewAzureMonitorSink(az)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewPrettyHandler(s, append(opts, WithFormat(FormatJSON), WithTimeFormat(TimeFormatRFC3339Nano))...), s, nil
+}
+
+// NewAzureMonitorSink starts a sink with the given options.
+func NewAzureMonitorSink(opts AzureMonitorOptions) (*AzureMonitorSink, error) {
+	if opts.WorkspaceID == "" {
+		return nil, errors.New("slogger: azure monitor: no workspace ID")
+	}
+	key, err := base64.StdEncoding.DecodeString(opts.SharedKey)
+	if err != nil || len(key) == 0 {
+		return nil, errors.New("slogger: azure monitor: invalid shared key")
+	}
+	opts.LogType = azureLogType(opts.LogType)
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://" + opts.WorkspaceID + ".ods.opinsights.azure.com"
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	s := &AzureMonitorSink{opts: opts, key: key}
+	s.batcher = newBatcher(batchOptions{
+		size:        opts.BatchSize,
+		interval:    opts.FlushInterval,
+		maxInFlight: opts.MaxInFlight,
+		maxRetries:  opts.MaxRetries,
+		maxBuffered: opts.MaxBuffered,
+		deadLetter:  opts.DeadLetter,
+		breaker:     opts.Breaker,
+		onError:     opts.OnError,
+		send:        s.send,
+	})
+	return s, nil
+}
+
+// azureLogType makes name a valid custom log type of at most 100 characters.
+func azureLogType(name string) string {
+	if name == "" {
+		return "slogger"
+	}
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	return name[:min(len(name), 100)]
+}
+
+// send posts one batch as a JSON array.
+func (s *AzureMonitorSink) send(ctx context.Context, records [][]byte) error {
+	body := append([]byte{'['}, bytes.Join(records, []byte{','})...)
+	body = append(body, ']')
+
+	date := time.Now().UTC().Format(http.TimeFormat)
+	headers := map[string]string{
+		"Authorization":        "SharedKey " + s.opts.WorkspaceID + ":" + s.signature(len(body), date),
+		"Log-Type":             s.opts.LogType,
+		"x-ms-date":            date,
+		"time-generated-field": slog.TimeKey,
+	}
+	if s.opts.ResourceID != "" {
+		headers["x-ms-AzureResourceId"] = s.opts.ResourceID
+	}
+	url := strings.TrimSuffix(s.opts.Endpoint, "/") + "/api/logs?api-version=2016-04-01"
+	if _, err := httpPost(ctx, s.opts.Client, url, "application/json", headers, body, false); err != nil {
+		return fmt.Errorf("slogger: azure monitor: %w", err)
+	}
+	return nil
+}
+
+// signature signs a request of the given length and date with the shared key.
+func (s *AzureMonitorSink) signature(length int, date string) string {
+	toSign := "POST\n" + strconv.Itoa(length) + "\napplication/json\nx-ms-date:" + date + "\n/api/logs"
+	m := hmac.New(sha256.New, s.key)
+	m.Write([]byte(toSign))
+	return base64.StdEncoding.EncodeToString(m.Sum(nil))
+}
@@ -0,0 +1,58 @@
+package slogger
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// baggageKey is the context key of ContextWithBaggage.
+type baggageKey struct{}
+
+// ContextWithBaggage returns a copy of ctx carrying the baggage members, in addition to
+// those of ctx, for services propagating baggage without OpenTelemetry.
+func ContextWithBaggage(ctx context.Context, members map[string]string) context.Context {
+	prev, _ := ctx.Value(baggageKey{}).(map[string]string)
+	all := make(map[string]string, len(prev)+len(members))
+	for k, v := range prev {
+		all[k] = v
+	}
+	for k, v := range members {
+		all[k] = v
+	}
+	return context.WithValue(ctx, baggageKey{}, all)
+}
+
+// baggageFunc is the function set by SetBaggageFunc.
+var baggageFunc atomic.Pointer[func(context.Context, string) (string, bool)]
+
+// SetBaggageFunc registers f to look up a baggage member in a context, so the handlers
+// log the members allowed by WithBaggage. This package doesn't depend on OpenTelemetry;
+// its baggage is one adapter away:
+//
+//	slogger.SetBaggageFunc(func(ctx context.Context, key string) (string, bool) {
+//		v := baggage.FromContext(ctx).Member(key).Value()
+//		return v, v != ""
+//	})
+//
+// Passing nil removes the function.
+func SetBaggageFunc(f func(ctx context.Context, key string) (string, bool)) {
+	if f == nil {
+		baggageFunc.Store(nil)
+		return
+	}
+	baggageFunc.Store(&f)
+}
+
+// BaggageFromContext returns the baggage member key carried by ctx: the one stored by
+// ContextWithBaggage, or else the one found by the function of SetBaggageFunc.
+func BaggageFromContext(ctx context.Context, key string) (string, bool) {
+	if members, ok := ctx.Value(baggageKey{}).(map[string]string); ok {
+		if v, ok := members[key]; ok {
+			return v, true
+		}
+	}
+	if f := baggageFunc.Load(); f != nil {
+		return (*f)(ctx, key)
+	}
+	return "", false
+}
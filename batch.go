@@ -0,0 +1,356 @@
+package slogger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// batchOptions configures a batcher. Zero values select the defaults documented on the
+// sinks built on it.
+type batchOptions struct {
+	size        int
+	maxBytes    int // zero leaves batches unbounded in bytes
+	interval    time.Duration
+	maxInFlight int
+	maxRetries  int
+	maxBuffered int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	deadLetter  *DeadLetter
+	breaker     *CircuitBreaker
+	onError     func(error)
+
+	// send delivers one batch. Errors wrapped in retryError are retried with backoff,
+	// others drop the batch.
+	send func(ctx context.Context, records [][]byte) error
+}
+
+// retryError marks a failed delivery as worth retrying, after a delay requested by the
+// server if after is positive. If records is not nil, only those are retried.
+type retryError struct {
+	err     error
+	after   time.Duration
+	records [][]byte
+}
+
+func (e *retryError) Error() string { return e.err.Error() }
+func (e *retryError) Unwrap() error { return e.err }
+
+// batcher collects records written by a handler and delivers them in batches from
+// background goroutines, with at most maxInFlight deliveries at once. It is the engine of
+// the HTTP based sinks.
+type batcher struct {
+	opts batchOptions
+
+	mu           sync.Mutex
+	pending      [][]byte
+	pendingBytes int
+	closed       bool
+
+	kick     chan struct{}
+	done     chan struct{}
+	inFlight chan struct{}
+	sends    sync.WaitGroup
+	wg       sync.WaitGroup
+	once     sync.Once
+}
+
+// newBatcher applies the defaults to opts and starts the batching goroutine.
+func newBatcher(opts batchOptions) *batcher {
+	if opts.size <= 0 {
+		opts.size = 500
+	}
+	if opts.interval <= 0 {
+		opts.interval = time.Second
+	}
+	if opts.maxInFlight <= 0 {
+		opts.maxInFlight = 2
+	}
+	if opts.maxRetries < 0 {
+		opts.maxRetries = 0
+	} else if opts.maxRetries == 0 {
+		opts.maxRetries = 5
+	}
+	if opts.maxBuffered <= 0 {
+		opts.maxBuffered = 10 * opts.size
+	}
+	if opts.minBackoff <= 0 {
+		opts.minBackoff = 100 * time.Millisecond
+	}
+	if opts.maxBackoff < opts.minBackoff {
+		opts.maxBackoff = max(30*time.Second, opts.minBackoff)
+	}
+
+	b := &batcher{
+		opts:     opts,
+		kick:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		inFlight: make(chan struct{}, opts.maxInFlight),
+	}
+	b.wg.Add(1)
+	go b.run()
+	return b
+}
+
+// Write queues one record, without its trailing newline. It fails with os.ErrClosed once
+// the batcher is closed.
+func (b *batcher) Write(p []byte) (int, error) {
+	rec := bytes.Clone(bytes.TrimSuffix(p, []byte{'\n'}))
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return 0, os.ErrClosed
+	}
+	if len(b.pending) >= b.opts.maxBuffered {
+		b.mu.Unlock()
+		if b.opts.deadLetter != nil {
+			if _, err := b.opts.deadLetter.spool([][]byte{rec}); err == nil {
+				return len(p), nil
+			}
+		}
+		return 0, ErrBufferFull
+	}
+	b.pending = append(b.pending, rec)
+	b.pendingBytes += len(rec)
+	full := len(b.pending) >= b.opts.size || b.opts.maxBytes > 0 && b.pendingBytes >= b.opts.maxBytes
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.kick <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Flush delivers the queued records and waits for every delivery in flight, or until
+// ctx is done. Once ctx is done, the batches it dispatched give up waiting for a retry.
+func (b *batcher) Flush(ctx context.Context) error {
+	b.dispatch(ctx)
+	wait := make(chan struct{})
+	go func() {
+		b.sends.Wait()
+		close(wait)
+	}()
+	select {
+	case <-wait:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close delivers the remaining records and stops the batcher. Each batch gets a single
+// attempt: deliveries waiting for a retry give up, spooling to the dead letter, if any.
+func (b *batcher) Close() error {
+	var err error
+	b.once.Do(func() {
+		b.mu.Lock()
+		b.closed = true
+		b.mu.Unlock()
+		close(b.done)
+		b.wg.Wait()
+		err = b.Flush(context.Background())
+	})
+	return err
+}
+
+func (b *batcher) run() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.opts.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+		case <-b.kick:
+		}
+		b.dispatch(context.Background())
+	}
+}
+
+// dispatch hands the queued records to delivery goroutines, one per batch, waiting for
+// a free in-flight slot before starting each of them.
+func (b *batcher) dispatch(ctx context.Context) {
+	for {
+		b.mu.Lock()
+		n, size := 0, 0
+		for n < len(b.pending) && n < b.opts.size {
+			if b.opts.maxBytes > 0 && n > 0 && size+len(b.pending[n]) > b.opts.maxBytes {
+				break
+			}
+			size += len(b.pending[n])
+			n++
+		}
+		batch := b.pending[:n:n]
+		b.pending = b.pending[n:]
+		b.pendingBytes -= size
+		b.mu.Unlock()
+		if n == 0 {
+			return
+		}
+
+		b.inFlight <- struct{}{}
+		b.sends.Add(1)
+		go func() {
+			defer func() { <-b.inFlight; b.sends.Done() }()
+			if err := b.deliver(ctx, batch); err != nil && b.opts.onError != nil {
+				b.opts.onError(err)
+			}
+		}()
+	}
+}
+
+// deliver sends one batch, retrying retryable failures with exponential backoff. A delay
+// requested by the server is capped at maxBackoff, and the wait ends early when the
+// batcher is closed or ctx is done. A batch that still fails, or is refused by an open
+// circuit, is spooled to the dead letter, if any.
+func (b *batcher) deliver(ctx context.Context, batch [][]byte) error {
+	backoff := b.opts.minBackoff
+	for attempt := 0; ; attempt++ {
+		err := b.send(batch)
+		if err == nil {
+			return nil
+		}
+		var retry *retryError
+		if !errors.As(err, &retry) || attempt >= b.opts.maxRetries {
+			return b.fail(err, batch)
+		}
+
+		delay := backoff
+		if retry.after > 0 {
+			delay = min(retry.after, b.opts.maxBackoff)
+		}
+		if retry.records != nil {
+			batch = retry.records
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-b.done:
+			timer.Stop()
+			return b.fail(err, batch)
+		case <-ctx.Done():
+			timer.Stop()
+			return b.fail(err, batch)
+		}
+		backoff = min(2*backoff, b.opts.maxBackoff)
+	}
+}
+
+// fail spools a batch that could not be delivered to the dead letter, if any, and returns
+// err describing what became of it.
+func (b *batcher) fail(err error, batch [][]byte) error {
+	if b.opts.deadLetter != nil {
+		n, serr := b.opts.deadLetter.spool(batch)
+		err = fmt.Errorf("%w (spooled %d records to %s)", err, n, b.opts.deadLetter.Path())
+		return errors.Join(err, serr)
+	}
+	return fmt.Errorf("%w (dropped %d records)", err, len(batch))
+}
+
+// send sends one batch through the circuit breaker, if any. Only retryable failures count
+// against the breaker; a rejected batch shows the backend is up.
+func (b *batcher) send(batch [][]byte) error {
+	cb := b.opts.breaker
+	if cb == nil {
+		return b.opts.send(context.Background(), batch)
+	}
+	if !cb.allow() {
+		return ErrCircuitOpen
+	}
+	err := b.opts.send(context.Background(), batch)
+	var retry *retryError
+	if errors.As(err, &retry) {
+		cb.done(err)
+	} else {
+		cb.done(nil)
+	}
+	return err
+}
+
+// httpPost sends body to url and returns the response body of a successful request. It
+// classifies failures: network errors, 429 and 5xx responses are retryable, honoring a
+// Retry-After header given in seconds.
+func httpPost(ctx context.Context, client *http.Client, url, contentType string, headers map[string]string, body []byte, compress bool) ([]byte, error) {
+	if compress {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(body)
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		body = buf.Bytes()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &retryError{err: err}
+	}
+	defer resp.Body.Close()
+	msg, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if resp.StatusCode/100 == 2 {
+		return msg, err
+	}
+
+	err = fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg[:min(len(msg), 512)]))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		after, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return nil, &retryError{err: err, after: time.Duration(after) * time.Second}
+	}
+	return nil, err
+}
+
+// maxResponseSize bounds how much of a response body httpPost reads.
+const maxResponseSize = 16 << 20
+
+// templatePart is a literal or a braced placeholder of a template such as an index name
+// or a subject.
+type templatePart struct {
+	text   string
+	braced bool
+}
+
+// parseTemplate splits a template into literals and the contents of braced placeholders.
+func parseTemplate(tmpl string) []templatePart {
+	var parts []templatePart
+	for tmpl != "" {
+		start := strings.IndexByte(tmpl, '{')
+		end := strings.IndexByte(tmpl, '}')
+		if start < 0 || end < start {
+			parts = append(parts, templatePart{text: tmpl})
+			break
+		}
+		if start > 0 {
+			parts = append(parts, templatePart{text: tmpl[:start]})
+		}
+		parts = append(parts, templatePart{text: tmpl[start+1 : end], braced: true})
+		tmpl = tmpl[end+1:]
+	}
+	return parts
+}
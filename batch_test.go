@@ -0,0 +1,115 @@
+package slogger
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// unavailable fails every delivery with a retryable error asking to wait an hour.
+func unavailable(calls *atomic.Int32) func(context.Context, [][]byte) error {
+	return func(context.Context, [][]byte) error {
+		calls.Add(1)
+		return &retryError{err: errors.New("503 Service Unavailable"), after: time.Hour}
+	}
+}
+
+func TestBatcherWriteAfterClose(t *testing.T) {
+	var sent atomic.Int32
+	b := newBatcher(batchOptions{send: func(_ context.Context, records [][]byte) error {
+		sent.Add(int32(len(records)))
+		return nil
+	}})
+	b.Write([]byte("before\n"))
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := b.Write([]byte("after\n")); n != 0 || !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Write after Close = %d, %v, want os.ErrClosed", n, err)
+	}
+	if n := sent.Load(); n != 1 {
+		t.Errorf("%d records sent, want the one written before Close", n)
+	}
+}
+
+func TestBatcherRetryAfterCapped(t *testing.T) {
+	var calls atomic.Int32
+	b := newBatcher(batchOptions{
+		send:       unavailable(&calls),
+		maxRetries: 2,
+		minBackoff: 10 * time.Millisecond,
+		maxBackoff: 20 * time.Millisecond,
+	})
+	defer b.Close()
+	b.Write([]byte("x"))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.Flush(ctx); err != nil {
+		t.Fatalf("Flush = %v: a Retry-After of an hour was not capped at maxBackoff", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("%d attempts, want 3", n)
+	}
+}
+
+func TestBatcherRetryWaitInterrupted(t *testing.T) {
+	var mu sync.Mutex
+	var errs []string
+	onError := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err.Error())
+	}
+
+	t.Run("flush context", func(t *testing.T) {
+		errs = nil
+		var calls atomic.Int32
+		b := newBatcher(batchOptions{send: unavailable(&calls), onError: onError})
+		defer b.Close()
+		b.Write([]byte("x"))
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := b.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Flush = %v, want the deadline of its context", err)
+		}
+		if err := b.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if calls.Load() != 1 || len(errs) != 1 || !strings.HasSuffix(errs[0], "(dropped 1 records)") {
+			t.Errorf("%d attempts, errors %q", calls.Load(), errs)
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		errs = nil
+		var calls atomic.Int32
+		b := newBatcher(batchOptions{send: unavailable(&calls), onError: onError, interval: 10 * time.Millisecond})
+		b.Write([]byte("x"))
+		for deadline := time.Now().Add(5 * time.Second); calls.Load() == 0 && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+		}
+
+		closed := make(chan error, 1)
+		go func() { closed <- b.Close() }()
+		select {
+		case err := <-closed:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Close waited for the retry of a delivery")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if len(errs) != 1 || !strings.HasSuffix(errs[0], "(dropped 1 records)") {
+			t.Errorf("errors = %q", errs)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package slogger
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// BatchWriterOptions configures a BatchWriter.
+type BatchWriterOptions struct {
+	// Send delivers one batch of records, each without its trailing newline. It is called
+	// from background goroutines, at most MaxInFlight at a time. Failed batches are
+	// retried with exponential backoff.
+	Send func(ctx context.Context, records [][]byte) error
+
+	// BatchSize is the largest number of records per batch. Defaults to 500.
+	BatchSize int
+
+	// MaxBytes, if positive, is the largest total size of the records of a batch. A single
+	// larger record is sent in a batch of its own.
+	MaxBytes int
+
+	// FlushInterval, MaxInFlight, MaxRetries, MaxBuffered, DeadLetter and Breaker tune
+	// batching as documented on WebhookOptions.
+	FlushInterval time.Duration
+	MaxInFlight   int
+	MaxRetries    int
+	MaxBuffered   int
+	DeadLetter    *DeadLetter
+	Breaker       *CircuitBreaker
+
+	// OnError, if not nil, receives failed deliveries.
+	OnError func(error)
+}
+
+// BatchWriter is an io.Writer grouping the records written by a handler into batches by
+// count, size and time, for outputs with a high cost per call such as an HTTP API, a
+// message broker or a database. It is the engine of the sinks of this package:
+//
+//	w := slogger.NewBatchWriter(slogger.BatchWriterOptions{
+//		Send: func(ctx context.Context, records [][]byte) error {
+//			return store.InsertMany(ctx, records)
+//		},
+//	})
+//	logger := slog.New(slogger.NewPrettyHandler(w, slogger.WithFormat(slogger.FormatJSON)))
+//
+// Call Flush to deliver the queued records and Close on shutdown.
+type BatchWriter struct {
+	*batcher
+}
+
+// NewBatchWriter starts a batch writer with the given options.
+func NewBatchWriter(opts BatchWriterOptions) (*BatchWriter, error) {
+	if opts.Send == nil {
+		return nil, errors.New("slogger: batch writer: no Send function")
+	}
+	send := opts.Send
+	return &BatchWriter{newBatcher(batchOptions{
+		size:        opts.BatchSize,
+		maxBytes:    opts.MaxBytes,
+		interval:    opts.FlushInterval,
+		maxInFlight: opts.MaxInFlight,
+		maxRetries:  opts.MaxRetries,
+		maxBuffered: opts.MaxBuffered,
+		deadLetter:  opts.DeadLetter,
+		breaker:     opts.Breaker,
+		onError:     opts.OnError,
+		send: func(ctx context.Context, records [][]byte) error {
+			if err := send(ctx, records); err != nil {
+				return &retryError{err: err}
+			}
+			return nil
+		},
+	})}, nil
+}
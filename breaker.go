@@ -0,0 +1,130 @@
+package slogger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of writing while a CircuitBreaker is open.
+var ErrCircuitOpen = errors.New("slogger: circuit open")
+
+// CircuitBreakerOptions configures a CircuitBreaker.
+type CircuitBreakerOptions struct {
+	// Failures is the number of consecutive failures opening the circuit. Defaults to 5.
+	Failures int
+
+	// ProbeInterval is how long the circuit stays open before a single write or batch is
+	// let through to probe the backend. Defaults to 30 seconds.
+	ProbeInterval time.Duration
+
+	// OnStateChange, if not nil, is called when the circuit opens or closes.
+	OnStateChange func(open bool)
+}
+
+// CircuitBreaker stops talking to a failing log backend: after a number of consecutive
+// failures it opens and fails writes immediately with ErrCircuitOpen, so a down backend
+// adds neither latency nor blocked goroutines to the application. Once ProbeInterval has
+// passed a single probe is let through, closing the circuit again if it succeeds.
+//
+// Set it as the Breaker of a batching sink such as LokiOptions, where batches refused by
+// an open circuit go to the DeadLetter if set, or wrap any writer with Wrap.
+type CircuitBreaker struct {
+	opts CircuitBreakerOptions
+
+	mu       sync.Mutex
+	failures int
+	open     bool
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker returns a closed circuit breaker.
+func NewCircuitBreaker(opts CircuitBreakerOptions) *CircuitBreaker {
+	if opts.Failures <= 0 {
+		opts.Failures = 5
+	}
+	if opts.ProbeInterval <= 0 {
+		opts.ProbeInterval = 30 * time.Second
+	}
+	return &CircuitBreaker{opts: opts}
+}
+
+// Open reports whether the circuit is open.
+func (cb *CircuitBreaker) Open() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.open
+}
+
+// allow reports whether a call may go through: always while closed, and once per
+// ProbeInterval while open.
+func (cb *CircuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if !cb.open {
+		return true
+	}
+	if cb.probing || time.Since(cb.openedAt) < cb.opts.ProbeInterval {
+		return false
+	}
+	cb.probing = true
+	return true
+}
+
+// done records the outcome of a call let through by allow.
+func (cb *CircuitBreaker) done(err error) {
+	cb.mu.Lock()
+	changed := false
+	if err == nil {
+		cb.failures = 0
+		changed = cb.open
+		cb.open, cb.probing = false, false
+	} else {
+		cb.failures++
+		if cb.open || cb.failures >= cb.opts.Failures {
+			changed = !cb.open
+			cb.open, cb.probing, cb.openedAt = true, false, time.Now()
+		}
+	}
+	open := cb.open
+	cb.mu.Unlock()
+
+	if changed && cb.opts.OnStateChange != nil {
+		cb.opts.OnStateChange(open)
+	}
+}
+
+// Wrap returns a writer passing writes to w while the circuit allows them.
+func (cb *CircuitBreaker) Wrap(w io.Writer) io.Writer {
+	return &breakerWriter{cb: cb, w: w}
+}
+
+type breakerWriter struct {
+	cb *CircuitBreaker
+	w  io.Writer
+}
+
+func (bw *breakerWriter) Write(p []byte) (int, error) {
+	if !bw.cb.allow() {
+		return 0, ErrCircuitOpen
+	}
+	n, err := bw.w.Write(p)
+	bw.cb.done(err)
+	return n, err
+}
+
+// Flush flushes the wrapped writer if it buffers records.
+func (bw *breakerWriter) Flush(ctx context.Context) error {
+	return flushNode(ctx, bw.w)
+}
+
+// Close closes the wrapped writer if it is an io.Closer.
+func (bw *breakerWriter) Close() error {
+	if c, ok := bw.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
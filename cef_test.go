@@ -0,0 +1,36 @@
+package slogger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestCEFWithoutMessage(t *testing.T) {
+	dropMessage := func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 && (a.Key == slog.MessageKey || a.Key == slog.TimeKey) {
+			return slog.Attr{}
+		}
+		return a
+	}
+	tests := []struct {
+		name  string
+		attrs []any
+		want  string
+	}{
+		{"no signature", nil, "CEF:0|Acme|App|1.0|-|-|3|user=bob"},
+		{"signature", []any{"signature_id", "login"}, "CEF:0|Acme|App|1.0|login|-|3|user=bob"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := NewCEFHandler(&buf, CEFOptions{Vendor: "Acme", Product: "App", Version: "1.0"},
+				WithReplaceAttr(dropMessage), WithSource(false))
+			slog.New(h).Info("dropped", append(tt.attrs, "user", "bob")...)
+			if got := strings.TrimSpace(buf.String()); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
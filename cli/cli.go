@@ -0,0 +1,142 @@
+// Package cli wires slogger into command-line applications built with cobra and viper.
+package cli
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/StasTolmachov/slogger"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Flag names registered by BindCobra and the viper keys read by FromViper.
+const (
+	FlagLevel  = "log-level"
+	FlagFormat = "log-format"
+	FlagOutput = "log-output"
+
+	KeyLevel  = "log.level"
+	KeyFormat = "log.format"
+	KeyOutput = "log.output"
+	KeyColor  = "log.color"
+	KeyRedact = "log.redact"
+)
+
+// BindCobra adds --log-level, --log-format and --log-output as persistent flags of cmd
+// and configures the global slogger logger from them in the PersistentPreRunE hook of cmd,
+// before the hook cmd already had, if any; an invalid flag value is returned as the error
+// of the command. A subcommand with a persistent pre-run hook of its own replaces that of
+// cmd unless cobra.EnableTraverseRunHooks is set. slogger.Close releases the files the
+// logger opened.
+func BindCobra(cmd *cobra.Command) {
+	flags := cmd.PersistentFlags()
+	flags.String(FlagLevel, "info", "minimum log level: debug, info, warn, error or fatal")
+	flags.String(FlagFormat, "pretty", "log format: pretty, json, text, logfmt, ecs, gelf, gcp or syslog")
+	flags.StringSlice(FlagOutput, []string{"stdout"}, "log outputs: stdout, stderr or file paths")
+
+	preRunE, preRun := cmd.PersistentPreRunE, cmd.PersistentPreRun
+	cmd.PersistentPreRunE = func(c *cobra.Command, args []string) error {
+		cfg := slogger.Config{}
+		cfg.Level, _ = flags.GetString(FlagLevel)
+		cfg.Format, _ = flags.GetString(FlagFormat)
+		cfg.Outputs, _ = flags.GetStringSlice(FlagOutput)
+
+		l, err := slogger.NewFromConfig(cfg)
+		if err != nil {
+			return err
+		}
+		slogger.SetDefault(l)
+
+		switch {
+		case preRunE != nil:
+			return preRunE(c, args)
+		case preRun != nil:
+			preRun(c, args)
+		}
+		return nil
+	}
+}
+
+// BindViperFlags binds the flags registered by BindCobra to the viper keys read by
+// FromViper, so flags, environment and config file share one source of truth.
+func BindViperFlags(v *viper.Viper, cmd *cobra.Command) error {
+	flags := cmd.PersistentFlags()
+	for key, name := range map[string]string{
+		KeyLevel:  FlagLevel,
+		KeyFormat: FlagFormat,
+		KeyOutput: FlagOutput,
+	} {
+		if err := v.BindPFlag(key, flags.Lookup(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConfigFromViper reads the log.* keys of v into a slogger.Config.
+func ConfigFromViper(v *viper.Viper) slogger.Config {
+	cfg := slogger.Config{
+		Level:  v.GetString(KeyLevel),
+		Format: v.GetString(KeyFormat),
+		Redact: v.GetStringSlice(KeyRedact),
+	}
+	for _, name := range v.GetStringSlice(KeyOutput) {
+		for _, part := range strings.Split(name, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				cfg.Outputs = append(cfg.Outputs, part)
+			}
+		}
+	}
+	if v.IsSet(KeyColor) {
+		color := v.GetBool(KeyColor)
+		cfg.Color = &color
+	}
+	return cfg
+}
+
+// ViperReloader keeps the global slogger logger in sync with the log.* keys of a viper
+// instance.
+type ViperReloader struct {
+	*slogger.Reloader
+	v       *viper.Viper
+	onError func(error)
+}
+
+// FromViper configures the global slogger logger from the log.* keys of v. It leaves the
+// OnConfigChange callback of v alone, since viper keeps only one: register the
+// OnConfigChange method of the returned reloader, or call it from the callback of the
+// application, to apply the new settings in place on every change:
+//
+//	r, err := cli.FromViper(v, nil)
+//	...
+//	v.OnConfigChange(func(e fsnotify.Event) {
+//		r.OnConfigChange(e)
+//		// reload the rest of the application
+//	})
+//	v.WatchConfig()
+//
+// onError, if not nil, receives errors from later reloads.
+func FromViper(v *viper.Viper, onError func(error)) (*ViperReloader, error) {
+	r, err := slogger.NewReloader(ConfigFromViper(v))
+	if err != nil {
+		return nil, err
+	}
+	slogger.SetDefault(slog.New(r.Handler()))
+	return &ViperReloader{Reloader: r, v: v, onError: onError}, nil
+}
+
+// Reload applies the current log.* keys of v. On error the previous configuration stays
+// active.
+func (r *ViperReloader) Reload() error {
+	return r.Apply(ConfigFromViper(r.v))
+}
+
+// OnConfigChange reloads the configuration, passing errors to onError. It has the
+// signature of a viper OnConfigChange callback.
+func (r *ViperReloader) OnConfigChange(fsnotify.Event) {
+	if err := r.Reload(); err != nil && r.onError != nil {
+		r.onError(err)
+	}
+}
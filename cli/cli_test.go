@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/StasTolmachov/slogger"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// keepDefault restores the global logger at the end of the test, after closing the
+// files opened by the one installed meanwhile.
+func keepDefault(t *testing.T) {
+	prev := slogger.Default()
+	t.Cleanup(func() {
+		slogger.Close(context.Background())
+		slogger.SetDefault(prev)
+	})
+}
+
+// messages returns the msg of each JSON line of the file at path.
+func messages(t *testing.T, path string) []string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msgs []string
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		var rec struct{ Msg string }
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		msgs = append(msgs, rec.Msg)
+	}
+	return msgs
+}
+
+func TestBindCobra(t *testing.T) {
+	keepDefault(t)
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	var hooked, ran bool
+	root := &cobra.Command{
+		Use: "app",
+		PersistentPreRunE: func(*cobra.Command, []string) error {
+			hooked = true
+			return nil
+		},
+	}
+	root.AddCommand(&cobra.Command{
+		Use: "serve",
+		Run: func(*cobra.Command, []string) {
+			ran = true
+			slog.Debug("from the command")
+		},
+	})
+	BindCobra(root)
+
+	root.SetArgs([]string{"serve", "--log-level=debug", "--log-format=json", "--log-output=" + path})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !hooked || !ran {
+		t.Errorf("hook of the command ran: %v, command ran: %v", hooked, ran)
+	}
+	if err := slogger.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(t, path); len(got) != 1 || got[0] != "from the command" {
+		t.Errorf("records = %q", got)
+	}
+
+	// A second command binds on its own, without the hooks adding up.
+	other := &cobra.Command{Use: "other", Run: func(*cobra.Command, []string) {}}
+	BindCobra(other)
+	other.SetArgs([]string{"--log-level=loud"})
+	other.SilenceErrors, other.SilenceUsage = true, true
+	if err := other.Execute(); err == nil || !strings.Contains(err.Error(), "loud") {
+		t.Errorf("Execute with an invalid level = %v", err)
+	}
+}
+
+func TestConfigFromViperEnv(t *testing.T) {
+	t.Setenv("APP_LOG_LEVEL", "warn")
+	t.Setenv("APP_LOG_OUTPUT", "stderr, /var/log/app.log")
+	t.Setenv("APP_LOG_COLOR", "false")
+
+	v := viper.New()
+	v.SetEnvPrefix("app")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	cmd := &cobra.Command{Use: "app"}
+	BindCobra(cmd)
+	if err := BindViperFlags(v, cmd); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.PersistentFlags().Set(FlagFormat, "logfmt"); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := ConfigFromViper(v)
+	if cfg.Level != "warn" || cfg.Format != "logfmt" || strings.Join(cfg.Outputs, " ") != "stderr /var/log/app.log" {
+		t.Errorf("config = %+v", cfg)
+	}
+	if cfg.Color == nil || *cfg.Color {
+		t.Errorf("Color = %v, want false", cfg.Color)
+	}
+}
+
+func TestFromViperReload(t *testing.T) {
+	keepDefault(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	cfgPath := filepath.Join(dir, "config.json")
+	write := func(level string) {
+		b, _ := json.Marshal(map[string]any{"log": map[string]any{"level": level, "format": "json", "output": path}})
+		if err := os.WriteFile(cfgPath, b, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("info")
+	v := viper.New()
+	v.SetConfigFile(cfgPath)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	var errs []error
+	r, err := FromViper(v, func(err error) { errs = append(errs, err) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slog.Debug("hidden")
+	write("debug")
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	r.OnConfigChange(fsnotify.Event{Name: cfgPath, Op: fsnotify.Write})
+	slog.Debug("shown")
+
+	write("loud")
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	r.OnConfigChange(fsnotify.Event{Name: cfgPath, Op: fsnotify.Write})
+	slog.Debug("still shown")
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(messages(t, path), " "); got != "shown still shown" {
+		t.Errorf("records = %s", got)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "loud") {
+		t.Errorf("reload errors = %v", errs)
+	}
+}
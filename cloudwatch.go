@@ -0,0 +1,314 @@
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CloudWatchOptions configures a CloudWatchSink.
+type CloudWatchOptions struct {
+	// Region is the AWS region. Defaults to AWS_REGION or AWS_DEFAULT_REGION.
+	Region string
+
+	// LogGroup and LogStream receive the events. Both are created when missing.
+	// LogStream defaults to the host name.
+	LogGroup  string
+	LogStream string
+
+	// Credentials returns the keys used to sign requests. Defaults to the credential chain
+	// of the AWS SDKs: environment variables, the shared credentials file, web identity
+	// tokens, the ECS container endpoint and the EC2 instance role.
+	Credentials func(context.Context) (AWSCredentials, error)
+
+	// Endpoint overrides the service URL, for example for LocalStack. Defaults to
+	// https://logs.<region>.amazonaws.com.
+	Endpoint string
+
+	// BatchSize, FlushInterval, MaxRetries, MaxBuffered, DeadLetter and Breaker tune
+	// batching as documented on WebhookOptions. Batches are split to stay within the
+	// PutLogEvents limits of 10,000 events and 1 MiB. Throttling and server errors are
+	// retried with backoff.
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxRetries    int
+	MaxBuffered   int
+	DeadLetter    *DeadLetter
+	Breaker       *CircuitBreaker
+
+	// Client sends the requests. Defaults to a client with a ten second timeout.
+	Client *http.Client
+
+	// OnError, if not nil, receives failed deliveries.
+	OnError func(error)
+}
+
+// CloudWatchSink is an io.Writer sending the JSON records written by a FormatJSON
+// handler to AWS CloudWatch Logs with PutLogEvents. Call Close to send the remaining
+// records.
+type CloudWatchSink struct {
+	*batcher
+	opts CloudWatchOptions
+
+	token string // sequence token of the next PutLogEvents call, if any
+}
+
+// NewCloudWatchHandler creates a JSON handler sending records to CloudWatch Logs and
+// returns the sink so it can be closed on shutdown. Handler options are applied as for
+// NewPrettyHandler.
+func NewCloudWatchHandler(cw CloudWatchOptions, opts ...Option) (*PrettyHandler, *CloudWatchSink, error) {
+	s, err := NewCloudWatchSink(cw)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewPrettyHandler(s, append(opts, WithFormat(FormatJSON), WithTimeFormat(TimeFormatRFC3339Nano))...), s, nil
+}
+
+// NewCloudWatchSink starts a sink with the given options. Credentials are resolved and
+// the log group and stream created with the first batch.
+func NewCloudWatchSink(opts CloudWatchOptions) (*CloudWatchSink, error) {
+	if opts.Region == "" {
+		opts.Region = awsRegion()
+	}
+	if opts.Region == "" {
+		return nil, errors.New("slogger: cloudwatch: no region")
+	}
+	if opts.LogGroup == "" {
+		return nil, errors.New("slogger: cloudwatch: no log group")
+	}
+	if opts.LogStream == "" {
+		opts.LogStream = hostname()
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://logs." + opts.Region + ".amazonaws.com"
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.Credentials == nil {
+		chain := &awsCredentialChain{client: opts.Client, region: opts.Region}
+		opts.Credentials = chain.get
+	}
+
+	s := &CloudWatchSink{opts: opts}
+	s.batcher = newBatcher(batchOptions{
+		size:        opts.BatchSize,
+		interval:    opts.FlushInterval,
+		maxInFlight: 1, // sequence tokens order the calls
+		maxRetries:  opts.MaxRetries,
+		maxBuffered: opts.MaxBuffered,
+		deadLetter:  opts.DeadLetter,
+		breaker:     opts.Breaker,
+		onError:     opts.OnError,
+		send:        s.send,
+	})
+	return s, nil
+}
+
+// PutLogEvents limits.
+const (
+	cloudWatchMaxEvents    = 10000
+	cloudWatchMaxBytes     = 1 << 20
+	cloudWatchEventBytes   = 26 // overhead counted per event
+	cloudWatchMaxEventSize = 256<<10 - cloudWatchEventBytes
+	cloudWatchMaxSpan      = 24 * time.Hour
+)
+
+// cloudWatchEvent is an input log event.
+type cloudWatchEvent struct {
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+
+	raw []byte
+}
+
+// send puts one batch, in as many calls as the limits require.
+func (s *CloudWatchSink) send(ctx context.Context, records [][]byte) error {
+	events := make([]cloudWatchEvent, 0, len(records))
+	for _, rec := range records {
+		ts := time.Now()
+		var v struct {
+			Time string `json:"time"`
+		}
+		if json.Unmarshal(rec, &v) == nil {
+			if parsed, err := time.Parse(time.RFC3339Nano, v.Time); err == nil {
+				ts = parsed
+			}
+		}
+		msg := string(rec)
+		if len(msg) > cloudWatchMaxEventSize {
+			msg = msg[:cloudWatchMaxEventSize]
+		}
+		events = append(events, cloudWatchEvent{Timestamp: ts.UnixMilli(), Message: msg, raw: rec})
+	}
+	// Events of a call must be in chronological order.
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp < events[j].Timestamp })
+
+	for len(events) > 0 {
+		n, size := 0, 0
+		for n < len(events) && n < cloudWatchMaxEvents {
+			size += len(events[n].Message) + cloudWatchEventBytes
+			if size > cloudWatchMaxBytes || events[n].Timestamp-events[0].Timestamp >= cloudWatchMaxSpan.Milliseconds() {
+				break
+			}
+			n++
+		}
+		if err := s.put(ctx, events[:n]); err != nil {
+			var retry *retryError
+			if errors.As(err, &retry) {
+				// Only the events not yet accepted are sent again.
+				retry.records = make([][]byte, len(events))
+				for i, e := range events {
+					retry.records[i] = e.raw
+				}
+			}
+			return err
+		}
+		events = events[n:]
+	}
+	return nil
+}
+
+// put calls PutLogEvents, creating the log group and stream and following the expected
+// sequence token as needed.
+func (s *CloudWatchSink) put(ctx context.Context, events []cloudWatchEvent) error {
+	for attempt := 0; ; attempt++ {
+		req := map[string]interface{}{
+			"logGroupName":  s.opts.LogGroup,
+			"logStreamName": s.opts.LogStream,
+			"logEvents":     events,
+		}
+		if s.token != "" {
+			req["sequenceToken"] = s.token
+		}
+		var resp struct {
+			NextSequenceToken string `json:"nextSequenceToken"`
+			Rejected          *struct {
+				TooOldLogEventEndIndex   *int `json:"tooOldLogEventEndIndex"`
+				TooNewLogEventStartIndex *int `json:"tooNewLogEventStartIndex"`
+				ExpiredLogEventEndIndex  *int `json:"expiredLogEventEndIndex"`
+			} `json:"rejectedLogEventsInfo"`
+		}
+		err := s.call(ctx, "PutLogEvents", req, &resp)
+		var aerr *awsError
+		if errors.As(err, &aerr) && attempt < 3 {
+			switch aerr.Type {
+			case "ResourceNotFoundException":
+				if err := s.create(ctx); err != nil {
+					return err
+				}
+				continue
+			case "InvalidSequenceTokenException":
+				s.token = aerr.ExpectedSequenceToken
+				continue
+			case "DataAlreadyAcceptedException":
+				s.token = aerr.ExpectedSequenceToken
+				return nil
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("slogger: cloudwatch put: %w", err)
+		}
+		s.token = resp.NextSequenceToken
+		if resp.Rejected != nil && s.opts.OnError != nil {
+			s.opts.OnError(errors.New("slogger: cloudwatch put: some events were rejected as too old, too new or expired"))
+		}
+		return nil
+	}
+}
+
+// create makes the log group and stream, ignoring those that already exist.
+func (s *CloudWatchSink) create(ctx context.Context) error {
+	group := map[string]interface{}{"logGroupName": s.opts.LogGroup}
+	stream := map[string]interface{}{"logGroupName": s.opts.LogGroup, "logStreamName": s.opts.LogStream}
+	for _, c := range []struct {
+		action string
+		req    interface{}
+	}{{"CreateLogGroup", group}, {"CreateLogStream", stream}} {
+		err := s.call(ctx, c.action, c.req, nil)
+		var aerr *awsError
+		if errors.As(err, &aerr) && aerr.Type == "ResourceAlreadyExistsException" {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("slogger: cloudwatch %s: %w", c.action, err)
+		}
+	}
+	s.token = ""
+	return nil
+}
+
+// awsError is an error response of an AWS JSON API.
+type awsError struct {
+	Status                int
+	Type                  string
+	Message               string
+	ExpectedSequenceToken string
+}
+
+func (e *awsError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.Status, e.Type, e.Message)
+}
+
+// call invokes an action of the CloudWatch Logs JSON API and decodes the response into
+// out if it is not nil. Throttling and server errors are returned as retryError.
+func (s *CloudWatchSink) call(ctx context.Context, action string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	creds, err := s.opts.Credentials(ctx)
+	if err != nil {
+		return &retryError{err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(s.opts.Endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Logs_20140328."+action)
+	signAWS(req, body, creds, s.opts.Region, "logs", time.Now())
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return &retryError{err: err}
+	}
+	defer resp.Body.Close()
+	msg, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return &retryError{err: err}
+	}
+	if resp.StatusCode/100 == 2 {
+		if out == nil || len(msg) == 0 {
+			return nil
+		}
+		return json.Unmarshal(msg, out)
+	}
+
+	aerr := &awsError{Status: resp.StatusCode}
+	var v struct {
+		Type                  string `json:"__type"`
+		Message               string `json:"message"`
+		ExpectedSequenceToken string `json:"expectedSequenceToken"`
+	}
+	json.Unmarshal(msg, &v)
+	if _, t, ok := strings.Cut(v.Type, "#"); ok {
+		v.Type = t
+	}
+	aerr.Type, aerr.Message, aerr.ExpectedSequenceToken = v.Type, v.Message, v.ExpectedSequenceToken
+	if aerr.Type == "" {
+		aerr.Type = resp.Status
+	}
+	if resp.StatusCode >= 500 || aerr.Type == "ThrottlingException" || aerr.Type == "ServiceUnavailableException" {
+		return &retryError{err: aerr}
+	}
+	return aerr
+}
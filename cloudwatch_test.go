@@ -0,0 +1,175 @@
+package slogger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// cloudWatchCall is a request received by newFakeCloudWatch.
+type cloudWatchCall struct {
+	action string
+	body   map[string]interface{}
+}
+
+// newFakeCloudWatch answers the calls of a CloudWatchSink with the recorded responses,
+// "<status> <body>", in order, and returns a function listing the calls received.
+func newFakeCloudWatch(t *testing.T, responses ...string) (*httptest.Server, func() []cloudWatchCall) {
+	var mu sync.Mutex
+	var calls []cloudWatchCall
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") ||
+			!strings.Contains(auth, "/us-east-1/logs/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target, Signature=") {
+			t.Errorf("Authorization = %s", auth)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-amz-json-1.1" {
+			t.Errorf("Content-Type = %s", ct)
+		}
+		b, _ := io.ReadAll(r.Body)
+		call := cloudWatchCall{action: strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "Logs_20140328.")}
+		if err := json.Unmarshal(b, &call.body); err != nil {
+			t.Errorf("%s: %v", call.action, err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(calls) >= len(responses) {
+			t.Errorf("unexpected %s", call.action)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		resp := responses[len(calls)]
+		calls = append(calls, call)
+		status, body, _ := strings.Cut(resp, " ")
+		code, _ := strconv.Atoi(status)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.WriteHeader(code)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []cloudWatchCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls
+	}
+}
+
+func newTestCloudWatchSink(t *testing.T, srv *httptest.Server, onError func(error)) (*PrettyHandler, *CloudWatchSink) {
+	t.Helper()
+	h, sink, err := NewCloudWatchHandler(CloudWatchOptions{
+		Region:    "us-east-1",
+		LogGroup:  "app",
+		LogStream: "web-1",
+		Endpoint:  srv.URL,
+		Client:    srv.Client(),
+		Credentials: func(context.Context) (AWSCredentials, error) {
+			return AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"}, nil
+		},
+		OnError: onError,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h, sink
+}
+
+func TestCloudWatchSinkPut(t *testing.T) {
+	srv, calls := newFakeCloudWatch(t,
+		`400 {"__type":"com.amazonaws.logs#ResourceNotFoundException","message":"The specified log group does not exist."}`,
+		`400 {"__type":"ResourceAlreadyExistsException","message":"The specified log group already exists"}`,
+		`200 `,
+		`400 {"__type":"InvalidSequenceTokenException","message":"The given sequenceToken is invalid.","expectedSequenceToken":"tok1"}`,
+		`503 {"__type":"ServiceUnavailableException","message":"The server failed to fulfill the request."}`,
+		`200 {"nextSequenceToken":"tok2","rejectedLogEventsInfo":{"tooOldLogEventEndIndex":0}}`,
+	)
+	var errs []string
+	h, sink := newTestCloudWatchSink(t, srv, func(err error) { errs = append(errs, err.Error()) })
+	logger := slog.New(h)
+	logger.Info("first")
+	logger.Info("second")
+	// Flush waits for the retries, Close would give them up.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sink.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var actions []string
+	for _, c := range calls() {
+		actions = append(actions, c.action)
+		if c.body["logGroupName"] != "app" {
+			t.Errorf("%s: logGroupName = %v", c.action, c.body["logGroupName"])
+		}
+	}
+	want := "PutLogEvents CreateLogGroup CreateLogStream PutLogEvents PutLogEvents PutLogEvents"
+	if got := strings.Join(actions, " "); got != want {
+		t.Fatalf("calls = %s, want %s", got, want)
+	}
+
+	var tokens []string
+	for _, c := range calls() {
+		if c.action == "PutLogEvents" {
+			tokens = append(tokens, fmt.Sprint(c.body["sequenceToken"]))
+		}
+	}
+	if got := strings.Join(tokens, " "); got != "<nil> <nil> tok1 tok1" {
+		t.Errorf("sequence tokens = %s", got)
+	}
+	if sink.token != "tok2" {
+		t.Errorf("next sequence token = %q, want tok2", sink.token)
+	}
+
+	last := calls()[len(calls())-1].body
+	events, _ := last["logEvents"].([]interface{})
+	if len(events) != 2 {
+		t.Fatalf("logEvents = %v", last["logEvents"])
+	}
+	var prev float64
+	for i, e := range events {
+		e := e.(map[string]interface{})
+		ts, _ := e["timestamp"].(float64)
+		if ts < prev {
+			t.Errorf("event %d out of order", i)
+		}
+		prev = ts
+		var rec struct{ Msg string }
+		json.Unmarshal([]byte(e["message"].(string)), &rec)
+		if want := []string{"first", "second"}[i]; rec.Msg != want {
+			t.Errorf("event %d = %q, want %q", i, rec.Msg, want)
+		}
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0], "rejected as too old") {
+		t.Errorf("errors = %q", errs)
+	}
+}
+
+func TestCloudWatchSinkAccessDenied(t *testing.T) {
+	srv, calls := newFakeCloudWatch(t,
+		`400 {"__type":"AccessDeniedException","Message":"User is not authorized to perform: logs:PutLogEvents"}`,
+	)
+	var errs []string
+	h, sink := newTestCloudWatchSink(t, srv, func(err error) { errs = append(errs, err.Error()) })
+	slog.New(h).Info("lost")
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(calls()); n != 1 {
+		t.Errorf("%d calls, want 1: access denied is not retried", n)
+	}
+	const want = "slogger: cloudwatch put: 400 AccessDeniedException: User is not authorized to perform: logs:PutLogEvents (dropped 1 records)"
+	if len(errs) != 1 || errs[0] != want {
+		t.Errorf("errors = %q, want %q", errs, want)
+	}
+}
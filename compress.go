@@ -0,0 +1,130 @@
+package slogger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// gzipExt is the extension of compressed backups.
+const gzipExt = ".gz"
+
+// compressBackups gzips every uncompressed backup, running at most CompressWorkers
+// compressions at once.
+func (s *FileSink) compressBackups() {
+	s.bmu.Lock()
+	var pending []string
+	for _, b := range s.backups() {
+		if !strings.HasSuffix(b.path, gzipExt) {
+			pending = append(pending, b.path)
+		}
+	}
+	s.bmu.Unlock()
+
+	workers := s.opts.CompressWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for _, path := range pending {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			if err := s.compress(path); err != nil {
+				s.report(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// compress gzips the backup at path into path.gz and removes it.
+//
+// The data is compressed without holding bmu, so rotations are not delayed. RotateIndex
+// rotations may rename the backup meanwhile; it is found again by identity before the
+// compressed copy takes its place.
+func (s *FileSink) compress(path string) error {
+	s.bmu.Lock()
+	src, err := os.Open(path)
+	s.bmu.Unlock()
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("slogger: compress %s: %w", path, err)
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return fmt.Errorf("slogger: compress %s: %w", path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".compress-*")
+	if err != nil {
+		return fmt.Errorf("slogger: compress %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	level := s.opts.CompressLevel
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	zw, err := gzip.NewWriterLevel(tmp, level)
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("slogger: compress %s: %w", path, err)
+	}
+	if _, err := io.Copy(zw, src); err != nil {
+		tmp.Close()
+		return fmt.Errorf("slogger: compress %s: %w", path, err)
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("slogger: compress %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("slogger: compress %s: %w", path, err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("slogger: compress %s: %w", path, err)
+	}
+	// Keep the modification time so the retention policy sees the original age.
+	if err := os.Chtimes(tmp.Name(), info.ModTime(), info.ModTime()); err != nil {
+		return fmt.Errorf("slogger: compress %s: %w", path, err)
+	}
+
+	s.bmu.Lock()
+	defer s.bmu.Unlock()
+	current := s.locate(path, info)
+	if current == "" {
+		// Removed by the retention policy in the meantime.
+		return nil
+	}
+	if err := os.Rename(tmp.Name(), current+gzipExt); err != nil {
+		return fmt.Errorf("slogger: compress %s: %w", current, err)
+	}
+	if err := os.Remove(current); err != nil {
+		return fmt.Errorf("slogger: compress %s: %w", current, err)
+	}
+	return nil
+}
+
+// locate returns the current path of the backup with the given identity, trying path
+// first. It returns "" when the file no longer exists. s.bmu must be held.
+func (s *FileSink) locate(path string, info os.FileInfo) string {
+	if fi, err := os.Stat(path); err == nil && os.SameFile(fi, info) {
+		return path
+	}
+	for _, b := range s.backups() {
+		if fi, err := os.Stat(b.path); err == nil && os.SameFile(fi, info) {
+			return b.path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,391 @@
+package slogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes a logger in a form that can be loaded from a YAML or JSON file.
+type Config struct {
+	// Level is the minimum level: debug, info, warn, error or fatal. Defaults to info.
+	Level string `json:"level" yaml:"level"`
+
+	// Format is the output encoding: pretty, json, text, logfmt, ecs, gelf, gcp or syslog. Defaults to pretty.
+	Format string `json:"format" yaml:"format"`
+
+	// Outputs lists where records are written: "stdout", "stderr" or file paths.
+	// Defaults to stdout.
+	Outputs []string `json:"outputs" yaml:"outputs"`
+
+	// Rotation, if set, rotates the files listed in Outputs, see FileSink.
+	Rotation *RotationConfig `json:"rotation" yaml:"rotation"`
+
+	// TimeFormat is a time.Format layout or a shortcut such as rfc3339 or unixmilli.
+	TimeFormat string `json:"timeFormat" yaml:"timeFormat"`
+
+	// TimeZone is an IANA time zone name such as "UTC" or "Europe/Berlin" used for timestamps.
+	TimeZone string `json:"timeZone" yaml:"timeZone"`
+
+	// ZoneSuffix appends the time zone abbreviation to the timestamps of the pretty and text output.
+	ZoneSuffix bool `json:"zoneSuffix" yaml:"zoneSuffix"`
+
+	// Layout is a console layout template, see ParseLayout.
+	Layout string `json:"layout" yaml:"layout"`
+
+	// Pattern is a compact console format string, see ParsePattern.
+	Pattern string `json:"pattern" yaml:"pattern"`
+
+	// Segments lists the console columns in order, e.g. [level, message, attrs].
+	Segments []string `json:"segments" yaml:"segments"`
+
+	// Hide lists console columns to leave out, e.g. [time, func].
+	Hide []string `json:"hide" yaml:"hide"`
+
+	// Color enables ANSI colors in the pretty output. Defaults to true.
+	Color *bool `json:"color" yaml:"color"`
+
+	// Redact lists attribute keys whose values are replaced with "[REDACTED]". A group
+	// with one of these names has the values of all of its attributes replaced.
+	Redact []string `json:"redact" yaml:"redact"`
+}
+
+// RotationConfig describes the rotation of file outputs.
+type RotationConfig struct {
+	// MaxSizeMB is the size in megabytes after which a file is rotated. Zero disables
+	// size-based rotation.
+	MaxSizeMB int64 `json:"maxSizeMB" yaml:"maxSizeMB"`
+
+	// Naming is "index" (app.log.1) or "timestamp" (app-2006-01-02T15-04-05.000.log).
+	// Defaults to index.
+	Naming string `json:"naming" yaml:"naming"`
+
+	// Interval is "hourly" or "daily" to rotate files when the period changes, see
+	// FileSinkOptions.Interval.
+	Interval string `json:"interval" yaml:"interval"`
+
+	// MaxBackups is the number of rotated files to keep. Zero keeps all of them.
+	MaxBackups int `json:"maxBackups" yaml:"maxBackups"`
+
+	// MaxAgeDays removes rotated files older than this many days. Zero keeps them.
+	MaxAgeDays int `json:"maxAgeDays" yaml:"maxAgeDays"`
+
+	// Compress gzips rotated files.
+	Compress bool `json:"compress" yaml:"compress"`
+
+	// MaxTotalSizeMB caps the megabytes used by a file and its rotated files, removing
+	// the oldest rotated files when exceeded. Zero disables the quota.
+	MaxTotalSizeMB int64 `json:"maxTotalSizeMB" yaml:"maxTotalSizeMB"`
+}
+
+// sinkOptions converts rc into FileSink options.
+func (rc *RotationConfig) sinkOptions() (FileSinkOptions, error) {
+	opts := FileSinkOptions{
+		MaxSize:    rc.MaxSizeMB << 20,
+		MaxBackups: rc.MaxBackups,
+		MaxAge:     time.Duration(rc.MaxAgeDays) * 24 * time.Hour,
+		Compress:   rc.Compress,
+
+		MaxTotalSize: rc.MaxTotalSizeMB << 20,
+	}
+	switch strings.ToLower(rc.Naming) {
+	case "", "index":
+		opts.Naming = RotateIndex
+	case "timestamp":
+		opts.Naming = RotateTimestamp
+	default:
+		return opts, fmt.Errorf("slogger: unknown rotation naming %q", rc.Naming)
+	}
+	switch strings.ToLower(rc.Interval) {
+	case "", "never":
+		opts.Interval = RotateNever
+	case "hourly":
+		opts.Interval = RotateHourly
+	case "daily":
+		opts.Interval = RotateDaily
+	default:
+		return opts, fmt.Errorf("slogger: unknown rotation interval %q", rc.Interval)
+	}
+	return opts, nil
+}
+
+// LoadConfig reads a Config from path. Files ending in .yaml or .yml are parsed as YAML,
+// everything else as JSON.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &cfg)
+	default:
+		err = json.Unmarshal(b, &cfg)
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("slogger: parse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// NewFromConfig builds a logger from cfg. Files listed in Outputs are opened for appending;
+// CloseHandler on the handler of the logger, or Close once it is the default logger,
+// flushes and closes them. Every logger built gets a level of its own, set to cfg.Level.
+func NewFromConfig(cfg Config) (*slog.Logger, error) {
+	h, _, err := cfg.handler(NewAtomicLevel(slog.LevelInfo))
+	if err != nil {
+		return nil, err
+	}
+	return slog.New(h), nil
+}
+
+// handler builds the handler described by cfg along with the files it opened. The handler
+// is enabled by level, which is only set to cfg.Level once everything else succeeded.
+func (cfg Config) handler(level AtomicLevel) (slog.Handler, []io.Closer, error) {
+	parsed := slog.LevelInfo
+	if cfg.Level != "" {
+		var err error
+		if parsed, err = ParseLevel(cfg.Level); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	opts, err := cfg.options(level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out, closers, err := openOutputs(cfg.Outputs, cfg.Rotation)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	level.SetLevel(parsed)
+	return NewPrettyHandler(out, opts...), closers, nil
+}
+
+// options converts cfg into handler options, enabled by level.
+func (cfg Config) options(level AtomicLevel) ([]Option, error) {
+	format := FormatPretty
+	if cfg.Format != "" {
+		var err error
+		if format, err = ParseFormat(cfg.Format); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := []Option{
+		WithLevel(level),
+		WithFormat(format),
+		WithSource(true),
+	}
+	if cfg.TimeFormat != "" {
+		opts = append(opts, WithTimeFormat(cfg.TimeFormat))
+	}
+	if cfg.TimeZone != "" {
+		loc, err := time.LoadLocation(cfg.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("slogger: %w", err)
+		}
+		opts = append(opts, WithLocation(loc))
+	}
+	if cfg.ZoneSuffix {
+		opts = append(opts, WithZoneSuffix(true))
+	}
+	if cfg.Layout != "" {
+		layout, err := ParseLayout(cfg.Layout)
+		if err != nil {
+			return nil, fmt.Errorf("slogger: parse layout: %w", err)
+		}
+		opts = append(opts, WithLayout(layout))
+	}
+	if cfg.Pattern != "" {
+		p, err := ParsePattern(cfg.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithPattern(p))
+	}
+	if len(cfg.Segments) > 0 {
+		segments, err := parseSegments(cfg.Segments)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithSegments(segments...))
+	}
+	if len(cfg.Hide) > 0 {
+		hidden, err := parseSegments(cfg.Hide)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithHiddenSegments(hidden...))
+	}
+	if cfg.Color != nil {
+		opts = append(opts, WithColor(*cfg.Color))
+	}
+	if len(cfg.Redact) > 0 {
+		opts = append(opts, WithReplaceAttr(redactKeys(cfg.Redact)))
+	}
+	return opts, nil
+}
+
+// parseSegments parses a list of segment names.
+func parseSegments(names []string) ([]Segment, error) {
+	segments := make([]Segment, 0, len(names))
+	for _, name := range names {
+		s, err := ParseSegment(name)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, s)
+	}
+	return segments, nil
+}
+
+// openOutputs resolves output names into a single writer and returns the files it opened.
+// Files are rotated according to rotation when it is not nil.
+func openOutputs(outputs []string, rotation *RotationConfig) (io.Writer, []io.Closer, error) {
+	if len(outputs) == 0 {
+		return os.Stdout, nil, nil
+	}
+
+	var closers []io.Closer
+	writers := make([]io.Writer, 0, len(outputs))
+	for _, name := range outputs {
+		w, err := openOutput(name, rotation)
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, err
+		}
+		if c, ok := w.(io.Closer); ok && w != os.Stdout && w != os.Stderr {
+			closers = append(closers, c)
+		}
+		writers = append(writers, w)
+	}
+	if len(writers) == 1 {
+		return writers[0], closers, nil
+	}
+	return multiOutput(writers), closers, nil
+}
+
+// multiOutput writes to every output in turn like io.MultiWriter, but lets Flush and
+// Close reach the outputs.
+type multiOutput []io.Writer
+
+func (m multiOutput) Write(p []byte) (int, error) {
+	for _, w := range m {
+		n, err := w.Write(p)
+		if err != nil {
+			return n, err
+		}
+		if n != len(p) {
+			return n, io.ErrShortWrite
+		}
+	}
+	return len(p), nil
+}
+
+// closeAll closes every closer and returns the first error.
+func closeAll(closers []io.Closer) error {
+	var first error
+	for _, c := range closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// openOutput resolves "stdout", "stderr" or a file path into a writer.
+func openOutput(name string, rotation *RotationConfig) (io.Writer, error) {
+	switch name {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	}
+
+	if rotation != nil {
+		opts, err := rotation.sinkOptions()
+		if err != nil {
+			return nil, err
+		}
+		return NewFileSink(name, opts)
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("slogger: open output: %w", err)
+	}
+	return f, nil
+}
+
+// redactKeys returns a ReplaceAttr function that masks the values of the given keys and
+// of the attributes in groups with those names. ReplaceAttr never sees the groups
+// themselves, so they are matched through the groups of each attribute.
+func redactKeys(keys []string) func([]string, slog.Attr) slog.Attr {
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[k] = struct{}{}
+	}
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if _, ok := set[a.Key]; ok {
+			return slog.String(a.Key, "[REDACTED]")
+		}
+		for _, g := range groups {
+			if _, ok := set[g]; ok {
+				return slog.String(a.Key, "[REDACTED]")
+			}
+		}
+		return a
+	}
+}
+
+// ParseLevel parses a level name such as "debug", "INFO", "warn+2" or a custom name from LevelNames.
+func ParseLevel(s string) (slog.Level, error) {
+	for l, name := range LevelNames {
+		if strings.EqualFold(name, s) {
+			return l.Level(), nil
+		}
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return level, fmt.Errorf("slogger: %w", err)
+	}
+	return level, nil
+}
+
+// ParseFormat parses a format name: "pretty", "json", "text" (also accepted as "plain"),
+// "logfmt", "ecs", "gelf", "gcp" or "syslog".
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "pretty":
+		return FormatPretty, nil
+	case "json":
+		return FormatJSON, nil
+	case "text", "plain":
+		return FormatText, nil
+	case "logfmt":
+		return FormatLogfmt, nil
+	case "ecs":
+		return FormatECS, nil
+	case "gelf":
+		return FormatGELF, nil
+	case "gcp":
+		return FormatGCP, nil
+	case "syslog":
+		return FormatSyslog, nil
+	default:
+		return FormatPretty, fmt.Errorf("slogger: unknown format %q", s)
+	}
+}
@@ -0,0 +1,56 @@
+package slogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConfigRedact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	r, err := NewReloader(Config{Outputs: []string{path}, Format: "json", Redact: []string{"password", "auth"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Logger().Info("login",
+		"user", "bob",
+		"password", "hunter2",
+		slog.Group("auth", "token", "t0k3n", slog.Group("oauth", "secret", "s3cr3t")),
+		slog.Group("request", "password", "hunter2", "path", "/login"),
+	)
+	r.Logger().WithGroup("auth").Info("refresh", "token", "t0k3n")
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	want := []map[string]any{
+		{
+			"user":     "bob",
+			"password": "[REDACTED]",
+			"auth":     map[string]any{"token": "[REDACTED]", "oauth": map[string]any{"secret": "[REDACTED]"}},
+			"request":  map[string]any{"password": "[REDACTED]", "path": "/login"},
+		},
+		{"auth": map[string]any{"token": "[REDACTED]"}},
+	}
+	for i, w := range want {
+		var rec map[string]any
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		for _, k := range []string{slog.TimeKey, slog.LevelKey, slog.MessageKey, slog.SourceKey} {
+			delete(rec, k)
+		}
+		if !reflect.DeepEqual(rec, w) {
+			t.Errorf("record %d = %v, want %v", i, rec, w)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// loggerKey is the context key of IntoContext.
+type loggerKey struct{}
+
+// IntoContext returns a copy of ctx carrying logger, so a request-scoped logger with its
+// bound attributes travels down the call stack without a logger parameter:
+//
+//	ctx = slogger.IntoContext(ctx, slogger.FromContext(ctx).With("user", id))
+//	...
+//	slogger.FromContext(ctx).Info("order placed")
+func IntoContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or Default if there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok && logger != nil {
+		return logger
+	}
+	return Default()
+}
+
+// attrsKey is the context key of AppendCtx.
+type attrsKey struct{}
+
+// AppendCtx returns a copy of ctx carrying attrs in addition to the attributes added by
+// earlier calls. The handlers of this package log them with every record logged with the
+// context, at the top level whatever the groups of the logger, so middleware deep in a
+// request can add a user ID once:
+//
+//	ctx = slogger.AppendCtx(ctx, slog.String("user-id", id))
+func AppendCtx(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if len(attrs) == 0 {
+		return ctx
+	}
+	prev := ctxAttrs(ctx)
+	// A fresh slice, so contexts derived from the same parent don't share appends.
+	all := make([]slog.Attr, 0, len(prev)+len(attrs))
+	all = append(append(all, prev...), attrs...)
+	return context.WithValue(ctx, attrsKey{}, all)
+}
+
+// ctxAttrs returns the attributes added to ctx by AppendCtx.
+func ctxAttrs(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
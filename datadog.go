@@ -0,0 +1,210 @@
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// DatadogOptions configures a DatadogSink.
+type DatadogOptions struct {
+	// APIKey authenticates the requests. Defaults to DD_API_KEY.
+	APIKey string
+
+	// Site is the Datadog site, such as datadoghq.eu or us5.datadoghq.com. Defaults to
+	// DD_SITE or datadoghq.com.
+	Site string
+
+	// URL overrides the intake endpoint derived from Site.
+	URL string
+
+	// Service, Env and Version are reported as the service and the env and version tags
+	// of unified service tagging. They default to DD_SERVICE, DD_ENV and DD_VERSION.
+	Service string
+	Env     string
+	Version string
+
+	// Tags are added to every record, as in "team:payments".
+	Tags []string
+
+	// Source is the ddsource of the records, selecting the log pipeline. Defaults to "go".
+	Source string
+
+	// Hostname overrides the host name reported with the records.
+	Hostname string
+
+	// NoGzip sends request bodies uncompressed.
+	NoGzip bool
+
+	// BatchSize, FlushInterval, MaxInFlight, MaxRetries, MaxBuffered, DeadLetter and Breaker
+	// tune batching as documented on WebhookOptions. Batches are split to stay within the
+	// intake limits of 1000 records and 5 MB. Rate limits and server errors are retried with
+	// backoff.
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxInFlight   int
+	MaxRetries    int
+	MaxBuffered   int
+	DeadLetter    *DeadLetter
+	Breaker       *CircuitBreaker
+
+	// Client sends the requests. Defaults to a client with a ten second timeout.
+	Client *http.Client
+
+	// OnError, if not nil, receives failed deliveries.
+	OnError func(error)
+}
+
+// DatadogSink is an io.Writer shipping the JSON records written by a FormatJSON handler
+// to the Datadog logs intake. Call Close to send the remaining records.
+type DatadogSink struct {
+	*batcher
+	opts   DatadogOptions
+	header []byte // reserved attributes prepended to every record
+}
+
+// NewDatadogHandler creates a JSON handler shipping records to Datadog and returns the
+// sink so it can be closed on shutdown. Handler options are applied as for
+// NewPrettyHandler.
+func NewDatadogHandler(dd DatadogOptions, opts ...Option) (*PrettyHandler, *DatadogSink, error) {
+	s, err := NewDatadogSink(dd)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewPrettyHandler(s, append(opts, WithFormat(FormatJSON), WithTimeFormat(TimeFormatRFC3339Nano))...), s, nil
+}
+
+// NewDatadogSink starts a sink with the given options.
+func NewDatadogSink(opts DatadogOptions) (*DatadogSink, error) {
+	env := func(v *string, name string) {
+		if *v == "" {
+			*v = os.Getenv(name)
+		}
+	}
+	env(&opts.APIKey, "DD_API_KEY")
+	env(&opts.Site, "DD_SITE")
+	env(&opts.Service, "DD_SERVICE")
+	env(&opts.Env, "DD_ENV")
+	env(&opts.Version, "DD_VERSION")
+	if opts.APIKey == "" {
+		return nil, errors.New("slogger: datadog: no API key")
+	}
+	if opts.Site == "" {
+		opts.Site = "datadoghq.com"
+	}
+	if opts.URL == "" {
+		opts.URL = "https://http-intake.logs." + opts.Site + "/api/v2/logs"
+	}
+	if opts.Source == "" {
+		opts.Source = "go"
+	}
+	if opts.Hostname == "" {
+		opts.Hostname = hostname()
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	tags := append([]string(nil), opts.Tags...)
+	if opts.Env != "" {
+		tags = append(tags, "env:"+opts.Env)
+	}
+	if opts.Version != "" {
+		tags = append(tags, "version:"+opts.Version)
+	}
+	reserved := fields{{key: "ddsource", value: opts.Source}, {key: "hostname", value: opts.Hostname}}
+	if opts.Service != "" {
+		reserved = append(reserved, field{key: "service", value: opts.Service})
+	}
+	if len(tags) > 0 {
+		reserved = append(reserved, field{key: "ddtags", value: strings.Join(tags, ",")})
+	}
+	header, err := json.Marshal(reserved)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &DatadogSink{opts: opts, header: header[:len(header)-1]}
+	s.batcher = newBatcher(batchOptions{
+		size:        opts.BatchSize,
+		interval:    opts.FlushInterval,
+		maxInFlight: opts.MaxInFlight,
+		maxRetries:  opts.MaxRetries,
+		maxBuffered: opts.MaxBuffered,
+		deadLetter:  opts.DeadLetter,
+		breaker:     opts.Breaker,
+		onError:     opts.OnError,
+		send:        s.send,
+	})
+	return s, nil
+}
+
+// Intake limits.
+const (
+	datadogMaxRecords = 1000
+	datadogMaxBytes   = 5 << 20
+)
+
+// send posts one batch, in as many requests as the limits require.
+func (s *DatadogSink) send(ctx context.Context, records [][]byte) error {
+	headers := map[string]string{"DD-API-KEY": s.opts.APIKey}
+	for len(records) > 0 {
+		var body bytes.Buffer
+		body.WriteByte('[')
+		n := 0
+		for n < len(records) && n < datadogMaxRecords {
+			rec := s.record(records[n])
+			if n > 0 && body.Len()+len(rec)+2 > datadogMaxBytes {
+				break
+			}
+			if n > 0 {
+				body.WriteByte(',')
+			}
+			body.Write(rec)
+			n++
+		}
+		body.WriteByte(']')
+
+		if _, err := httpPost(ctx, s.opts.Client, s.opts.URL, "application/json", headers, body.Bytes(), !s.opts.NoGzip); err != nil {
+			var retry *retryError
+			if errors.As(err, &retry) {
+				retry.records = records
+			}
+			return fmt.Errorf("slogger: datadog: %w", err)
+		}
+		records = records[n:]
+	}
+	return nil
+}
+
+// record prepends the reserved attributes to a JSON record and copies its time to the
+// timestamp attribute recognized by Datadog.
+func (s *DatadogSink) record(rec []byte) []byte {
+	if len(rec) < 2 || rec[0] != '{' {
+		b, _ := json.Marshal(map[string]string{"message": string(rec)})
+		rec = b
+	}
+	out := make([]byte, 0, len(s.header)+len(rec)+48)
+	out = append(out, s.header...)
+	var v struct {
+		Time string `json:"time"`
+	}
+	if json.Unmarshal(rec, &v) == nil && v.Time != "" {
+		out = append(out, `,"timestamp":`...)
+		b, _ := json.Marshal(v.Time)
+		out = append(out, b...)
+	}
+	if rest := rec[1:]; len(bytes.TrimSpace(rest)) > 1 {
+		out = append(out, ',')
+		out = append(out, rest...)
+	} else {
+		out = append(out, '}')
+	}
+	return out
+}
@@ -0,0 +1,260 @@
+package slogger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DeadLetterOptions configures a DeadLetter.
+type DeadLetterOptions struct {
+	// MaxSize caps the size of the file in bytes. Records that do not fit are dropped.
+	// Zero leaves the file unbounded.
+	MaxSize int64
+
+	// Perm is the permission of the file when it is created. Defaults to 0600, since
+	// records may hold sensitive data.
+	Perm os.FileMode
+}
+
+// DeadLetter is a local spool file receiving the records a sink fails to deliver, so none
+// are lost during an outage of the log backend. Records are stored one per line, as
+// written by the sink's handler, and can be sent again with Replay once the backend is
+// back:
+//
+//	dl, err := slogger.NewDeadLetter("/var/spool/app/loki.dead", slogger.DeadLetterOptions{})
+//	h, sink := slogger.NewLokiHandler(slogger.LokiOptions{URL: url, DeadLetter: dl})
+//	...
+//	n, err := dl.Replay(sink)
+type DeadLetter struct {
+	path string
+	opts DeadLetterOptions
+
+	replay sync.Mutex // serializes Replay
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// NewDeadLetter opens or creates the spool file at path. Records spooled earlier are kept
+// until replayed.
+func NewDeadLetter(path string, opts DeadLetterOptions) (*DeadLetter, error) {
+	if opts.Perm == 0 {
+		opts.Perm = 0o600
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, opts.Perm)
+	if err != nil {
+		return nil, fmt.Errorf("slogger: dead letter: %w", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("slogger: dead letter: %w", err)
+	}
+	return &DeadLetter{path: path, opts: opts, f: f, size: fi.Size()}, nil
+}
+
+// Path returns the path of the spool file.
+func (d *DeadLetter) Path() string {
+	return d.path
+}
+
+// Size returns the number of bytes currently spooled.
+func (d *DeadLetter) Size() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.size
+}
+
+// spool appends records to the file and returns how many were stored.
+func (d *DeadLetter) spool(records [][]byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.f == nil {
+		return 0, errors.New("slogger: dead letter closed")
+	}
+
+	var buf bytes.Buffer
+	n := 0
+	for _, rec := range records {
+		rec = bytes.TrimSuffix(rec, []byte{'\n'})
+		if d.opts.MaxSize > 0 && d.size+int64(buf.Len()+len(rec)+1) > d.opts.MaxSize {
+			break
+		}
+		buf.Write(rec)
+		buf.WriteByte('\n')
+		n++
+	}
+	written, err := d.f.Write(buf.Bytes())
+	d.size += int64(written)
+	if err != nil {
+		return 0, fmt.Errorf("slogger: dead letter: %w", err)
+	}
+	if n < len(records) {
+		return n, fmt.Errorf("slogger: dead letter full, %d records dropped", len(records)-n)
+	}
+	return n, nil
+}
+
+// Replay writes every spooled record to w, typically the sink that failed to deliver
+// them, and removes them from the file. It stops at the first failing write; the records
+// not replayed stay spooled. It returns the number of records replayed.
+//
+// Records that fail again while or after being replayed are spooled again, so Replay can
+// safely be called while the sink is in use. The records stay in the file until they are
+// replayed, which is then rewritten through a temporary file renamed over it: a crash
+// or a failure to rewrite it leaves them all spooled, to be replayed again.
+func (d *DeadLetter) Replay(w io.Writer) (int, error) {
+	d.replay.Lock()
+	defer d.replay.Unlock()
+
+	d.mu.Lock()
+	if d.f == nil {
+		d.mu.Unlock()
+		return 0, errors.New("slogger: dead letter closed")
+	}
+	data, err := os.ReadFile(d.path)
+	d.mu.Unlock()
+	if err != nil {
+		return 0, fmt.Errorf("slogger: dead letter: %w", err)
+	}
+
+	// The lock is not held while writing, so a sink spooling synchronously can't deadlock.
+	var records [][]byte
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, len(data)+1)
+	for sc.Scan() {
+		if len(sc.Bytes()) > 0 {
+			records = append(records, append(sc.Bytes(), '\n'))
+		}
+	}
+	n := 0
+	var werr error
+	for ; n < len(records); n++ {
+		if _, err := w.Write(records[n]); err != nil {
+			werr = fmt.Errorf("slogger: dead letter replay: %w", err)
+			break
+		}
+	}
+	if n == 0 {
+		return 0, werr
+	}
+	if err := d.rewrite(int64(len(data)), records[n:]); err != nil {
+		return n, errors.Join(werr, err)
+	}
+	return n, werr
+}
+
+// rewrite replaces the file with keep followed by the records spooled after its first
+// offset bytes were read. The new content is written to a temporary file renamed over
+// the spool, so a failure leaves the spool as it was.
+func (d *DeadLetter) rewrite(offset int64, keep [][]byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.f == nil {
+		return errors.New("slogger: dead letter closed")
+	}
+	data, err := os.ReadFile(d.path)
+	if err != nil {
+		return fmt.Errorf("slogger: dead letter: %w", err)
+	}
+	var buf bytes.Buffer
+	for _, rec := range keep {
+		buf.Write(rec)
+	}
+	buf.Write(data[min(offset, int64(len(data))):])
+
+	tmp, err := os.CreateTemp(filepath.Dir(d.path), filepath.Base(d.path)+".*")
+	if err != nil {
+		return fmt.Errorf("slogger: dead letter: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // left behind only if the rename fails
+	_, err = tmp.Write(buf.Bytes())
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), d.opts.Perm)
+	}
+	if err != nil {
+		return fmt.Errorf("slogger: dead letter: %w", err)
+	}
+
+	// Windows can't rename over an open file, so the spool is closed and reopened.
+	d.f.Close()
+	rerr := os.Rename(tmp.Name(), d.path)
+	f, err := os.OpenFile(d.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, d.opts.Perm)
+	if err != nil {
+		d.f = nil
+		return errors.Join(rerr, fmt.Errorf("slogger: dead letter: %w", err))
+	}
+	d.f = f
+	if rerr != nil {
+		return fmt.Errorf("slogger: dead letter: %w", rerr)
+	}
+	d.size = int64(buf.Len())
+	return nil
+}
+
+// Wrap returns a writer passing writes to w and spooling the records w fails to write,
+// for writers without a DeadLetter option of their own such as SyslogWriter:
+//
+//	w := dl.Wrap(syslogWriter)
+//	...
+//	n, err := dl.Replay(w)
+//
+// A spooled record counts as written; only a failure to spool is returned.
+func (d *DeadLetter) Wrap(w io.Writer) io.Writer {
+	return &deadLetterWriter{dl: d, w: w}
+}
+
+type deadLetterWriter struct {
+	dl *DeadLetter
+	w  io.Writer
+}
+
+func (dw *deadLetterWriter) Write(p []byte) (int, error) {
+	n, err := dw.w.Write(p)
+	if err == nil {
+		return n, nil
+	}
+	if _, serr := dw.dl.spool([][]byte{p}); serr != nil {
+		return n, errors.Join(err, serr)
+	}
+	return len(p), nil
+}
+
+// Flush flushes the wrapped writer if it buffers records.
+func (dw *deadLetterWriter) Flush(ctx context.Context) error {
+	return flushNode(ctx, dw.w)
+}
+
+// Close closes the wrapped writer if it is an io.Closer. The DeadLetter stays open.
+func (dw *deadLetterWriter) Close() error {
+	if c, ok := dw.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Close closes the spool file. Records spooled afterwards are dropped.
+func (d *DeadLetter) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.f == nil {
+		return nil
+	}
+	err := d.f.Close()
+	d.f = nil
+	return err
+}
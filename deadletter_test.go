@@ -0,0 +1,78 @@
+package slogger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// replayWriter collects the records replayed to it, failing at the record "fail" and
+// spooling the record "again" back to dl, as a sink failing meanwhile would.
+type replayWriter struct {
+	dl      *DeadLetter
+	written []string
+}
+
+func (w *replayWriter) Write(p []byte) (int, error) {
+	rec := strings.TrimSuffix(string(p), "\n")
+	switch rec {
+	case "fail":
+		return 0, errors.New("unavailable")
+	case "again":
+		if _, err := w.dl.spool([][]byte{p}); err != nil {
+			return 0, err
+		}
+	}
+	w.written = append(w.written, rec)
+	return len(p), nil
+}
+
+func TestDeadLetterReplay(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.dead")
+	dl, err := NewDeadLetter(path, DeadLetterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dl.Close()
+	spooled := func(want string) {
+		t.Helper()
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.ReplaceAll(strings.TrimSpace(string(b)), "\n", " "); got != want {
+			t.Errorf("spooled %q, want %q", got, want)
+		}
+		if dl.Size() != int64(len(b)) {
+			t.Errorf("Size = %d, want %d", dl.Size(), len(b))
+		}
+	}
+
+	if _, err := dl.spool([][]byte{[]byte("1\n"), []byte("again\n"), []byte("fail\n"), []byte("3")}); err != nil {
+		t.Fatal(err)
+	}
+	w := &replayWriter{dl: dl}
+	n, err := dl.Replay(w)
+	if n != 2 || err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Errorf("Replay = %d, %v, want 2 and the error of the write", n, err)
+	}
+	// The records not replayed come first, then those spooled during the replay.
+	spooled("fail 3 again")
+
+	// Records spooled after the rewrite go to the new file.
+	if _, err := dl.spool([][]byte{[]byte("4")}); err != nil {
+		t.Fatal(err)
+	}
+	spooled("fail 3 again 4")
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("%d files in the spool directory, want no temporary file left", len(entries))
+	}
+}
@@ -0,0 +1,158 @@
+package slogger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Attribute keys of the summary records of a DedupHandler.
+const (
+	RepeatedKey     = "repeated"      // number of repeats collapsed into the record
+	RepeatedSpanKey = "repeated_span" // time from the first to the last repeat
+)
+
+// DedupOptions configures a DedupHandler.
+type DedupOptions struct {
+	// Interval is the longest time repeats are held back: a repeat arriving later writes
+	// the summary of the previous ones. Defaults to 30 seconds.
+	Interval time.Duration
+}
+
+// DedupHandler collapses identical consecutive records, with the same level, message
+// and attributes, like syslog's "last message repeated N times": the first record is
+// written, and the repeats following it are counted instead. The last repeat is written
+// with RepeatedKey and RepeatedSpanKey attributes once a different record arrives,
+// Interval passes or Flush is called.
+type DedupHandler struct {
+	next   slog.Handler
+	s      *dedupState
+	prefix string
+	bound  []flatAttr
+}
+
+// dedupState is the state shared by a DedupHandler and the handlers derived from it.
+type dedupState struct {
+	opts DedupOptions
+
+	mu       sync.Mutex
+	last     string    // fingerprint of the last record
+	repeats  int       // repeats of the last record held back
+	first    time.Time // time of the first repeat held back
+	lastItem asyncItem // the last repeat
+}
+
+// NewDedupHandler wraps next with duplicate suppression.
+func NewDedupHandler(next slog.Handler, opts DedupOptions) *DedupHandler {
+	if opts.Interval <= 0 {
+		opts.Interval = 30 * time.Second
+	}
+	return &DedupHandler{next: next, s: &dedupState{opts: opts}}
+}
+
+// Flush writes the summary of the repeats held back.
+func (h *DedupHandler) Flush(ctx context.Context) error {
+	h.s.mu.Lock()
+	summaries := h.s.summary()
+	h.s.mu.Unlock()
+	return handleSummaries(summaries)
+}
+
+func (h *DedupHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *DedupHandler) Handle(ctx context.Context, r slog.Record) error {
+	fp := h.fingerprint(r)
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	s := h.s
+	s.mu.Lock()
+	if fp == s.last {
+		if s.repeats == 0 || t.Sub(s.first) < s.opts.Interval {
+			if s.repeats == 0 {
+				s.first = t
+			}
+			s.repeats++
+			// The summary is written after Handle returns, so the record must not share
+			// the caller's attribute storage.
+			s.lastItem = asyncItem{ctx: ctx, h: h.next, r: r.Clone()}
+			s.mu.Unlock()
+			return nil
+		}
+		// The repeats held back for too long are summarized, and this one starts a new
+		// run of repeats after their summary.
+		summaries := s.summary()
+		s.first, s.repeats = t, 1
+		s.lastItem = asyncItem{ctx: ctx, h: h.next, r: r.Clone()}
+		s.mu.Unlock()
+		return handleSummaries(summaries)
+	}
+	summaries := s.summary()
+	s.last = fp
+	s.mu.Unlock()
+
+	return errors.Join(handleSummaries(summaries), h.next.Handle(ctx, r))
+}
+
+func (h *DedupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &DedupHandler{
+		next:   h.next.WithAttrs(attrs),
+		s:      h.s,
+		prefix: h.prefix,
+		bound:  bindFlatAttrs(h.bound, h.prefix, attrs),
+	}
+}
+
+func (h *DedupHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &DedupHandler{
+		next:   h.next.WithGroup(name),
+		s:      h.s,
+		prefix: h.prefix + name + ".",
+		bound:  h.bound,
+	}
+}
+
+// fingerprint returns a string equal for records differing only in time and source.
+func (h *DedupHandler) fingerprint(r slog.Record) string {
+	var b strings.Builder
+	b.WriteString(r.Level.String())
+	b.WriteByte(0)
+	b.WriteString(r.Message)
+	attrs := h.bound[:len(h.bound):len(h.bound)]
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendFlatAttr(attrs, h.prefix, a)
+		return true
+	})
+	for _, a := range attrs {
+		b.WriteByte(0)
+		b.WriteString(a.key)
+		b.WriteByte('=')
+		b.WriteString(a.value.String())
+	}
+	return b.String()
+}
+
+// summary returns the summary record of the repeats held back, if any, and resets them.
+// s.mu must be held.
+func (s *dedupState) summary() []asyncItem {
+	if s.repeats == 0 {
+		return nil
+	}
+	item := s.lastItem
+	item.r.AddAttrs(
+		slog.Int(RepeatedKey, s.repeats),
+		slog.Duration(RepeatedSpanKey, item.r.Time.Sub(s.first)),
+	)
+	s.repeats, s.lastItem = 0, asyncItem{}
+	return []asyncItem{item}
+}
@@ -0,0 +1,27 @@
+package slogger
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+// defaultLogger holds the logger returned by Default.
+var defaultLogger atomic.Pointer[slog.Logger]
+
+// SetDefault makes l the global logger returned by Default and installs it with
+// slog.SetDefault, so calls to the slog package-level functions are routed through it too.
+// It is safe to call while other goroutines are logging.
+func SetDefault(l *slog.Logger) {
+	defaultLogger.Store(l)
+	Log = l
+	slog.SetDefault(l)
+}
+
+// Default returns the global logger set by SetDefault or MakeLogger, falling back to
+// slog.Default when none has been set.
+func Default() *slog.Logger {
+	if l := defaultLogger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
+}
@@ -0,0 +1,103 @@
+package slogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// ECSVersion is the Elastic Common Schema version declared in ECS output.
+const ECSVersion = "8.11.0"
+
+// NewECSHandler creates a handler producing Elastic Common Schema JSON lines, so records
+// can be ingested by Elasticsearch and Kibana without ingest pipelines. It accepts the same
+// options as NewPrettyHandler.
+func NewECSHandler(out io.Writer, opts ...Option) *PrettyHandler {
+	return NewPrettyHandler(out, append(opts, WithFormat(FormatECS))...)
+}
+
+// stackTracer is implemented by errors that carry a stack trace, such as those of
+// github.com/pkg/errors.
+type stackTracer interface {
+	error
+	StackTrace() string
+}
+
+// encodeECS renders e as an ECS document: time→@timestamp, level→log.level, msg→message,
+// source→log.origin, err/error→error.message and error.stack_trace, trace_id→trace.id,
+// span_id→span.id, and trace-id→trace.id when there is no span context.
+func (h *PrettyHandler) encodeECS(e entry) ([]byte, error) {
+	fs := make(fields, 0, len(e.attrs)+6)
+	if e.time.Key != "" {
+		if e.time.Value.Kind() == slog.KindTime {
+			fs = append(fs, field{key: "@timestamp", value: e.time.Value.Time().UTC().Format(time.RFC3339Nano)})
+		} else {
+			fs = append(fs, field{key: "@timestamp", value: e.time.Value.Any()})
+		}
+	}
+	if e.level.Key != "" {
+		fs = append(fs, field{key: "log.level", value: strings.ToLower(levelName(e.level.Value))})
+	}
+	if e.message.Key != "" {
+		fs = append(fs, field{key: "message", value: e.message.Value.String()})
+	}
+	fs = append(fs, field{key: "ecs.version", value: ECSVersion})
+	if e.source.Key != "" {
+		if src, ok := e.source.Value.Any().(*slog.Source); ok {
+			fs = append(fs, field{key: "log.origin", value: fields{
+				{key: "file.name", value: src.File},
+				{key: "file.line", value: src.Line},
+				{key: "function", value: src.Function},
+			}})
+		}
+	}
+
+	var errFields fields
+	span := e.attrs.has(SpanTraceIDKey)
+	for _, f := range e.attrs {
+		switch f.key {
+		case "err", "error":
+			errFields = append(errFields, ecsError(f.value)...)
+		case "stack", "stack_trace":
+			errFields = append(errFields, field{key: "stack_trace", value: fmt.Sprint(f.value)})
+		case TraceIDKey:
+			if span {
+				fs = append(fs, f)
+				continue
+			}
+			fs = append(fs, field{key: "trace.id", value: f.value})
+		case SpanTraceIDKey:
+			fs = append(fs, field{key: "trace.id", value: f.value})
+		case SpanIDKey:
+			fs = append(fs, field{key: "span.id", value: f.value})
+		default:
+			fs = append(fs, f)
+		}
+	}
+	if len(errFields) > 0 {
+		fs = append(fs, field{key: "error", value: errFields})
+	}
+	return json.Marshal(fs)
+}
+
+// ecsError maps an error attribute to the error.* fields.
+func ecsError(v interface{}) fields {
+	err, ok := v.(error)
+	if !ok {
+		return fields{{key: "message", value: fmt.Sprint(v)}}
+	}
+
+	fs := fields{
+		{key: "message", value: err.Error()},
+		{key: "type", value: fmt.Sprintf("%T", err)},
+	}
+	if st, ok := err.(stackTracer); ok {
+		fs = append(fs, field{key: "stack_trace", value: st.StackTrace()})
+	} else if verbose := fmt.Sprintf("%+v", err); verbose != err.Error() {
+		fs = append(fs, field{key: "stack_trace", value: verbose})
+	}
+	return fs
+}
@@ -0,0 +1,183 @@
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ElasticsearchOptions configures an ElasticsearchSink.
+type ElasticsearchOptions struct {
+	// URL is the base URL of the cluster. Defaults to http://localhost:9200.
+	URL string
+
+	// Index is the target index. Time layouts in braces are replaced with the UTC time
+	// of each record, so "logs-{2006.01.02}" writes to one index per day. Defaults to
+	// "logs-slogger-{2006.01.02}".
+	Index string
+
+	// APIKey, if set, is sent as "Authorization: ApiKey <APIKey>".
+	APIKey string
+
+	// Headers are added to every request.
+	Headers map[string]string
+
+	// BatchSize, FlushInterval, MaxInFlight, MaxRetries, MaxBuffered, DeadLetter and Breaker
+	// tune batching as documented on WebhookOptions. Documents rejected with 429 are retried
+	// with backoff; MaxBuffered bounds the records waiting meanwhile.
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxInFlight   int
+	MaxRetries    int
+	MaxBuffered   int
+	DeadLetter    *DeadLetter
+	Breaker       *CircuitBreaker
+
+	// Client sends the requests. Defaults to a client with a ten second timeout.
+	Client *http.Client
+
+	// OnError, if not nil, receives failed deliveries and rejected documents.
+	OnError func(error)
+}
+
+// ElasticsearchSink is an io.Writer indexing the ECS documents written by a FormatECS
+// handler through the Elasticsearch _bulk API, so small services can skip a separate
+// shipper. Call Close to send the remaining records.
+type ElasticsearchSink struct {
+	*batcher
+	opts  ElasticsearchOptions
+	index []templatePart
+}
+
+// NewElasticsearchHandler creates an ECS handler indexing records in Elasticsearch and
+// returns the sink so it can be closed on shutdown. Handler options are applied as for
+// NewPrettyHandler.
+func NewElasticsearchHandler(es ElasticsearchOptions, opts ...Option) (*PrettyHandler, *ElasticsearchSink) {
+	s := NewElasticsearchSink(es)
+	return NewPrettyHandler(s, append(opts, WithFormat(FormatECS))...), s
+}
+
+// NewElasticsearchSink starts a sink with the given options.
+func NewElasticsearchSink(opts ElasticsearchOptions) *ElasticsearchSink {
+	if opts.URL == "" {
+		opts.URL = "http://localhost:9200"
+	}
+	if opts.Index == "" {
+		opts.Index = "logs-slogger-{2006.01.02}"
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	headers := make(map[string]string, len(opts.Headers)+1)
+	for k, v := range opts.Headers {
+		headers[k] = v
+	}
+	if opts.APIKey != "" {
+		headers["Authorization"] = "ApiKey " + opts.APIKey
+	}
+	opts.Headers = headers
+
+	s := &ElasticsearchSink{opts: opts, index: parseTemplate(opts.Index)}
+	s.batcher = newBatcher(batchOptions{
+		size:        opts.BatchSize,
+		interval:    opts.FlushInterval,
+		maxInFlight: opts.MaxInFlight,
+		maxRetries:  opts.MaxRetries,
+		maxBuffered: opts.MaxBuffered,
+		deadLetter:  opts.DeadLetter,
+		breaker:     opts.Breaker,
+		onError:     opts.OnError,
+		send:        s.send,
+	})
+	return s
+}
+
+// indexFor returns the index of a document written at t.
+func (s *ElasticsearchSink) indexFor(t time.Time) string {
+	var b strings.Builder
+	for _, p := range s.index {
+		if p.braced {
+			b.WriteString(t.UTC().Format(p.text))
+		} else {
+			b.WriteString(p.text)
+		}
+	}
+	return b.String()
+}
+
+// esBulkResponse is the part of a _bulk response needed to find rejected documents.
+type esBulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int             `json:"status"`
+		Error  json.RawMessage `json:"error"`
+	} `json:"items"`
+}
+
+// send indexes records with one _bulk request. Documents rejected with 429 are retried;
+// documents rejected for other reasons are reported and dropped.
+func (s *ElasticsearchSink) send(ctx context.Context, records [][]byte) error {
+	var body bytes.Buffer
+	for _, rec := range records {
+		var doc struct {
+			Timestamp time.Time `json:"@timestamp"`
+		}
+		t := time.Now()
+		if json.Unmarshal(rec, &doc) == nil && !doc.Timestamp.IsZero() {
+			t = doc.Timestamp
+		}
+		action, err := json.Marshal(fields{{key: "create", value: fields{{key: "_index", value: s.indexFor(t)}}}})
+		if err != nil {
+			return err
+		}
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(rec)
+		body.WriteByte('\n')
+	}
+
+	resp, err := httpPost(ctx, s.opts.Client, strings.TrimSuffix(s.opts.URL, "/")+"/_bulk", "application/x-ndjson", s.opts.Headers, body.Bytes(), false)
+	if err != nil {
+		return fmt.Errorf("slogger: elasticsearch bulk: %w", err)
+	}
+
+	var bulk esBulkResponse
+	if err := json.Unmarshal(resp, &bulk); err != nil {
+		return fmt.Errorf("slogger: elasticsearch bulk: %w", err)
+	}
+	if !bulk.Errors {
+		return nil
+	}
+
+	var retry [][]byte
+	var rejected int
+	var first json.RawMessage
+	for i, item := range bulk.Items {
+		for _, result := range item {
+			switch {
+			case result.Status == http.StatusTooManyRequests && i < len(records):
+				retry = append(retry, records[i])
+			case result.Status/100 != 2:
+				rejected++
+				if first == nil {
+					first = result.Error
+				}
+			}
+		}
+	}
+	if rejected > 0 && s.opts.OnError != nil {
+		s.opts.OnError(fmt.Errorf("slogger: elasticsearch bulk: %d documents rejected: %s", rejected, first))
+	}
+	if len(retry) > 0 {
+		return &retryError{err: fmt.Errorf("slogger: elasticsearch bulk: %w", errTooManyRequests), records: retry}
+	}
+	return nil
+}
+
+// errTooManyRequests reports documents rejected because the cluster is overloaded.
+var errTooManyRequests = errors.New("429 Too Many Requests")
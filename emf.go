@@ -0,0 +1,85 @@
+package slogger
+
+import (
+	"log/slog"
+)
+
+// EMFMetric selects a numeric attribute to publish as a CloudWatch metric.
+type EMFMetric struct {
+	// Name is the attribute key, dotted for grouped attributes, and the metric name.
+	Name string
+	// Unit is a CloudWatch unit such as "Milliseconds", "Count" or "Bytes". Optional.
+	Unit string
+}
+
+// EMFOptions configures AWS CloudWatch Embedded Metric Format output.
+type EMFOptions struct {
+	// Namespace is the CloudWatch namespace of the metrics.
+	Namespace string
+	// Dimensions lists the attribute keys used as metric dimensions.
+	Dimensions []string
+	// Metrics lists the attributes published as metrics.
+	Metrics []EMFMetric
+}
+
+// WithEMF makes FormatJSON output Embedded Metric Format documents: records carrying at
+// least one of the configured numeric metrics get an "_aws" metadata block, so CloudWatch
+// Logs extracts them as metrics automatically.
+func WithEMF(emf EMFOptions) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.EMF = &emf
+	})
+}
+
+// emfMetadata builds the "_aws" member describing the metrics found in attrs.
+func (h *PrettyHandler) emfMetadata(e entry, attrs fields) (field, bool) {
+	emf := h.opts.EMF
+	values := make(map[string]interface{}, len(attrs))
+	for _, f := range attrs {
+		values[f.key] = f.value
+	}
+
+	var metrics []interface{}
+	for _, m := range emf.Metrics {
+		if !isNumber(values[m.Name]) {
+			continue
+		}
+		def := fields{{key: "Name", value: m.Name}}
+		if m.Unit != "" {
+			def = append(def, field{key: "Unit", value: m.Unit})
+		}
+		metrics = append(metrics, def)
+	}
+	if len(metrics) == 0 {
+		return field{}, false
+	}
+
+	dims := []string{}
+	for _, d := range emf.Dimensions {
+		if _, ok := values[d]; ok {
+			dims = append(dims, d)
+		}
+	}
+
+	var ts int64
+	if e.time.Value.Kind() == slog.KindTime {
+		ts = e.time.Value.Time().UnixMilli()
+	}
+	return field{key: "_aws", value: fields{
+		{key: "Timestamp", value: ts},
+		{key: "CloudWatchMetrics", value: []interface{}{fields{
+			{key: "Namespace", value: emf.Namespace},
+			{key: "Dimensions", value: [][]string{dims}},
+			{key: "Metrics", value: metrics},
+		}}},
+	}}, true
+}
+
+// isNumber reports whether v holds a numeric value.
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,305 @@
+package slogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// entry is a log record after ReplaceAttr has been applied, ready to be encoded.
+// Built-in fields that were dropped are left as the zero slog.Attr.
+type entry struct {
+	time    slog.Attr
+	level   slog.Attr
+	message slog.Attr
+	source  slog.Attr
+	attrs   fields
+}
+
+// encode renders e in the configured output format.
+func (h *PrettyHandler) encode(e entry) ([]byte, error) {
+	switch h.opts.Format {
+	case FormatJSON:
+		return h.encodeJSON(e)
+	case FormatLogfmt:
+		return h.encodeLogfmt(e)
+	case FormatECS:
+		return h.encodeECS(e)
+	case FormatGELF:
+		return h.encodeGELF(e)
+	case FormatCEF:
+		return h.encodeCEF(e)
+	case FormatGCP:
+		return h.encodeGCP(e)
+	case FormatOTLP:
+		return h.encodeOTLP(e)
+	case FormatCSV:
+		return h.encodeCSV(e)
+	case FormatSyslog:
+		return h.encodeSyslog(e)
+	case FormatJournal:
+		return h.encodeJournal(e)
+	default:
+		return h.encodePretty(e)
+	}
+}
+
+// encodePretty renders e as the "time | level | msg | func | file:line" layout followed by
+// the indented JSON attributes, or with the configured Layout, Pattern or Segments.
+// Colors are left out for FormatText. Multi-line attributes follow the record when folded.
+func (h *PrettyHandler) encodePretty(e entry) ([]byte, error) {
+	var rest string
+	var blocks fields
+	if h.opts.Multiline == MultilineFold {
+		if e.message.Key != "" && e.message.Value.Kind() == slog.KindString {
+			var line string
+			line, rest = foldMessage(e.message.Value.String(), MultilineFold)
+			e.message.Value = slog.StringValue(line)
+		}
+		e.attrs, blocks = e.attrs.splitMultiline("")
+	}
+	b, err := h.encodeColumns(e)
+	if err != nil {
+		return nil, err
+	}
+	b = append(b, rest...)
+	return append(b, foldBlocks(blocks)...), nil
+}
+
+// encodeColumns renders the single record line of the pretty output.
+func (h *PrettyHandler) encodeColumns(e entry) ([]byte, error) {
+	d, err := h.layoutData(e)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.opts.Layout != nil {
+		var b strings.Builder
+		if err := h.opts.Layout.Execute(&b, d); err != nil {
+			return nil, err
+		}
+		return []byte(b.String()), nil
+	}
+
+	if p := h.opts.Pattern; p != nil {
+		plain := d
+		if p.override && !h.opts.NoColor && h.opts.Format != FormatText {
+			h2 := *h
+			h2.opts.NoColor = true
+			if plain, err = h2.layoutData(e); err != nil {
+				return nil, err
+			}
+		}
+		return []byte(p.render(h, d, plain)), nil
+	}
+
+	segments := h.opts.Segments
+	if len(segments) == 0 {
+		segments = DefaultSegments
+	}
+	return []byte(d.join(segments)), nil
+}
+
+// layoutData renders every segment of e.
+func (h *PrettyHandler) layoutData(e entry) (LayoutData, error) {
+	var d LayoutData
+	if e.time.Key != "" {
+		d.Time = h.formatTime(e.time.Value)
+	}
+	if e.level.Key != "" {
+		d.Level = h.formatLevel(e.level.Value)
+	}
+	if e.message.Key != "" {
+		d.Message = h.colorize(color.FgBlue, h.message(e.message.Value))
+	}
+	if e.source.Key != "" {
+		if src := h.formatSource(e.source.Value); len(src) == 2 {
+			d.Func, d.Source = src[0], src[1]
+		} else {
+			d.Source = src[0]
+		}
+	}
+
+	if h.opts.TreeDepth > 0 {
+		d.Attrs = h.renderTree(e.attrs)
+		d.hide(h.opts.Hidden)
+		return d, nil
+	}
+
+	var b []byte
+	var err error
+	if h.opts.NoIndent {
+		b, err = json.Marshal(e.attrs)
+	} else {
+		b, err = json.MarshalIndent(e.attrs, "", "  ")
+	}
+	if err != nil {
+		return d, err
+	}
+	d.Attrs = string(b)
+	d.hide(h.opts.Hidden)
+	return d, nil
+}
+
+// message renders the message segment, escaping newlines when configured.
+func (h *PrettyHandler) message(v slog.Value) string {
+	msg, _ := foldMessage(v.String(), h.opts.Multiline)
+	return msg
+}
+
+// encodeJSON renders e as a single-line JSON object with the built-in fields first.
+func (h *PrettyHandler) encodeJSON(e entry) ([]byte, error) {
+	fs := make(fields, 0, len(e.attrs)+5)
+	if h.opts.EMF != nil {
+		// EMF looks metrics and dimensions up by their dotted key at the document root.
+		e.attrs = e.attrs.flatten("")
+		if aws, ok := h.emfMetadata(e, e.attrs); ok {
+			fs = append(fs, aws)
+		}
+	}
+	if e.time.Key != "" {
+		fs = append(fs, field{key: e.time.Key, value: h.timeValue(e.time.Value, time.RFC3339Nano)})
+	}
+	if e.level.Key != "" {
+		fs = append(fs, field{key: e.level.Key, value: levelName(e.level.Value)})
+	}
+	if e.message.Key != "" {
+		fs = append(fs, field{key: e.message.Key, value: e.message.Value.Any()})
+	}
+	if e.source.Key != "" {
+		fs = append(fs, field{key: e.source.Key, value: e.source.Value.Any()})
+	}
+	fs = append(fs, e.attrs...)
+	return json.Marshal(fs)
+}
+
+// levelName returns the display name of a level value, honoring LevelNames.
+func levelName(v slog.Value) string {
+	l, ok := v.Any().(slog.Level)
+	if !ok {
+		return v.String()
+	}
+	if name, ok := LevelNames[l]; ok {
+		return name
+	}
+	return l.String()
+}
+
+// formatTime renders the time field, falling back to the plain value when ReplaceAttr changed its kind.
+// Only the console output gets the zone suffix, so machine formats stay parseable.
+func (h *PrettyHandler) formatTime(v slog.Value) string {
+	s := fmt.Sprint(h.timeValue(v, time.DateTime))
+	if h.opts.ZoneSuffix && v.Kind() == slog.KindTime {
+		t := v.Time()
+		if h.opts.Location != nil {
+			t = t.In(h.opts.Location)
+		}
+		s += " " + t.Format("MST")
+	}
+	return h.colorize(color.FgGreen, s)
+}
+
+// Shortcut names accepted by TimeFormat in addition to time.Format layouts.
+const (
+	TimeFormatRFC3339     = "rfc3339"
+	TimeFormatRFC3339Nano = "rfc3339nano"
+	TimeFormatKitchen     = "kitchen"
+	TimeFormatDateTime    = "datetime"
+	TimeFormatUnix        = "unix"      // seconds since the Unix epoch, as a number
+	TimeFormatUnixMilli   = "unixmilli" // milliseconds since the Unix epoch, as a number
+)
+
+var timeLayouts = map[string]string{
+	TimeFormatRFC3339:     time.RFC3339,
+	TimeFormatRFC3339Nano: time.RFC3339Nano,
+	TimeFormatKitchen:     time.Kitchen,
+	TimeFormatDateTime:    time.DateTime,
+}
+
+// timeValue renders the time field with the configured TimeFormat, or with def when none
+// is set. Unix formats yield numbers; a value whose kind was changed by ReplaceAttr is kept as is.
+func (h *PrettyHandler) timeValue(v slog.Value, def string) interface{} {
+	if v.Kind() != slog.KindTime {
+		return v.Any()
+	}
+	t := v.Time()
+	if h.opts.Location != nil {
+		t = t.In(h.opts.Location)
+	}
+
+	layout := h.opts.TimeFormat
+	if layout == "" {
+		layout = def
+	}
+	switch strings.ToLower(layout) {
+	case TimeFormatUnix:
+		return t.Unix()
+	case TimeFormatUnixMilli:
+		return t.UnixMilli()
+	}
+	if l, ok := timeLayouts[strings.ToLower(layout)]; ok {
+		layout = l
+	}
+	return t.Format(layout)
+}
+
+// formatLevel renders the level field with its custom name and color.
+func (h *PrettyHandler) formatLevel(v slog.Value) string {
+	level := levelName(v)
+	l, ok := v.Any().(slog.Level)
+	if !ok {
+		return level
+	}
+
+	// Change color based on log level
+	switch l {
+	case slog.LevelDebug:
+		level = h.colorize(color.FgMagenta, level)
+	case slog.LevelInfo:
+		level = h.colorize(color.FgGreen, level+" ")
+	case slog.LevelWarn:
+		level = h.colorize(color.FgYellow, level+" ")
+	case slog.LevelError:
+		level = h.colorize(color.FgRed, level)
+	case LevelFatal:
+		level = h.colorize(color.FgRed, level)
+	}
+	return level
+}
+
+// formatSource renders the source field as the "func | file:line" columns.
+func (h *PrettyHandler) formatSource(v slog.Value) []string {
+	src, ok := v.Any().(*slog.Source)
+	if !ok {
+		return []string{v.String()}
+	}
+	file := src.File
+	if !h.opts.FullPath {
+		file = filepath.Base(file)
+	}
+	return []string{
+		h.colorize(color.FgCyan, filepath.Base(src.Function)),
+		fmt.Sprintf("%v:%v", file, src.Line),
+	}
+}
+
+// colorize wraps s in the given color unless colors are disabled for this handler.
+func (h *PrettyHandler) colorize(attr color.Attribute, s string) string {
+	if h.opts.NoColor || h.opts.Format == FormatText {
+		return s
+	}
+	return color.New(attr).Sprint(s)
+}
+
+// sourceString renders a source value as "file:line".
+func sourceString(v interface{}) interface{} {
+	if src, ok := v.(*slog.Source); ok {
+		return fmt.Sprintf("%s:%d", src.File, src.Line)
+	}
+	return v
+}
@@ -0,0 +1,45 @@
+package slogger
+
+import (
+	"os"
+	"strings"
+)
+
+// Environment variables read by MakeLoggerFromEnv.
+const (
+	EnvLevel   = "SLOGGER_LEVEL"
+	EnvFormat  = "SLOGGER_FORMAT"
+	EnvOutput  = "SLOGGER_OUTPUT"
+	EnvNoColor = "NO_COLOR"
+)
+
+// ConfigFromEnv builds a Config from the SLOGGER_LEVEL, SLOGGER_FORMAT, SLOGGER_OUTPUT
+// and NO_COLOR environment variables. SLOGGER_OUTPUT may list several comma-separated outputs.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Level:  os.Getenv(EnvLevel),
+		Format: os.Getenv(EnvFormat),
+	}
+	if out := os.Getenv(EnvOutput); out != "" {
+		for _, name := range strings.Split(out, ",") {
+			cfg.Outputs = append(cfg.Outputs, strings.TrimSpace(name))
+		}
+	}
+	// See https://no-color.org: any non-empty value disables colors.
+	if os.Getenv(EnvNoColor) != "" {
+		noColor := false
+		cfg.Color = &noColor
+	}
+	return cfg
+}
+
+// MakeLoggerFromEnv initializes the global slogger instance from the environment, see ConfigFromEnv.
+// Close releases the files it opened.
+func MakeLoggerFromEnv() error {
+	l, err := NewFromConfig(ConfigFromEnv())
+	if err != nil {
+		return err
+	}
+	SetDefault(l)
+	return nil
+}
@@ -0,0 +1,95 @@
+//go:build windows
+
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// EventLogOptions configures an EventLogHandler.
+type EventLogOptions struct {
+	// Source is the event source name shown in Event Viewer.
+	Source string
+
+	// EventID is the ID of every event, between 1 and 1000. Defaults to 1.
+	EventID uint32
+
+	// Install registers Source in the registry if it is not registered yet, using
+	// EventCreate.exe as the message file. It requires administrator rights and is
+	// usually done once by the installer of a service.
+	Install bool
+}
+
+// EventLogHandler writes records to the Windows Event Log as Error, Warning or
+// Information events, so Windows services integrate with Event Viewer. The event text is
+// the FormatText rendering of the record.
+type EventLogHandler struct {
+	inner *PrettyHandler
+	log   *eventlog.Log
+	id    uint32
+}
+
+// NewEventLogHandler opens the event log for the given source. It accepts the same
+// options as NewPrettyHandler; the output format is fixed. Call Close when done.
+func NewEventLogHandler(el EventLogOptions, opts ...Option) (*EventLogHandler, error) {
+	if el.Install {
+		err := eventlog.InstallAsEventCreate(el.Source, eventlog.Error|eventlog.Warning|eventlog.Info)
+		if err != nil && !strings.Contains(err.Error(), "already exists") {
+			return nil, fmt.Errorf("slogger: install event source: %w", err)
+		}
+	}
+	log, err := eventlog.Open(el.Source)
+	if err != nil {
+		return nil, fmt.Errorf("slogger: open event log: %w", err)
+	}
+	if el.EventID == 0 {
+		el.EventID = 1
+	}
+	inner := NewPrettyHandler(nil, append(opts, WithFormat(FormatText), WithIndent(false))...)
+	return &EventLogHandler{inner: inner, log: log, id: el.EventID}, nil
+}
+
+func (h *EventLogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+// Handle reports r as an event whose type follows its level: Error from slog.LevelError,
+// Warning from slog.LevelWarn and Information below.
+func (h *EventLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	var buf bytes.Buffer
+	if err := h.inner.withWriter(&buf).Handle(ctx, r); err != nil {
+		return err
+	}
+	msg := strings.TrimSuffix(buf.String(), "\n")
+	if msg == "" {
+		return nil
+	}
+
+	switch {
+	case r.Level >= slog.LevelError:
+		return h.log.Error(h.id, msg)
+	case r.Level >= slog.LevelWarn:
+		return h.log.Warning(h.id, msg)
+	default:
+		return h.log.Info(h.id, msg)
+	}
+}
+
+func (h *EventLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &EventLogHandler{inner: h.inner.WithAttrs(attrs).(*PrettyHandler), log: h.log, id: h.id}
+}
+
+func (h *EventLogHandler) WithGroup(name string) slog.Handler {
+	return &EventLogHandler{inner: h.inner.WithGroup(name).(*PrettyHandler), log: h.log, id: h.id}
+}
+
+// Close closes the event log. Handlers derived from h must not be used afterwards.
+func (h *EventLogHandler) Close() error {
+	return h.log.Close()
+}
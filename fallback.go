@@ -0,0 +1,97 @@
+package slogger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// FallbackOptions configures a FallbackWriter.
+type FallbackOptions struct {
+	// Fallback receives the records the primary writer fails to write. Defaults to
+	// os.Stderr.
+	Fallback io.Writer
+
+	// RetryInterval is how long records go straight to the fallback after a failure
+	// before the primary writer is tried again. Defaults to ten seconds.
+	RetryInterval time.Duration
+
+	// OnError, if not nil, also receives the failures of the primary writer.
+	OnError func(error)
+}
+
+// FallbackWriter is an io.Writer passing records to a primary writer, such as a file or
+// a sink, and switching to a fallback writer when it fails, for example on a full disk
+// or a broken pipe. A diagnostic line is written to the fallback when the primary fails
+// and when it recovers, so the switch is visible where the records went.
+type FallbackWriter struct {
+	primary io.Writer
+	opts    FallbackOptions
+
+	mu      sync.Mutex
+	failed  time.Time // when the primary last failed, zero while it works
+	written int       // records written to the fallback since the primary failed
+}
+
+// NewFallbackWriter returns a writer falling back from primary as configured by opts.
+func NewFallbackWriter(primary io.Writer, opts FallbackOptions) *FallbackWriter {
+	if opts.Fallback == nil {
+		opts.Fallback = os.Stderr
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = 10 * time.Second
+	}
+	return &FallbackWriter{primary: primary, opts: opts}
+}
+
+// Write writes p to the primary writer, or to the fallback if the primary fails or failed
+// less than RetryInterval ago.
+func (w *FallbackWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.failed.IsZero() || time.Since(w.failed) >= w.opts.RetryInterval {
+		_, err := w.primary.Write(p)
+		if err == nil {
+			if !w.failed.IsZero() {
+				fmt.Fprintf(w.opts.Fallback, "slogger: output recovered, %d records were written here\n", w.written)
+				w.failed, w.written = time.Time{}, 0
+			}
+			return len(p), nil
+		}
+		if w.failed.IsZero() {
+			err = fmt.Errorf("slogger: output failed, writing to fallback: %w", err)
+			fmt.Fprintln(w.opts.Fallback, err)
+			if w.opts.OnError != nil {
+				w.opts.OnError(err)
+			}
+		}
+		w.failed = time.Now()
+	}
+
+	w.written++
+	return w.opts.Fallback.Write(p)
+}
+
+// Failing reports whether records currently go to the fallback.
+func (w *FallbackWriter) Failing() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !w.failed.IsZero()
+}
+
+// Flush flushes the primary writer if it buffers records, as the sinks of this package do.
+func (w *FallbackWriter) Flush(ctx context.Context) error {
+	return flushNode(ctx, w.primary)
+}
+
+// Close closes the primary writer if it is an io.Closer. The fallback is not closed.
+func (w *FallbackWriter) Close() error {
+	if c, ok := w.primary.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
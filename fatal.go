@@ -0,0 +1,47 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+)
+
+// exitTimeout bounds how long Exit waits for buffered records to be written.
+const exitTimeout = 5 * time.Second
+
+// Fatal logs a record at LevelFatal with the Default logger, then calls Exit(1), so the
+// record and everything logged before it reach the outputs even through an
+// AsyncHandler or a batching sink.
+func Fatal(msg string, args ...any) {
+	logFatal(context.Background(), msg, args...)
+}
+
+// FatalContext is Fatal with a context, for the trace ID and other context attributes.
+func FatalContext(ctx context.Context, msg string, args ...any) {
+	logFatal(ctx, msg, args...)
+}
+
+// logFatal logs with a source pointing at the caller of Fatal or FatalContext and exits.
+func logFatal(ctx context.Context, msg string, args ...any) {
+	l := Default()
+	if l.Enabled(ctx, LevelFatal) {
+		var pcs [1]uintptr
+		runtime.Callers(3, pcs[:]) // skip Callers, logFatal and Fatal
+		r := slog.NewRecord(time.Now(), LevelFatal, msg, pcs[0])
+		r.Add(args...)
+		l.Handler().Handle(ctx, r)
+	}
+	Exit(1)
+}
+
+// Exit writes the records buffered below the Default logger and closes its outputs, as
+// Close does, waiting at most five seconds, and then exits with the given status. Use it
+// instead of os.Exit, which would lose the records still queued.
+func Exit(code int) {
+	ctx, cancel := context.WithTimeout(context.Background(), exitTimeout)
+	Close(ctx)
+	cancel()
+	os.Exit(code)
+}
@@ -0,0 +1,145 @@
+package slogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// field is a single key/value pair of a log record.
+type field struct {
+	key   string
+	value interface{}
+}
+
+// fields is an ordered list of key/value pairs that marshals to a JSON object
+// with the keys in the order they were logged.
+type fields []field
+
+// MarshalJSON implements json.Marshaler, preserving the order of the fields.
+func (fs fields) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range fs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(marshalValue(f.value))
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping the keys of the object and of the
+// objects nested in it in their original order. Numbers are decoded as json.Number.
+func (fs *fields) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	v, err := decodeOrdered(dec)
+	if err != nil {
+		return err
+	}
+	obj, ok := v.(fields)
+	if !ok {
+		return fmt.Errorf("slogger: cannot decode %T into fields", v)
+	}
+	*fs = obj
+	return nil
+}
+
+// decodeOrdered decodes the next value of dec, with objects as fields.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := fields{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, field{key: key.(string), value: v})
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err := dec.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+// take removes the field with the given key from fs and returns its value.
+func (fs *fields) take(key string) (interface{}, bool) {
+	for i, f := range *fs {
+		if f.key == key {
+			*fs = append((*fs)[:i:i], (*fs)[i+1:]...)
+			return f.value, true
+		}
+	}
+	return nil, false
+}
+
+// has reports whether fs holds a field with the given key.
+func (fs fields) has(key string) bool {
+	for _, f := range fs {
+		if f.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// flatten replaces nested groups with dot-separated keys prefixed by prefix.
+func (fs fields) flatten(prefix string) fields {
+	out := make(fields, 0, len(fs))
+	for _, f := range fs {
+		key := f.key
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if group, ok := f.value.(fields); ok {
+			out = append(out, group.flatten(key)...)
+			continue
+		}
+		out = append(out, field{key: key, value: f.value})
+	}
+	return out
+}
+
+// marshalValue encodes a single field value. Errors without their own JSON encoding are
+// rendered as their message, and values that cannot be encoded are replaced by a
+// descriptive string so the rest of the record is not lost.
+func marshalValue(v interface{}) []byte {
+	if err, ok := v.(error); ok {
+		if _, ok := v.(json.Marshaler); !ok {
+			v = err.Error()
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprintf("!ERROR: %v", err))
+	}
+	return b
+}
@@ -0,0 +1,439 @@
+package slogger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RotateNaming selects how rotated files of a FileSink are named.
+type RotateNaming int
+
+const (
+	// RotateIndex renames app.log to app.log.1, shifting older backups to app.log.2 and so on.
+	RotateIndex RotateNaming = iota
+	// RotateTimestamp renames app.log to app-2006-01-02T15-04-05.000.log using the rotation time.
+	RotateTimestamp
+)
+
+// backupTimeFormat is the timestamp layout of RotateTimestamp backups.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotateInterval selects time-based rotation of a FileSink.
+type RotateInterval int
+
+const (
+	// RotateNever disables time-based rotation.
+	RotateNever RotateInterval = iota
+	// RotateHourly rotates at the start of every hour, naming backups app-2006-01-02T15.log.
+	RotateHourly
+	// RotateDaily rotates at midnight, naming backups app-2006-01-02.log.
+	RotateDaily
+)
+
+// layout returns the layout naming the period of i.
+func (i RotateInterval) layout() string {
+	switch i {
+	case RotateHourly:
+		return "2006-01-02T15"
+	case RotateDaily:
+		return "2006-01-02"
+	}
+	return ""
+}
+
+// FileSinkOptions configures a FileSink.
+type FileSinkOptions struct {
+	// MaxSize is the size in bytes after which the file is rotated. Zero disables
+	// size-based rotation.
+	MaxSize int64
+
+	// Naming selects how rotated files are named. Defaults to RotateIndex. It is ignored
+	// when Interval is set.
+	Naming RotateNaming
+
+	// Interval rotates the file when the hour or day of the local time changes. Backups are
+	// named after the period they cover, such as app-2024-05-01.log, with a counter added
+	// when MaxSize rotates the file more than once per period.
+	Interval RotateInterval
+
+	// Perm is the permission of newly created files. Defaults to 0644.
+	Perm os.FileMode
+
+	// MaxBackups is the number of rotated files to keep. Zero keeps all of them.
+	MaxBackups int
+
+	// MaxAge removes rotated files last written longer ago than MaxAge. Zero keeps them
+	// regardless of age.
+	MaxAge time.Duration
+
+	// BeforeRemove, if not nil, is called with the path of every rotated file before the
+	// retention policy deletes it, for example to archive it. Returning an error keeps
+	// the file. It runs in the background, without holding up writes or rotations.
+	BeforeRemove func(path string) error
+
+	// Compress gzips rotated files in the background, adding a .gz extension.
+	Compress bool
+
+	// CompressLevel is the gzip compression level, see compress/gzip. Zero selects
+	// gzip.DefaultCompression.
+	CompressLevel int
+
+	// CompressWorkers bounds how many files are compressed at once. Defaults to 1.
+	CompressWorkers int
+
+	// MaxTotalSize caps the bytes used by the current file and all rotated files together.
+	// Zero disables the quota. See QuotaPolicy.
+	MaxTotalSize int64
+
+	// QuotaPolicy selects what happens when MaxTotalSize is exceeded. Defaults to
+	// QuotaDeleteOldest.
+	QuotaPolicy QuotaPolicy
+
+	// OnQuota, if not nil, is called with the bytes in use and the limit when the quota is
+	// first exceeded, for example to raise an alert. It is called again only after usage
+	// dropped below the limit.
+	OnQuota func(used, limit int64)
+
+	// ReopenOnSignal reopens the file on SIGHUP, see FileSink.Reopen. It has no effect on
+	// platforms without SIGHUP.
+	ReopenOnSignal bool
+
+	// OnError, if not nil, receives errors from background work such as compressing or
+	// removing old files, or reopening the file on SIGHUP.
+	OnError func(error)
+}
+
+// QuotaPolicy selects how a FileSink enforces MaxTotalSize.
+type QuotaPolicy int
+
+const (
+	// QuotaDeleteOldest removes the oldest rotated files in the background until the total
+	// fits again. Writes are refused only when the current file alone exceeds the quota.
+	QuotaDeleteOldest QuotaPolicy = iota
+	// QuotaStop refuses writes with ErrQuotaExceeded while the quota is exceeded.
+	QuotaStop
+)
+
+// ErrQuotaExceeded is returned by FileSink.Write when a record does not fit into MaxTotalSize.
+var ErrQuotaExceeded = errors.New("slogger: file sink disk quota exceeded")
+
+// FileSink is an io.Writer appending to a file and rotating it once it grows past
+// MaxSize or a new period starts. Rotated files are compressed and removed in the
+// background according to the retention options. It is safe for concurrent use; a
+// single Write is never split across files, so records written by a handler stay intact.
+type FileSink struct {
+	path string
+	opts FileSinkOptions
+
+	mu     sync.Mutex
+	file   *os.File // nil after a rotation failed to open the new file; reopened by the next write
+	closed bool
+	size   int64
+	period string // the period covered by the current file when Interval is set
+	now    func() time.Time
+
+	// bmu guards the rotated files against concurrent renames and removals.
+	bmu       sync.Mutex
+	used      atomic.Int64 // bytes used by the current file, readable without mu
+	backed    atomic.Int64 // bytes used by rotated files as of the last count
+	overQuota bool         // OnQuota was called and usage has not dropped below the limit
+	millCh    chan struct{}
+	millDone  chan struct{}
+
+	// sig delivers SIGHUP when ReopenOnSignal is set.
+	sig     chan os.Signal
+	sigDone chan struct{}
+	sigOnce sync.Once
+}
+
+// NewFileSink opens path for appending, creating it and its directory if needed.
+func NewFileSink(path string, opts FileSinkOptions) (*FileSink, error) {
+	if opts.Perm == 0 {
+		opts.Perm = 0o644
+	}
+	s := &FileSink{path: path, opts: opts, now: time.Now}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("slogger: open file sink: %w", err)
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	if opts.MaxTotalSize > 0 {
+		s.backed.Store(backupSize(s.backups()))
+	}
+	if opts.MaxBackups > 0 || opts.MaxAge > 0 || opts.Compress || opts.MaxTotalSize > 0 {
+		s.startMill()
+		s.triggerMill()
+	}
+	if opts.ReopenOnSignal && sigReopen != nil {
+		s.sig = make(chan os.Signal, 1)
+		s.sigDone = make(chan struct{})
+		signal.Notify(s.sig, sigReopen)
+		go func() {
+			defer close(s.sigDone)
+			for range s.sig {
+				if err := s.Reopen(); err != nil {
+					s.report(err)
+				}
+			}
+		}()
+	}
+	return s, nil
+}
+
+// Reopen closes and reopens the file at the configured path, for use with external
+// rotation tools such as logrotate: once the file was renamed, Reopen starts a new one.
+// Writes wait during the swap, so no record is lost or written to the moved file after
+// Reopen returns. If the new file cannot be opened, writing continues to the old one.
+// It implements Reopener.
+func (s *FileSink) Reopen() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return os.ErrClosed
+	}
+	old := s.file
+	if err := s.open(); err != nil {
+		return err
+	}
+	if old == nil {
+		return nil
+	}
+	if err := old.Close(); err != nil {
+		return fmt.Errorf("slogger: reopen %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// Write appends p to the file, rotating it first when p would push it past MaxSize or
+// when a new period started.
+//
+// Periods are compared rather than deadlines, so a file left over from a previous run or
+// a clock jumping in either direction rotates on the next write instead of appending the
+// record to the wrong period. If a rotation could not open the new file, each write tries
+// again, so the sink recovers once the disk or directory is fixed.
+func (s *FileSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, os.ErrClosed
+	}
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return 0, err
+		}
+	}
+	if s.opts.Interval != RotateNever && s.size > 0 && s.periodOf(s.now()) != s.period {
+		if err := s.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	if s.opts.MaxSize > 0 && s.size > 0 && s.size+int64(len(p)) > s.opts.MaxSize {
+		if err := s.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	if s.opts.MaxTotalSize > 0 && !s.checkQuota(int64(len(p))) {
+		return 0, ErrQuotaExceeded
+	}
+	n, err := s.file.Write(p)
+	s.size += int64(n)
+	s.used.Store(s.size)
+	return n, err
+}
+
+// checkQuota reports whether n more bytes fit into MaxTotalSize, alerting OnQuota when
+// the quota is first exceeded. s.mu must be held.
+func (s *FileSink) checkQuota(n int64) bool {
+	limit := s.opts.MaxTotalSize
+	total := s.size + n + s.backed.Load()
+	if total <= limit {
+		s.overQuota = false
+		return true
+	}
+	if !s.overQuota {
+		s.overQuota = true
+		if s.opts.OnQuota != nil {
+			s.opts.OnQuota(total, limit)
+		}
+	}
+	if s.opts.QuotaPolicy == QuotaDeleteOldest {
+		s.triggerMill()
+		return s.size+n <= limit
+	}
+	return false
+}
+
+// Rotate closes the current file, renames it to a backup and starts a new one.
+func (s *FileSink) Rotate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return os.ErrClosed
+	}
+	if s.file == nil {
+		return s.open()
+	}
+	return s.rotate()
+}
+
+// Close closes the current file and waits for background work to finish.
+func (s *FileSink) Close() error {
+	s.sigOnce.Do(func() {
+		if s.sig != nil {
+			signal.Stop(s.sig)
+			close(s.sig)
+			<-s.sigDone
+		}
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	var err error
+	if s.file != nil {
+		err = s.file.Close()
+		s.file = nil
+	}
+	if s.millCh != nil {
+		close(s.millCh)
+		<-s.millDone
+	}
+	return err
+}
+
+// open opens the current file and records its size.
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, s.opts.Perm)
+	if err != nil {
+		return fmt.Errorf("slogger: open file sink: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("slogger: open file sink: %w", err)
+	}
+	s.file, s.size = f, info.Size()
+	s.used.Store(s.size)
+	if s.opts.Interval != RotateNever {
+		// An existing file belongs to the period it was last written in.
+		t := s.now()
+		if s.size > 0 {
+			t = info.ModTime()
+		}
+		s.period = s.periodOf(t)
+	}
+	return nil
+}
+
+// periodOf returns the name of the rotation period containing t.
+func (s *FileSink) periodOf(t time.Time) string {
+	return t.Format(s.opts.Interval.layout())
+}
+
+// rotate moves the current file aside and opens a new one. s.mu must be held and s.file
+// set. On failure s.file may be left nil, for Write to reopen.
+func (s *FileSink) rotate() error {
+	err := s.file.Close()
+	s.file = nil
+	if err != nil {
+		return fmt.Errorf("slogger: rotate %s: %w", s.path, err)
+	}
+
+	s.bmu.Lock()
+	switch {
+	case s.opts.Interval != RotateNever:
+		err = os.Rename(s.path, s.backupName(s.period))
+	case s.opts.Naming == RotateTimestamp:
+		err = os.Rename(s.path, s.backupName(s.now().Format(backupTimeFormat)))
+	default:
+		err = s.shiftIndexed()
+	}
+	s.bmu.Unlock()
+	if err == nil {
+		s.backed.Add(s.size)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		// Keep writing to the current file rather than losing records.
+		if openErr := s.open(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("slogger: rotate %s: %w", s.path, err)
+	}
+	s.triggerMill()
+	return s.open()
+}
+
+// backupName returns an unused backup name for the given stamp: app.log becomes
+// app-<stamp>.log, or app-<stamp>-N.log when that name is taken.
+func (s *FileSink) backupName(stamp string) string {
+	ext := filepath.Ext(s.path)
+	base := strings.TrimSuffix(s.path, ext) + "-" + stamp
+	name := base + ext
+	for n := 1; ; n++ {
+		if _, err := os.Lstat(name); os.IsNotExist(err) {
+			return name
+		}
+		name = base + "-" + strconv.Itoa(n) + ext
+	}
+}
+
+// shiftIndexed renames app.log.N to app.log.N+1, oldest first, and app.log to app.log.1.
+// Compressed backups keep their .gz extension.
+func (s *FileSink) shiftIndexed() error {
+	indexes := s.backupIndexes()
+	for i := len(indexes) - 1; i >= 0; i-- {
+		b := indexes[i]
+		if err := os.Rename(s.indexedName(b.n)+b.ext, s.indexedName(b.n+1)+b.ext); err != nil {
+			return err
+		}
+	}
+	return os.Rename(s.path, s.indexedName(1))
+}
+
+// indexedName returns the RotateIndex backup name with index n.
+func (s *FileSink) indexedName(n int) string {
+	return s.path + "." + strconv.Itoa(n)
+}
+
+// indexedBackup is an existing RotateIndex backup with its index and compression extension.
+type indexedBackup struct {
+	n   int
+	ext string
+}
+
+// backupIndexes returns the existing RotateIndex backups in ascending order of index.
+func (s *FileSink) backupIndexes() []indexedBackup {
+	entries, _ := os.ReadDir(filepath.Dir(s.path))
+	prefix := filepath.Base(s.path) + "."
+	var indexes []indexedBackup
+	for _, e := range entries {
+		rest, ok := strings.CutPrefix(e.Name(), prefix)
+		if !ok {
+			continue
+		}
+		rest, gz := strings.CutSuffix(rest, gzipExt)
+		if n, err := strconv.Atoi(rest); err == nil && n > 0 {
+			b := indexedBackup{n: n}
+			if gz {
+				b.ext = gzipExt
+			}
+			indexes = append(indexes, b)
+		}
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].n < indexes[j].n })
+	return indexes
+}
@@ -0,0 +1,257 @@
+package slogger
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dirFiles returns the contents of the files in dir by name, decompressing .gz files.
+func dirFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, e := range entries {
+		f, err := os.Open(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r io.Reader = f
+		if strings.HasSuffix(e.Name(), gzipExt) {
+			if r, err = gzip.NewReader(f); err != nil {
+				t.Fatalf("%s: %v", e.Name(), err)
+			}
+		}
+		b, err := io.ReadAll(r)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", e.Name(), err)
+		}
+		files[e.Name()] = string(b)
+	}
+	return files
+}
+
+// names returns the sorted keys of files.
+func names(files map[string]string) string {
+	var list []string
+	for name := range files {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return strings.Join(list, " ")
+}
+
+// writeRecords writes each record to s, pausing so that the backups have distinct
+// modification times.
+func writeRecords(t *testing.T, s *FileSink, records ...string) {
+	t.Helper()
+	for _, rec := range records {
+		if _, err := s.Write([]byte(rec)); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFileSinkRotatesAtMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFileSink(filepath.Join(dir, "app.log"), FileSinkOptions{MaxSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeRecords(t, s, "one\n", "two\n", "three\n", "a record longer than MaxSize\n", "four\n")
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files := dirFiles(t, dir)
+	want := map[string]string{
+		"app.log":   "four\n",
+		"app.log.1": "a record longer than MaxSize\n",
+		"app.log.2": "three\n",
+		"app.log.3": "one\ntwo\n",
+	}
+	if names(files) != names(want) {
+		t.Fatalf("files = %s, want %s", names(files), names(want))
+	}
+	for name, content := range want {
+		if files[name] != content {
+			t.Errorf("%s = %q, want %q", name, files[name], content)
+		}
+	}
+}
+
+func TestFileSinkRetention(t *testing.T) {
+	t.Run("max backups", func(t *testing.T) {
+		dir := t.TempDir()
+		s, err := NewFileSink(filepath.Join(dir, "app.log"), FileSinkOptions{MaxSize: 5, MaxBackups: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeRecords(t, s, "1111\n", "2222\n", "3333\n", "4444\n", "5555\n")
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+		files := dirFiles(t, dir)
+		if got := names(files); got != "app.log app.log.1 app.log.2" {
+			t.Fatalf("files = %s", got)
+		}
+		if files["app.log.1"] != "4444\n" || files["app.log.2"] != "3333\n" {
+			t.Errorf("kept backups %q and %q, want the two newest", files["app.log.1"], files["app.log.2"])
+		}
+	})
+
+	t.Run("max age", func(t *testing.T) {
+		dir := t.TempDir()
+		old := time.Now().Add(-2 * time.Hour)
+		for _, name := range []string{"app.log.1", "app.log.2", "app-2024-01-02.log.gz", "app.log.bak", "other.log.3"} {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if name != "app.log.1" {
+				if err := os.Chtimes(path, old, old); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		s, err := NewFileSink(filepath.Join(dir, "app.log"), FileSinkOptions{MaxAge: time.Hour})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+		// Files with names the sink doesn't produce are never touched.
+		if got := names(dirFiles(t, dir)); got != "app.log app.log.1 app.log.bak other.log.3" {
+			t.Errorf("files = %s", got)
+		}
+	})
+}
+
+func TestFileSinkCompress(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFileSink(filepath.Join(dir, "app.log"), FileSinkOptions{MaxSize: 5, Compress: true, CompressWorkers: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeRecords(t, s, "1111\n", "2222\n", "3333\n")
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	files := dirFiles(t, dir)
+	if got := names(files); got != "app.log app.log.1.gz app.log.2.gz" {
+		t.Fatalf("files = %s", got)
+	}
+	if files["app.log.1.gz"] != "2222\n" || files["app.log.2.gz"] != "1111\n" || files["app.log"] != "3333\n" {
+		t.Errorf("files = %q", files)
+	}
+}
+
+func TestFileSinkQuota(t *testing.T) {
+	t.Run("delete oldest", func(t *testing.T) {
+		dir := t.TempDir()
+		var alerts []int64
+		s, err := NewFileSink(filepath.Join(dir, "app.log"), FileSinkOptions{
+			MaxSize:      5,
+			MaxTotalSize: 12,
+			OnQuota:      func(used, limit int64) { alerts = append(alerts, used, limit) },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeRecords(t, s, "1111\n", "2222\n", "3333\n", "4444\n")
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+		files := dirFiles(t, dir)
+		if got := names(files); got != "app.log app.log.1" {
+			t.Errorf("files = %s, want the current file and the newest backup", got)
+		}
+		if files["app.log.1"] != "3333\n" {
+			t.Errorf("kept backup %q", files["app.log.1"])
+		}
+		if len(alerts) < 2 || alerts[0] != 15 || alerts[1] != 12 {
+			t.Errorf("OnQuota calls = %v, want (15, 12) first", alerts)
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		s, err := NewFileSink(filepath.Join(t.TempDir(), "app.log"), FileSinkOptions{MaxTotalSize: 8, QuotaPolicy: QuotaStop})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		if _, err := s.Write([]byte("1111\n")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Write([]byte("2222\n")); !errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("Write over the quota = %v, want ErrQuotaExceeded", err)
+		}
+	})
+}
+
+func TestFileSinkBeforeRemoveDoesNotBlockWrites(t *testing.T) {
+	dir := t.TempDir()
+	called := make(chan string, 10)
+	release := make(chan struct{})
+	s, err := NewFileSink(filepath.Join(dir, "app.log"), FileSinkOptions{
+		MaxSize:    5,
+		MaxBackups: 1,
+		BeforeRemove: func(path string) error {
+			called <- filepath.Base(path)
+			<-release
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeRecords(t, s, "1111\n", "2222\n", "3333\n")
+	select {
+	case name := <-called:
+		if name != "app.log.2" {
+			t.Errorf("BeforeRemove(%s), want app.log.2", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("BeforeRemove not called")
+	}
+
+	// The hook is still running: rotations and writes go on meanwhile, and the backup it
+	// was given is renamed to app.log.3.
+	written := make(chan error, 1)
+	go func() {
+		_, err := s.Write([]byte("4444\n"))
+		written <- err
+	}()
+	select {
+	case err := <-written:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Write waited for BeforeRemove")
+	}
+	close(release)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files := dirFiles(t, dir)
+	if got := names(files); got != "app.log app.log.1" {
+		t.Errorf("files = %s", got)
+	}
+	if files["app.log.1"] != "3333\n" {
+		t.Errorf("kept backup %q, want the newest", files["app.log.1"])
+	}
+}
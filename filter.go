@@ -0,0 +1,155 @@
+package slogger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sync/atomic"
+)
+
+// FilterAction is what a FilterRule does with the records it matches.
+type FilterAction int
+
+const (
+	// FilterDrop discards the record.
+	FilterDrop FilterAction = iota
+	// FilterKeep passes the record on, skipping the rules after this one.
+	FilterKeep
+)
+
+// FilterRule matches records by message and attributes. Every condition set must match;
+// a rule with none matches every record.
+type FilterRule struct {
+	Action FilterAction
+
+	// Message is a regular expression the message must match.
+	Message string
+
+	// Key and Value match records with an attribute Key, dotted for attributes inside
+	// groups, whose value renders as Value. An empty Value matches any value of Key.
+	Key   string
+	Value string
+
+	// Below, if set, restricts the rule to records below this level, for example to mute
+	// a component while still seeing its errors.
+	Below slog.Leveler
+
+	// Match, if set, must also report true. attrs holds the attributes of the record,
+	// including those bound with WithAttrs, by dotted key.
+	Match func(r slog.Record, attrs map[string]slog.Value) bool
+
+	msg *regexp.Regexp
+}
+
+// matches reports whether the record r matches fr. attrs computes its attributes.
+func (fr *FilterRule) matches(r slog.Record, attrs func() map[string]slog.Value) bool {
+	if fr.Below != nil && r.Level >= fr.Below.Level() {
+		return false
+	}
+	if fr.msg != nil && !fr.msg.MatchString(r.Message) {
+		return false
+	}
+	if fr.Key != "" {
+		v, ok := attrs()[fr.Key]
+		if !ok || (fr.Value != "" && v.String() != fr.Value) {
+			return false
+		}
+	}
+	return fr.Match == nil || fr.Match(r, attrs())
+}
+
+// FilterHandler drops or keeps records by rules, such as muting a noisy third-party
+// component:
+//
+//	h, err := slogger.NewFilterHandler(next,
+//		slogger.FilterRule{Action: slogger.FilterDrop, Key: "component", Value: "grpc", Below: slog.LevelWarn},
+//		slogger.FilterRule{Action: slogger.FilterDrop, Message: `^health check`},
+//	)
+//
+// The first rule matching a record decides, and records matching none pass; end with a
+// FilterDrop rule without conditions to keep only the records matched by FilterKeep
+// rules. The rules can be replaced at runtime with SetRules.
+type FilterHandler struct {
+	next   slog.Handler
+	rules  *atomic.Pointer[[]FilterRule]
+	prefix string
+	bound  []flatAttr
+}
+
+// NewFilterHandler wraps next with the given rules. It fails if the Message of a rule is
+// not a valid regular expression.
+func NewFilterHandler(next slog.Handler, rules ...FilterRule) (*FilterHandler, error) {
+	h := &FilterHandler{next: next, rules: &atomic.Pointer[[]FilterRule]{}}
+	if err := h.SetRules(rules...); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// SetRules replaces the rules of h and of the handlers derived from it. On error the
+// previous rules stay in place.
+func (h *FilterHandler) SetRules(rules ...FilterRule) error {
+	compiled := make([]FilterRule, len(rules))
+	for i, fr := range rules {
+		if fr.Message != "" {
+			re, err := regexp.Compile(fr.Message)
+			if err != nil {
+				return fmt.Errorf("slogger: filter rule %d: %w", i, err)
+			}
+			fr.msg = re
+		}
+		compiled[i] = fr
+	}
+	h.rules.Store(&compiled)
+	return nil
+}
+
+// Rules returns the current rules.
+func (h *FilterHandler) Rules() []FilterRule {
+	return append([]FilterRule(nil), *h.rules.Load()...)
+}
+
+func (h *FilterHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *FilterHandler) Handle(ctx context.Context, r slog.Record) error {
+	var attrs map[string]slog.Value
+	lazyAttrs := func() map[string]slog.Value {
+		if attrs == nil {
+			attrs = recordAttrs(h.bound, h.prefix, r)
+		}
+		return attrs
+	}
+	for _, fr := range *h.rules.Load() {
+		if fr.matches(r, lazyAttrs) {
+			if fr.Action == FilterDrop {
+				return nil
+			}
+			break
+		}
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *FilterHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &FilterHandler{
+		next:   h.next.WithAttrs(attrs),
+		rules:  h.rules,
+		prefix: h.prefix,
+		bound:  bindFlatAttrs(h.bound, h.prefix, attrs),
+	}
+}
+
+func (h *FilterHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &FilterHandler{
+		next:   h.next.WithGroup(name),
+		rules:  h.rules,
+		prefix: h.prefix + name + ".",
+		bound:  h.bound,
+	}
+}
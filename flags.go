@@ -0,0 +1,68 @@
+package slogger
+
+import (
+	"flag"
+	"log/slog"
+	"strings"
+)
+
+// Flags holds the logging flags registered by RegisterFlags.
+type Flags struct {
+	Level       string
+	Format      string
+	Output      string
+	Verbose     bool
+	VeryVerbose bool
+}
+
+// RegisterFlags adds -log-level, -log-format, -log-output, -v and -vv to fs, or to
+// flag.CommandLine when fs is nil. After parsing, call Apply to configure the global logger:
+//
+//	lf := slogger.RegisterFlags(nil)
+//	flag.Parse()
+//	if err := lf.Apply(); err != nil { ... }
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+
+	f := &Flags{}
+	fs.StringVar(&f.Level, "log-level", "info", "minimum log level: debug, info, warn, error or fatal")
+	fs.StringVar(&f.Format, "log-format", "pretty", "log format: pretty, json, text, logfmt, ecs, gelf, gcp or syslog")
+	fs.StringVar(&f.Output, "log-output", "stdout", "comma-separated log outputs: stdout, stderr or file paths")
+	fs.BoolVar(&f.Verbose, "v", false, "verbose logging, same as -log-level=debug")
+	fs.BoolVar(&f.VeryVerbose, "vv", false, "very verbose logging, below debug level")
+	return f
+}
+
+// Config returns the configuration selected by the flags. -vv takes precedence over -v,
+// which takes precedence over -log-level.
+func (f *Flags) Config() Config {
+	cfg := Config{
+		Level:  f.Level,
+		Format: f.Format,
+	}
+	switch {
+	case f.VeryVerbose:
+		cfg.Level = (slog.LevelDebug - levelStep).String()
+	case f.Verbose:
+		cfg.Level = slog.LevelDebug.String()
+	}
+	for _, name := range strings.Split(f.Output, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			cfg.Outputs = append(cfg.Outputs, name)
+		}
+	}
+	return cfg
+}
+
+// Apply builds a logger from the flags and makes it the global logger. Close releases the
+// files it opened.
+func (f *Flags) Apply() error {
+	l, err := NewFromConfig(f.Config())
+	if err != nil {
+		return err
+	}
+	SetDefault(l)
+	return nil
+}
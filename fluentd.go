@@ -0,0 +1,403 @@
+package slogger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FluentdOptions configures a FluentdSink.
+type FluentdOptions struct {
+	// Address is the host:port of the forward input, or unix:///path for a Unix socket.
+	// Defaults to localhost:24224.
+	Address string
+
+	// Tag routes the records in the fluentd or Fluent Bit pipeline. Defaults to "slogger".
+	Tag string
+
+	// RequireAck asks the server to acknowledge every batch. Batches not acknowledged
+	// within Timeout are sent again, so records may be delivered twice.
+	RequireAck bool
+
+	// Timeout bounds connecting, each batch and waiting for its acknowledgment. Defaults
+	// to five seconds.
+	Timeout time.Duration
+
+	// BatchSize, FlushInterval, MaxRetries, MaxBuffered, DeadLetter and Breaker tune
+	// batching as documented on WebhookOptions. Each batch is sent as one Forward mode
+	// message over a single connection.
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxRetries    int
+	MaxBuffered   int
+	DeadLetter    *DeadLetter
+	Breaker       *CircuitBreaker
+
+	// OnError, if not nil, receives failed deliveries.
+	OnError func(error)
+}
+
+// FluentdSink is an io.Writer sending the JSON records written by a FormatJSON handler to
+// fluentd or Fluent Bit with the forward protocol, as msgpack over TCP. The record time
+// becomes the event time. Call Close to send the remaining records.
+type FluentdSink struct {
+	*batcher
+	opts FluentdOptions
+
+	mu   sync.Mutex // guards conn for Close
+	conn net.Conn
+}
+
+// NewFluentdHandler creates a JSON handler forwarding records to fluentd and returns the
+// sink so it can be closed on shutdown. Handler options are applied as for
+// NewPrettyHandler.
+func NewFluentdHandler(fluentd FluentdOptions, opts ...Option) (*PrettyHandler, *FluentdSink) {
+	s := NewFluentdSink(fluentd)
+	return NewPrettyHandler(s, append(opts, WithFormat(FormatJSON), WithTimeFormat(TimeFormatRFC3339Nano))...), s
+}
+
+// NewFluentdSink starts a sink with the given options. The server is contacted with the
+// first batch.
+func NewFluentdSink(opts FluentdOptions) *FluentdSink {
+	if opts.Address == "" {
+		opts.Address = "localhost:24224"
+	}
+	if opts.Tag == "" {
+		opts.Tag = "slogger"
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	s := &FluentdSink{opts: opts}
+	s.batcher = newBatcher(batchOptions{
+		size:        opts.BatchSize,
+		interval:    opts.FlushInterval,
+		maxInFlight: 1,
+		maxRetries:  opts.MaxRetries,
+		maxBuffered: opts.MaxBuffered,
+		deadLetter:  opts.DeadLetter,
+		breaker:     opts.Breaker,
+		onError:     opts.OnError,
+		send:        s.send,
+	})
+	return s
+}
+
+// Close sends the remaining records and closes the connection.
+func (s *FluentdSink) Close() error {
+	err := s.batcher.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+// send writes one batch as a Forward mode message, [tag, [[time, record]...], option],
+// and waits for its acknowledgment if requested.
+func (s *FluentdSink) send(ctx context.Context, records [][]byte) error {
+	msg := appendMsgpackArray(nil, 3)
+	msg = appendMsgpackString(msg, s.opts.Tag)
+	msg = appendMsgpackArray(msg, len(records))
+	for _, rec := range records {
+		ts := time.Now()
+		var v struct {
+			Time string `json:"time"`
+		}
+		if json.Unmarshal(rec, &v) == nil {
+			if parsed, err := time.Parse(time.RFC3339Nano, v.Time); err == nil {
+				ts = parsed
+			}
+		}
+		msg = appendMsgpackArray(msg, 2)
+		msg = appendMsgpackEventTime(msg, ts)
+		if m, err := appendMsgpackJSON(msg, rec); err == nil && bytes.HasPrefix(rec, []byte{'{'}) {
+			msg = m
+		} else {
+			// Not a JSON object, such as a line from another format.
+			msg = appendMsgpackMap(msg, 1)
+			msg = appendMsgpackString(msg, "message")
+			msg = appendMsgpackString(msg, strings.TrimSpace(string(rec)))
+		}
+	}
+	var chunk string
+	if s.opts.RequireAck {
+		id := make([]byte, 16)
+		rand.Read(id)
+		chunk = base64.StdEncoding.EncodeToString(id)
+		msg = appendMsgpackMap(msg, 2)
+		msg = appendMsgpackString(msg, "chunk")
+		msg = appendMsgpackString(msg, chunk)
+	} else {
+		msg = appendMsgpackMap(msg, 1)
+	}
+	msg = appendMsgpackString(msg, "size")
+	msg = appendMsgpackInt(msg, int64(len(records)))
+
+	conn, err := s.connect(ctx)
+	if err != nil {
+		return &retryError{err: fmt.Errorf("slogger: fluentd connect: %w", err)}
+	}
+	conn.SetDeadline(time.Now().Add(s.opts.Timeout))
+	if _, err := conn.Write(msg); err != nil {
+		s.drop(conn)
+		return &retryError{err: fmt.Errorf("slogger: fluentd: %w", err)}
+	}
+	if chunk == "" {
+		return nil
+	}
+	resp, err := readMsgpackStringMap(bufio.NewReader(conn))
+	if err == nil && resp["ack"] != chunk {
+		err = fmt.Errorf("unexpected ack %q", resp["ack"])
+	}
+	if err != nil {
+		s.drop(conn)
+		return &retryError{err: fmt.Errorf("slogger: fluentd ack: %w", err)}
+	}
+	return nil
+}
+
+// connect returns the current connection or dials a new one.
+func (s *FluentdSink) connect(ctx context.Context) (net.Conn, error) {
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
+	if conn != nil {
+		return conn, nil
+	}
+	network, addr := "tcp", s.opts.Address
+	if path, ok := strings.CutPrefix(addr, "unix://"); ok {
+		network, addr = "unix", path
+	}
+	d := net.Dialer{Timeout: s.opts.Timeout}
+	conn, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.conn = conn
+	s.mu.Unlock()
+	return conn, nil
+}
+
+// drop closes a failed connection so the next batch reconnects.
+func (s *FluentdSink) drop(conn net.Conn) {
+	conn.Close()
+	s.mu.Lock()
+	if s.conn == conn {
+		s.conn = nil
+	}
+	s.mu.Unlock()
+}
+
+// appendMsgpackArray appends an array header of n elements.
+func appendMsgpackArray(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xdc), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, 0xdd), uint32(n))
+}
+
+// appendMsgpackMap appends a map header of n pairs.
+func appendMsgpackMap(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xde), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, 0xdf), uint32(n))
+}
+
+// appendMsgpackString appends a str value.
+func appendMsgpackString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+// appendMsgpackInt appends an integer as a fixint, int32 or int64.
+func appendMsgpackInt(b []byte, n int64) []byte {
+	switch {
+	case n >= 0 && n < 128:
+		return append(b, byte(n))
+	case n < 0 && n >= -32:
+		return append(b, byte(n))
+	case n >= math.MinInt32 && n <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(n))
+}
+
+// appendMsgpackEventTime appends t as the EventTime extension of the forward protocol,
+// with nanosecond precision.
+func appendMsgpackEventTime(b []byte, t time.Time) []byte {
+	b = append(b, 0xd7, 0x00)
+	b = binary.BigEndian.AppendUint32(b, uint32(t.Unix()))
+	return binary.BigEndian.AppendUint32(b, uint32(t.Nanosecond()))
+}
+
+// appendMsgpackJSON converts a JSON value to msgpack, keeping the order of object keys.
+func appendMsgpackJSON(b []byte, data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	b, err := appendMsgpackToken(b, dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("trailing data after JSON value")
+	}
+	return b, nil
+}
+
+// appendMsgpackToken converts the next JSON value of dec.
+func appendMsgpackToken(b []byte, dec *json.Decoder) ([]byte, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		// Elements are converted first since the header carries their count.
+		var elems []byte
+		n := 0
+		for dec.More() {
+			if t == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				elems = appendMsgpackString(elems, key.(string))
+			}
+			if elems, err = appendMsgpackToken(elems, dec); err != nil {
+				return nil, err
+			}
+			n++
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		if t == '{' {
+			b = appendMsgpackMap(b, n)
+		} else {
+			b = appendMsgpackArray(b, n)
+		}
+		return append(b, elems...), nil
+	case string:
+		return appendMsgpackString(b, t), nil
+	case json.Number:
+		if n, err := strconv.ParseInt(string(t), 10, 64); err == nil {
+			return appendMsgpackInt(b, n), nil
+		}
+		f, err := t.Float64()
+		if err != nil {
+			return appendMsgpackString(b, string(t)), nil
+		}
+		return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(f)), nil
+	case bool:
+		if t {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	}
+	return append(b, 0xc0), nil
+}
+
+// readMsgpackStringMap reads a msgpack map of strings, such as an ack response.
+func readMsgpackStringMap(r *bufio.Reader) (map[string]string, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	var n int
+	switch {
+	case c&0xf0 == 0x80:
+		n = int(c & 0x0f)
+	case c == 0xde:
+		var v uint16
+		err = binary.Read(r, binary.BigEndian, &v)
+		n = int(v)
+	default:
+		return nil, fmt.Errorf("expected a map, got 0x%02x", c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string, n)
+	for range n {
+		key, err := readMsgpackString(r)
+		if err != nil {
+			return nil, err
+		}
+		value, err := readMsgpackString(r)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = value
+	}
+	return m, nil
+}
+
+// readMsgpackString reads a str or bin value.
+func readMsgpackString(r *bufio.Reader) (string, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	var n int
+	switch c {
+	case 0xd9, 0xc4:
+		var v uint8
+		err = binary.Read(r, binary.BigEndian, &v)
+		n = int(v)
+	case 0xda, 0xc5:
+		var v uint16
+		err = binary.Read(r, binary.BigEndian, &v)
+		n = int(v)
+	case 0xdb, 0xc6:
+		var v uint32
+		err = binary.Read(r, binary.BigEndian, &v)
+		n = int(v)
+	default:
+		if c&0xe0 != 0xa0 {
+			return "", fmt.Errorf("expected a string, got 0x%02x", c)
+		}
+		n = int(c & 0x1f)
+	}
+	if err != nil {
+		return "", err
+	}
+	if n > maxResponseSize {
+		return "", fmt.Errorf("string of %d bytes", n)
+	}
+	buf := make([]byte, n)
+	_, err = io.ReadFull(r, buf)
+	return string(buf), err
+}
@@ -0,0 +1,402 @@
+package slogger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAppendMsgpack(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		got  []byte
+		want string // hex
+	}{
+		{"fixstr", appendMsgpackString(nil, "ab"), "a26162"},
+		{"fixstr max", appendMsgpackString(nil, strings.Repeat("a", 31)), "bf" + strings.Repeat("61", 31)},
+		{"str8", appendMsgpackString(nil, strings.Repeat("a", 32)), "d920" + strings.Repeat("61", 32)},
+		{"str16", appendMsgpackString(nil, strings.Repeat("a", 256))[:3], "da0100"},
+		{"str32", appendMsgpackString(nil, strings.Repeat("a", 65536))[:5], "db00010000"},
+		{"fixarray", appendMsgpackArray(nil, 15), "9f"},
+		{"array16", appendMsgpackArray(nil, 16), "dc0010"},
+		{"array32", appendMsgpackArray(nil, 65536), "dd00010000"},
+		{"fixmap", appendMsgpackMap(nil, 15), "8f"},
+		{"map16", appendMsgpackMap(nil, 16), "de0010"},
+		{"map32", appendMsgpackMap(nil, 65536), "df00010000"},
+		{"int 0", appendMsgpackInt(nil, 0), "00"},
+		{"int 127", appendMsgpackInt(nil, 127), "7f"},
+		{"int 128", appendMsgpackInt(nil, 128), "d200000080"},
+		{"int -32", appendMsgpackInt(nil, -32), "e0"},
+		{"int -33", appendMsgpackInt(nil, -33), "d2ffffffdf"},
+		{"int32 max", appendMsgpackInt(nil, math.MaxInt32), "d27fffffff"},
+		{"int64", appendMsgpackInt(nil, math.MaxInt32+1), "d30000000080000000"},
+		{"int64 min", appendMsgpackInt(nil, math.MinInt32-1), "d3ffffffff7fffffff"},
+		{"event time", appendMsgpackEventTime(nil, time.Unix(1700000000, 123456789)), "d7006553f100075bcd15"},
+	} {
+		if got := hex.EncodeToString(tt.got); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAppendMsgpackJSON(t *testing.T) {
+	got, err := appendMsgpackJSON(nil, []byte(`{"z":null,"b":true,"a":[1,-2,2.5],"o":{"k":"v"},"f":false}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "85" + // keys in their JSON order
+		"a17a" + "c0" +
+		"a162" + "c3" +
+		"a161" + "93" + "01" + "fe" + "cb4004000000000000" +
+		"a16f" + "81" + "a16b" + "a176" +
+		"a166" + "c2"
+	if hex.EncodeToString(got) != want {
+		t.Errorf("appendMsgpackJSON = %x\nwant                %s", got, want)
+	}
+
+	for _, in := range []string{
+		`{"time":"2024-01-02T03:04:05.123456789Z","level":"INFO","msg":"hello \"world\"","n":42,"neg":-1000,"big":9007199254740993,"f":0.1,"e":1e+300}`,
+		`{"nested":{"list":[{"k":[]},{},"s",null],"empty":""},"unicode":"héllo ☃"}`,
+		`{"long":"` + strings.Repeat("x", 70000) + `"}`,
+		`[1,2,3]`,
+		`"plain"`,
+	} {
+		b, err := appendMsgpackJSON(nil, []byte(in))
+		if err != nil {
+			t.Errorf("appendMsgpackJSON(%.40q): %v", in, err)
+			continue
+		}
+		r := bufio.NewReader(bytes.NewReader(b))
+		out, err := msgpackToJSON(r)
+		if err != nil {
+			t.Errorf("decoding %.40q: %v", in, err)
+			continue
+		}
+		if rest, _ := io.ReadAll(r); len(rest) > 0 {
+			t.Errorf("decoding %.40q left %d bytes", in, len(rest))
+		}
+		if out != in {
+			t.Errorf("round trip of %.80q = %.80q", in, out)
+		}
+	}
+
+	for _, in := range []string{``, `{"a":`, `{"a":1}{}`, `[1,]`, `{"a" 1}`} {
+		if _, err := appendMsgpackJSON(nil, []byte(in)); err == nil {
+			t.Errorf("appendMsgpackJSON(%q): no error", in)
+		}
+	}
+}
+
+// msgpackToJSON decodes one msgpack value written by the encoder of the sink into
+// compact JSON, keeping the order of map keys. Event times become "@<sec>.<nsec>".
+func msgpackToJSON(r *bufio.Reader) (string, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	read := func(n int) ([]byte, error) {
+		b := make([]byte, n)
+		_, err := io.ReadFull(r, b)
+		return b, err
+	}
+	uint := func(size int) (int, error) {
+		b, err := read(size)
+		if err != nil {
+			return 0, err
+		}
+		var n uint64
+		for _, x := range b {
+			n = n<<8 | uint64(x)
+		}
+		return int(n), nil
+	}
+	var n int
+	switch {
+	case c < 0x80:
+		return strconv.Itoa(int(c)), nil
+	case c >= 0xe0:
+		return strconv.Itoa(int(int8(c))), nil
+	case c == 0xc0:
+		return "null", nil
+	case c == 0xc2:
+		return "false", nil
+	case c == 0xc3:
+		return "true", nil
+	case c == 0xd2:
+		b, err := read(4)
+		return strconv.Itoa(int(int32(binary.BigEndian.Uint32(b)))), err
+	case c == 0xd3:
+		b, err := read(8)
+		return strconv.FormatInt(int64(binary.BigEndian.Uint64(b)), 10), err
+	case c == 0xcb:
+		b, err := read(8)
+		f, _ := json.Marshal(math.Float64frombits(binary.BigEndian.Uint64(b)))
+		return string(f), err
+	case c == 0xd7:
+		b, err := read(9)
+		if err != nil || b[0] != 0 {
+			return "", fmt.Errorf("ext 0x%02x", b[0])
+		}
+		return fmt.Sprintf(`"@%d.%09d"`, binary.BigEndian.Uint32(b[1:]), binary.BigEndian.Uint32(b[5:])), nil
+	case c&0xe0 == 0xa0, c == 0xd9, c == 0xda, c == 0xdb:
+		switch c {
+		case 0xd9:
+			n, err = uint(1)
+		case 0xda:
+			n, err = uint(2)
+		case 0xdb:
+			n, err = uint(4)
+		default:
+			n = int(c & 0x1f)
+		}
+		if err != nil {
+			return "", err
+		}
+		var b []byte
+		if b, err = read(n); err != nil {
+			return "", err
+		}
+		var s strings.Builder
+		enc := json.NewEncoder(&s)
+		enc.SetEscapeHTML(false)
+		enc.Encode(string(b))
+		return strings.TrimSuffix(s.String(), "\n"), nil
+	case c&0xf0 == 0x90, c == 0xdc, c == 0xdd, c&0xf0 == 0x80, c == 0xde, c == 0xdf:
+		switch c {
+		case 0xdc, 0xde:
+			n, err = uint(2)
+		case 0xdd, 0xdf:
+			n, err = uint(4)
+		default:
+			n = int(c & 0x0f)
+		}
+		if err != nil {
+			return "", err
+		}
+		isMap := c&0xf0 == 0x80 || c == 0xde || c == 0xdf
+		var s strings.Builder
+		s.WriteByte("[{"[btoi(isMap)])
+		for i := range n {
+			if i > 0 {
+				s.WriteByte(',')
+			}
+			if isMap {
+				k, err := msgpackToJSON(r)
+				if err != nil {
+					return "", err
+				}
+				s.WriteString(k + ":")
+			}
+			v, err := msgpackToJSON(r)
+			if err != nil {
+				return "", err
+			}
+			s.WriteString(v)
+		}
+		s.WriteByte("]}"[btoi(isMap)])
+		return s.String(), nil
+	}
+	return "", fmt.Errorf("unexpected 0x%02x", c)
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestReadMsgpackStringMap(t *testing.T) {
+	for _, tt := range []struct {
+		in   string // hex
+		want string // map or error
+	}{
+		{"81" + "a361636b" + "a3616263", "map[ack:abc]"},
+		{"80", "map[]"},
+		{"de0001" + "d903616b6b" + "c403616263", "map[akk:abc]"},
+		{"82" + "da0003616263" + "c5000178" + "a0" + "db00000001" + "79", "map[:y abc:x]"},
+		{"", "EOF"},
+		{"90", "expected a map, got 0x90"},
+		{"81" + "01", "expected a string, got 0x01"},
+		{"81" + "a361636b" + "c0", "expected a string, got 0xc0"},
+		{"81" + "a361636b" + "a5616263", "unexpected EOF"},
+		{"82" + "a161" + "a162", "EOF"},
+		{"de00", "unexpected EOF"},
+		{"81" + "a161" + "dbffffffff", "string of 4294967295 bytes"},
+	} {
+		b, _ := hex.DecodeString(tt.in)
+		m, err := readMsgpackStringMap(bufio.NewReader(bytes.NewReader(b)))
+		got := fmt.Sprint(m)
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("readMsgpackStringMap(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+// fluentdMessage is a Forward mode message decoded by newFakeFluentd.
+type fluentdMessage struct {
+	tag     string
+	entries []string // [time,record] as JSON
+	option  map[string]string
+}
+
+// newFakeFluentd reads Forward mode messages and answers those asking for an ack with
+// ack. It returns the address of the server and a function stopping it and returning
+// the messages read.
+func newFakeFluentd(t *testing.T, ack func(n int, chunk string) string) (string, func() []fluentdMessage) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msgs []fluentdMessage
+	var wg sync.WaitGroup
+	serve := func(conn net.Conn) error {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			c, err := r.ReadByte()
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if c != 0x93 {
+				return fmt.Errorf("message starts with 0x%02x, want an array of 3", c)
+			}
+			tag, err := msgpackToJSON(r)
+			if err != nil {
+				return err
+			}
+			entries, err := msgpackToJSON(r)
+			if err != nil {
+				return err
+			}
+			option, err := readForwardOption(r)
+			if err != nil {
+				return err
+			}
+			var msg fluentdMessage
+			json.Unmarshal([]byte(tag), &msg.tag)
+			var raw []json.RawMessage
+			if err := json.Unmarshal([]byte(entries), &raw); err != nil {
+				return err
+			}
+			for _, e := range raw {
+				msg.entries = append(msg.entries, string(e))
+			}
+			msg.option = option
+			msgs = append(msgs, msg)
+			if chunk := option["chunk"]; chunk != "" {
+				reply := appendMsgpackMap(nil, 1)
+				reply = appendMsgpackString(reply, "ack")
+				reply = appendMsgpackString(reply, ack(len(msgs), chunk))
+				if _, err := conn.Write(reply); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			if err := serve(conn); err != nil {
+				t.Errorf("fake fluentd: %v", err)
+			}
+		}
+	}()
+	stop := sync.OnceValue(func() []fluentdMessage {
+		ln.Close()
+		wg.Wait()
+		return msgs
+	})
+	t.Cleanup(func() { stop() })
+	return ln.Addr().String(), stop
+}
+
+// readForwardOption reads the option map of a Forward message, whose size is an
+// integer.
+func readForwardOption(r *bufio.Reader) (map[string]string, error) {
+	s, err := msgpackToJSON(r)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		return nil, errors.New("option is not a map")
+	}
+	opts := make(map[string]string, len(m))
+	for k, v := range m {
+		opts[k] = fmt.Sprint(v)
+	}
+	return opts, nil
+}
+
+func TestFluentdSinkForward(t *testing.T) {
+	addr, stop := newFakeFluentd(t, func(n int, chunk string) string {
+		if n == 1 {
+			return "not-the-chunk" // the batch must be sent again
+		}
+		return chunk
+	})
+	var errs []string
+	h, sink := NewFluentdHandler(FluentdOptions{
+		Address:    addr,
+		Tag:        "app.access",
+		RequireAck: true,
+		OnError:    func(err error) { errs = append(errs, err.Error()) },
+	}, WithReplaceAttr(func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey && len(groups) == 0 {
+			return slog.Time(slog.TimeKey, time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC))
+		}
+		if a.Key == slog.SourceKey {
+			return slog.Attr{}
+		}
+		return a
+	}))
+	slog.New(h).Info("served", "status", 200, "path", "/")
+	// Flush waits for the retries, Close would give them up.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sink.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	msgs := stop()
+
+	if len(msgs) != 2 {
+		t.Fatalf("%d messages, want the batch and its retry", len(msgs))
+	}
+	const want = `["@1704164645.000000006",{"time":"2024-01-02T03:04:05.000000006Z","level":"INFO","msg":"served","status":200,"path":"/"}]`
+	for i, msg := range msgs {
+		if msg.tag != "app.access" || msg.option["size"] != "1" || msg.option["chunk"] == "" || len(msg.entries) != 1 || msg.entries[0] != want {
+			t.Errorf("message %d = %+v\nwant entry %s", i, msg, want)
+		}
+	}
+	if len(errs) != 0 {
+		t.Errorf("errors = %q", errs)
+	}
+}
@@ -0,0 +1,102 @@
+package slogger
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Special fields understood by the Google Cloud Logging agent in structured JSON payloads.
+const (
+	gcpSourceLocationKey = "logging.googleapis.com/sourceLocation"
+	gcpTraceKey          = "logging.googleapis.com/trace"
+	gcpSpanIDKey         = "logging.googleapis.com/spanId"
+)
+
+// NewGCPHandler creates a handler producing Google Cloud Logging structured JSON, parsed
+// natively when written to stdout on GKE, Cloud Run or Cloud Functions. projectID is used to
+// build the trace resource name from the context trace ID and may be empty. It accepts the
+// same options as NewPrettyHandler.
+func NewGCPHandler(out io.Writer, projectID string, opts ...Option) *PrettyHandler {
+	return NewPrettyHandler(out, append(opts, WithFormat(FormatGCP), WithGCPProject(projectID))...)
+}
+
+// WithGCPProject sets the Google Cloud project used to build trace resource names in FormatGCP.
+func WithGCPProject(projectID string) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.GCPProjectID = projectID
+	})
+}
+
+// gcpSeverity maps a slog level to a Cloud Logging LogSeverity.
+func gcpSeverity(l slog.Level) string {
+	switch {
+	case l >= LevelFatal:
+		return "CRITICAL"
+	case l >= slog.LevelError:
+		return "ERROR"
+	case l >= slog.LevelWarn:
+		return "WARNING"
+	case l > slog.LevelInfo:
+		return "NOTICE"
+	case l >= slog.LevelInfo:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
+
+// encodeGCP renders e as a Cloud Logging structured JSON payload.
+func (h *PrettyHandler) encodeGCP(e entry) ([]byte, error) {
+	fs := make(fields, 0, len(e.attrs)+5)
+	if l, ok := e.level.Value.Any().(slog.Level); ok && e.level.Key != "" {
+		fs = append(fs, field{key: "severity", value: gcpSeverity(l)})
+	}
+	if e.message.Key != "" {
+		fs = append(fs, field{key: "message", value: e.message.Value.String()})
+	}
+	if e.time.Key != "" && e.time.Value.Kind() == slog.KindTime {
+		fs = append(fs, field{key: "time", value: e.time.Value.Time().UTC().Format(time.RFC3339Nano)})
+	}
+	if src, ok := e.source.Value.Any().(*slog.Source); ok && e.source.Key != "" {
+		fs = append(fs, field{key: gcpSourceLocationKey, value: fields{
+			{key: "file", value: src.File},
+			{key: "line", value: strconv.Itoa(src.Line)},
+			{key: "function", value: src.Function},
+		}})
+	}
+	span := e.attrs.has(SpanTraceIDKey)
+	for _, f := range e.attrs {
+		switch {
+		case f.key == SpanTraceIDKey, f.key == TraceIDKey && !span:
+			fs = append(fs, field{key: gcpTraceKey, value: h.gcpTrace(f.value)})
+		case f.key == SpanIDKey:
+			fs = append(fs, field{key: gcpSpanIDKey, value: f.value})
+		default:
+			fs = append(fs, f)
+		}
+	}
+	return json.Marshal(fs)
+}
+
+// gcpTrace formats a trace ID as expected by Cloud Trace: 32 hex characters, prefixed with
+// projects/<id>/traces/ when a project is configured.
+func (h *PrettyHandler) gcpTrace(v interface{}) string {
+	var id string
+	switch v := v.(type) {
+	case uuid.UUID:
+		id = hex.EncodeToString(v[:])
+	default:
+		id = fmt.Sprint(v)
+	}
+	if h.opts.GCPProjectID == "" {
+		return id
+	}
+	return "projects/" + h.opts.GCPProjectID + "/traces/" + id
+}
@@ -0,0 +1,485 @@
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CloudLoggingOptions configures a CloudLoggingSink.
+type CloudLoggingOptions struct {
+	// ProjectID owns the log. Defaults to GOOGLE_CLOUD_PROJECT, the project of the
+	// service account key or the project reported by the metadata server.
+	ProjectID string
+
+	// LogName is the log ID entries are written to. Defaults to the executable name.
+	LogName string
+
+	// Resource is the monitored resource of the entries. Defaults to the resource
+	// detected from the environment: cloud_run_revision, cloud_function, k8s_container,
+	// gce_instance, or global elsewhere.
+	Resource *GCPResource
+
+	// Labels are attached to every entry.
+	Labels map[string]string
+
+	// Token returns an OAuth 2.0 access token with the logging.write scope. Defaults to
+	// the service account key named by GOOGLE_APPLICATION_CREDENTIALS, or the metadata
+	// server on Google Cloud.
+	Token func(context.Context) (string, error)
+
+	// Endpoint overrides the API URL. Defaults to https://logging.googleapis.com.
+	Endpoint string
+
+	// BatchSize, FlushInterval, MaxInFlight, MaxRetries, MaxBuffered, DeadLetter and Breaker
+	// tune batching as documented on WebhookOptions. Rate limits and server errors are
+	// retried with backoff.
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxInFlight   int
+	MaxRetries    int
+	MaxBuffered   int
+	DeadLetter    *DeadLetter
+	Breaker       *CircuitBreaker
+
+	// Client sends the requests. Defaults to a client with a ten second timeout.
+	Client *http.Client
+
+	// OnError, if not nil, receives failed deliveries.
+	OnError func(error)
+}
+
+// GCPResource is a Cloud Logging monitored resource.
+type GCPResource struct {
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// CloudLoggingSink is an io.Writer sending the records written by a FormatGCP handler
+// to the Cloud Logging API with entries:write, for workloads whose stdout is not
+// collected by a logging agent. Severities, source locations and the trace of the
+// context trace-id carry over to the entries. Call Close to send the remaining records.
+type CloudLoggingSink struct {
+	*batcher
+	opts    CloudLoggingOptions
+	logName string
+}
+
+// NewCloudLoggingHandler creates a FormatGCP handler sending records to Cloud Logging and
+// returns the sink so it can be closed on shutdown. Handler options are applied as for
+// NewPrettyHandler.
+func NewCloudLoggingHandler(cl CloudLoggingOptions, opts ...Option) (*PrettyHandler, *CloudLoggingSink, error) {
+	s, err := NewCloudLoggingSink(cl)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewPrettyHandler(s, append(opts, WithFormat(FormatGCP), WithGCPProject(s.opts.ProjectID))...), s, nil
+}
+
+// NewCloudLoggingSink starts a sink with the given options, detecting the project and
+// resource first. On Google Cloud this asks the metadata server.
+func NewCloudLoggingSink(opts CloudLoggingOptions) (*CloudLoggingSink, error) {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://logging.googleapis.com"
+	}
+	if opts.LogName == "" {
+		opts.LogName = filepath.Base(os.Args[0])
+	}
+	md := &gcpMetadata{client: opts.Client}
+
+	var key *gcpServiceAccount
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" && opts.Token == nil {
+		var err error
+		if key, err = loadGCPServiceAccount(path); err != nil {
+			return nil, err
+		}
+	}
+	if opts.ProjectID == "" {
+		opts.ProjectID = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	if opts.ProjectID == "" && key != nil {
+		opts.ProjectID = key.ProjectID
+	}
+	if opts.ProjectID == "" {
+		opts.ProjectID = md.get("project/project-id")
+	}
+	if opts.ProjectID == "" {
+		return nil, errors.New("slogger: cloud logging: no project")
+	}
+	if opts.Resource == nil {
+		opts.Resource = detectGCPResource(md, opts.ProjectID)
+	}
+	if opts.Token == nil {
+		ts := &gcpTokenSource{client: opts.Client, key: key, md: md}
+		opts.Token = ts.token
+	}
+
+	s := &CloudLoggingSink{
+		opts:    opts,
+		logName: "projects/" + opts.ProjectID + "/logs/" + url.PathEscape(opts.LogName),
+	}
+	s.batcher = newBatcher(batchOptions{
+		size:        opts.BatchSize,
+		interval:    opts.FlushInterval,
+		maxInFlight: opts.MaxInFlight,
+		maxRetries:  opts.MaxRetries,
+		maxBuffered: opts.MaxBuffered,
+		deadLetter:  opts.DeadLetter,
+		breaker:     opts.Breaker,
+		onError:     opts.OnError,
+		send:        s.send,
+	})
+	return s, nil
+}
+
+// send writes one batch of entries.
+func (s *CloudLoggingSink) send(ctx context.Context, records [][]byte) error {
+	entries := make([]json.RawMessage, 0, len(records))
+	for _, rec := range records {
+		entries = append(entries, gcpLogEntry(rec))
+	}
+	req := map[string]interface{}{
+		"logName":        s.logName,
+		"resource":       s.opts.Resource,
+		"entries":        entries,
+		"partialSuccess": true,
+	}
+	if len(s.opts.Labels) > 0 {
+		req["labels"] = s.opts.Labels
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	token, err := s.opts.Token(ctx)
+	if err != nil {
+		return &retryError{err: fmt.Errorf("slogger: cloud logging: %w", err)}
+	}
+	headers := map[string]string{"Authorization": "Bearer " + token}
+	if _, err := httpPost(ctx, s.opts.Client, strings.TrimSuffix(s.opts.Endpoint, "/")+"/v2/entries:write", "application/json", headers, body, false); err != nil {
+		return fmt.Errorf("slogger: cloud logging write: %w", err)
+	}
+	return nil
+}
+
+// gcpLogEntry turns a FormatGCP record into a LogEntry, moving the special fields out of
+// the JSON payload.
+func gcpLogEntry(rec []byte) json.RawMessage {
+	dec := json.NewDecoder(bytes.NewReader(rec))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		b, _ := json.Marshal(map[string]string{"textPayload": string(rec)})
+		return b
+	}
+
+	var entry, payload bytes.Buffer
+	add := func(buf *bytes.Buffer, key string, raw []byte) {
+		if buf.Len() == 0 {
+			buf.WriteByte('{')
+		} else {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(raw)
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			break
+		}
+		key, _ := t.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			break
+		}
+		switch key {
+		case "severity":
+			add(&entry, "severity", raw)
+		case "time":
+			add(&entry, "timestamp", raw)
+		case gcpSourceLocationKey:
+			add(&entry, "sourceLocation", raw)
+		case gcpTraceKey:
+			add(&entry, "trace", raw)
+		case "logging.googleapis.com/spanId":
+			add(&entry, "spanId", raw)
+		case "logging.googleapis.com/labels":
+			add(&entry, "labels", raw)
+		default:
+			add(&payload, key, raw)
+		}
+	}
+	if payload.Len() > 0 {
+		payload.WriteByte('}')
+		add(&entry, "jsonPayload", payload.Bytes())
+	}
+	if entry.Len() == 0 {
+		return json.RawMessage("{}")
+	}
+	entry.WriteByte('}')
+	return entry.Bytes()
+}
+
+// detectGCPResource describes the environment the process runs in.
+func detectGCPResource(md *gcpMetadata, project string) *GCPResource {
+	region := func() string {
+		// projects/123/regions/us-central1
+		r := md.get("instance/region")
+		return r[strings.LastIndexByte(r, '/')+1:]
+	}
+	zone := func() string {
+		z := md.get("instance/zone")
+		return z[strings.LastIndexByte(z, '/')+1:]
+	}
+
+	switch {
+	case os.Getenv("FUNCTION_TARGET") != "" && os.Getenv("K_SERVICE") != "":
+		return &GCPResource{Type: "cloud_function", Labels: map[string]string{
+			"project_id":    project,
+			"function_name": os.Getenv("K_SERVICE"),
+			"region":        region(),
+		}}
+	case os.Getenv("K_SERVICE") != "":
+		return &GCPResource{Type: "cloud_run_revision", Labels: map[string]string{
+			"project_id":         project,
+			"service_name":       os.Getenv("K_SERVICE"),
+			"revision_name":      os.Getenv("K_REVISION"),
+			"configuration_name": os.Getenv("K_CONFIGURATION"),
+			"location":           region(),
+		}}
+	case os.Getenv("KUBERNETES_SERVICE_HOST") != "":
+		namespace := os.Getenv("POD_NAMESPACE")
+		if namespace == "" {
+			b, _ := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+			namespace = strings.TrimSpace(string(b))
+		}
+		pod := os.Getenv("POD_NAME")
+		if pod == "" {
+			pod = hostname()
+		}
+		return &GCPResource{Type: "k8s_container", Labels: map[string]string{
+			"project_id":     project,
+			"location":       md.get("instance/attributes/cluster-location"),
+			"cluster_name":   md.get("instance/attributes/cluster-name"),
+			"namespace_name": namespace,
+			"pod_name":       pod,
+			"container_name": os.Getenv("CONTAINER_NAME"),
+		}}
+	}
+	if id := md.get("instance/id"); id != "" {
+		return &GCPResource{Type: "gce_instance", Labels: map[string]string{
+			"project_id":  project,
+			"instance_id": id,
+			"zone":        zone(),
+		}}
+	}
+	return &GCPResource{Type: "global", Labels: map[string]string{"project_id": project}}
+}
+
+// gcpMetadata queries the metadata server, remembering when it is unreachable.
+type gcpMetadata struct {
+	client *http.Client
+
+	mu          sync.Mutex
+	unreachable bool
+}
+
+// gcpMetadataHost is the metadata server, overridable as in the Google client libraries.
+func gcpMetadataHost() string {
+	if host := os.Getenv("GCE_METADATA_HOST"); host != "" {
+		return host
+	}
+	return "metadata.google.internal"
+}
+
+// get returns a metadata value, or "" when it is missing or not on Google Cloud.
+func (m *gcpMetadata) get(path string) string {
+	b, err := m.fetch(context.Background(), path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func (m *gcpMetadata) fetch(ctx context.Context, path string) ([]byte, error) {
+	m.mu.Lock()
+	unreachable := m.unreachable
+	m.mu.Unlock()
+	if unreachable {
+		return nil, errors.New("metadata server unreachable")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+gcpMetadataHost()+"/computeMetadata/v1/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		m.mu.Lock()
+		m.unreachable = true
+		m.mu.Unlock()
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metadata %s: %s", path, resp.Status)
+	}
+	return b, nil
+}
+
+// gcpServiceAccount is the part of a service account key file used for tokens.
+type gcpServiceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+
+	key *rsa.PrivateKey
+}
+
+// loadGCPServiceAccount reads a service account key file.
+func loadGCPServiceAccount(path string) (*gcpServiceAccount, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("slogger: cloud logging credentials: %w", err)
+	}
+	var sa gcpServiceAccount
+	if err := json.Unmarshal(b, &sa); err != nil {
+		return nil, fmt.Errorf("slogger: cloud logging credentials: %w", err)
+	}
+	block, _ := pem.Decode([]byte(sa.PrivateKey))
+	if block == nil {
+		return nil, errors.New("slogger: cloud logging credentials: no private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("slogger: cloud logging credentials: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("slogger: cloud logging credentials: not an RSA key")
+	}
+	sa.key = key
+	if sa.TokenURI == "" {
+		sa.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	return &sa, nil
+}
+
+// gcpLoggingScope is the OAuth scope needed to write entries.
+const gcpLoggingScope = "https://www.googleapis.com/auth/logging.write"
+
+// gcpTokenSource obtains access tokens for a service account key or from the metadata
+// server, caching them until shortly before they expire.
+type gcpTokenSource struct {
+	client *http.Client
+	key    *gcpServiceAccount
+	md     *gcpMetadata
+
+	mu      sync.Mutex
+	current string
+	expires time.Time
+}
+
+func (ts *gcpTokenSource) token(ctx context.Context) (string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.current != "" && time.Until(ts.expires) > time.Minute {
+		return ts.current, nil
+	}
+
+	var body []byte
+	var err error
+	if ts.key != nil {
+		body, err = ts.exchange(ctx)
+	} else {
+		body, err = ts.md.fetch(ctx, "instance/service-accounts/default/token?scopes="+url.QueryEscape(gcpLoggingScope))
+	}
+	if err != nil {
+		return "", fmt.Errorf("access token: %w", err)
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return "", fmt.Errorf("access token: %w", err)
+	}
+	ts.current = tok.AccessToken
+	ts.expires = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	return ts.current, nil
+}
+
+// exchange trades a signed JWT for an access token.
+func (ts *gcpTokenSource) exchange(ctx context.Context) ([]byte, error) {
+	now := time.Now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   ts.key.ClientEmail,
+		"scope": gcpLoggingScope,
+		"aud":   ts.key.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, ts.key.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + base64.RawURLEncoding.EncodeToString(sig)},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ts.key.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := ts.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Status, body[:min(len(body), 512)])
+	}
+	return body, nil
+}
@@ -0,0 +1,158 @@
+package slogger
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// NewGELFHandler creates a handler producing GELF 1.1 messages for Graylog. Attributes
+// become "_"-prefixed additional fields. Use NewGELFChunkWriter or NewGELFTCPWriter around
+// the network connection to apply the GELF transport rules. It accepts the same options as
+// NewPrettyHandler.
+func NewGELFHandler(out io.Writer, opts ...Option) *PrettyHandler {
+	return NewPrettyHandler(out, append(opts, WithFormat(FormatGELF))...)
+}
+
+// encodeGELF renders e as a GELF 1.1 JSON message.
+func (h *PrettyHandler) encodeGELF(e entry) ([]byte, error) {
+	msg := e.message.Value.String()
+	short, full := msg, ""
+	if i := strings.IndexByte(msg, '\n'); i >= 0 {
+		short, full = msg[:i], msg
+	}
+
+	fs := fields{
+		{key: "version", value: "1.1"},
+		{key: "host", value: hostname()},
+		{key: "short_message", value: short},
+	}
+	if full != "" {
+		fs = append(fs, field{key: "full_message", value: full})
+	}
+	if e.time.Key != "" && e.time.Value.Kind() == slog.KindTime {
+		fs = append(fs, field{key: "timestamp", value: float64(e.time.Value.Time().UnixMilli()) / 1000})
+	}
+	if l, ok := e.level.Value.Any().(slog.Level); ok && e.level.Key != "" {
+		fs = append(fs, field{key: "level", value: syslogSeverity(l)})
+	}
+	if src, ok := e.source.Value.Any().(*slog.Source); ok && e.source.Key != "" {
+		fs = append(fs,
+			field{key: "_file", value: src.File},
+			field{key: "_line", value: src.Line},
+			field{key: "_function", value: src.Function},
+		)
+	}
+	for _, f := range e.attrs.flatten("") {
+		fs = append(fs, field{key: gelfFieldName(f.key), value: f.value})
+	}
+	return json.Marshal(fs)
+}
+
+// gelfFieldName turns an attribute key into a valid GELF additional field name:
+// characters outside [A-Za-z0-9_.-] become '_' and the reserved "_id" is avoided.
+func gelfFieldName(key string) string {
+	name := []byte("_" + key)
+	for i, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-') {
+			name[i] = '_'
+		}
+	}
+	if string(name) == "_id" {
+		return "_id_"
+	}
+	return string(name)
+}
+
+// GELFDefaultChunk is the default datagram size of NewGELFChunkWriter, fitting a typical Ethernet MTU.
+const GELFDefaultChunk = 1420
+
+// GELF UDP chunking limits.
+const (
+	gelfChunkMagic0   = 0x1e
+	gelfChunkMagic1   = 0x0f
+	gelfChunkHeader   = 12
+	gelfMaxChunks     = 128
+	gelfMaxChunkBytes = 8192
+)
+
+// gelfChunkWriter splits GELF messages into UDP chunks.
+type gelfChunkWriter struct {
+	w         io.Writer
+	chunkSize int
+}
+
+// NewGELFChunkWriter wraps a datagram connection, such as a *net.UDPConn, so each GELF
+// message is sent as one datagram or, when larger than chunkSize, as a sequence of GELF
+// chunks. A chunkSize of zero uses GELFDefaultChunk; a positive one is raised to leave room
+// for at least one byte after the 12-byte chunk header. Messages needing more than 128
+// chunks are rejected as the GELF specification requires.
+func NewGELFChunkWriter(w io.Writer, chunkSize int) io.Writer {
+	if chunkSize <= 0 {
+		chunkSize = GELFDefaultChunk
+	}
+	if chunkSize <= gelfChunkHeader {
+		chunkSize = gelfChunkHeader + 1
+	}
+	if chunkSize > gelfMaxChunkBytes {
+		chunkSize = gelfMaxChunkBytes
+	}
+	return &gelfChunkWriter{w: w, chunkSize: chunkSize}
+}
+
+func (c *gelfChunkWriter) Write(p []byte) (int, error) {
+	msg := bytes.TrimSuffix(p, []byte("\n"))
+	if len(msg) <= c.chunkSize {
+		if _, err := c.w.Write(msg); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	payload := c.chunkSize - gelfChunkHeader
+	count := (len(msg) + payload - 1) / payload
+	if count > gelfMaxChunks {
+		return 0, fmt.Errorf("slogger: GELF message of %d bytes needs %d chunks, more than %d", len(msg), count, gelfMaxChunks)
+	}
+
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return 0, err
+	}
+
+	chunk := make([]byte, 0, c.chunkSize)
+	for i := 0; i < count; i++ {
+		end := min((i+1)*payload, len(msg))
+		chunk = append(chunk[:0], gelfChunkMagic0, gelfChunkMagic1)
+		chunk = append(chunk, id[:]...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, msg[i*payload:end]...)
+		if _, err := c.w.Write(chunk); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// gelfTCPWriter terminates GELF messages with a null byte.
+type gelfTCPWriter struct {
+	w io.Writer
+}
+
+// NewGELFTCPWriter wraps a stream connection so each GELF message is terminated by a null
+// byte instead of a newline, as GELF over TCP requires.
+func NewGELFTCPWriter(w io.Writer) io.Writer {
+	return &gelfTCPWriter{w: w}
+}
+
+func (t *gelfTCPWriter) Write(p []byte) (int, error) {
+	msg := append(bytes.TrimSuffix(p, []byte("\n")), 0)
+	if _, err := t.w.Write(msg); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,99 @@
+package slogger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// datagrams records every Write as one datagram.
+type datagrams [][]byte
+
+func (d *datagrams) Write(p []byte) (int, error) {
+	*d = append(*d, bytes.Clone(p))
+	return len(p), nil
+}
+
+func TestGELFFormat(t *testing.T) {
+	var buf bytes.Buffer
+	at := time.Date(2024, 1, 2, 3, 4, 5, 678_900_000, time.UTC)
+	logger := slog.New(NewGELFHandler(&buf, WithSource(false), WithReplaceAttr(func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey && len(groups) == 0 {
+			return slog.Time(slog.TimeKey, at)
+		}
+		return a
+	})))
+	logger.Warn("disk almost full\nat 95%", "id", 7, "mount point", "/var", slog.Group("disk", "free", 1.5))
+
+	want := `{"version":"1.1","host":"` + hostname() + `","short_message":"disk almost full","full_message":"disk almost full\nat 95%",` +
+		`"timestamp":1704164645.678,"level":4,"_id_":7,"_mount_point":"/var","_disk.free":1.5}`
+	if got := strings.TrimSuffix(buf.String(), "\n"); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestGELFChunkWriter(t *testing.T) {
+	var d datagrams
+	w := NewGELFChunkWriter(&d, 22) // 10 bytes of payload per chunk
+
+	if _, err := w.Write([]byte("0123456789012345678901\n")); err != nil {
+		t.Fatal(err)
+	}
+	if len(d) != 1 || string(d[0]) != "0123456789012345678901" {
+		t.Fatalf("message of chunkSize bytes sent as %q, want one datagram without the newline", d)
+	}
+
+	d = nil
+	msg := "abcdefghij" + "klmnopqrst" + "uvw"
+	if n, err := w.Write([]byte(msg + "\n")); err != nil || n != len(msg)+1 {
+		t.Fatalf("Write = %d, %v", n, err)
+	}
+	if len(d) != 3 {
+		t.Fatalf("%d chunks, want 3", len(d))
+	}
+	var payload []byte
+	for i, c := range d {
+		if len(c) < 12 || c[0] != 0x1e || c[1] != 0x0f {
+			t.Fatalf("chunk %d = %x, want the magic bytes 1e0f", i, c)
+		}
+		if !bytes.Equal(c[2:10], d[0][2:10]) {
+			t.Errorf("chunk %d has message id %x, chunk 0 %x", i, c[2:10], d[0][2:10])
+		}
+		if c[10] != byte(i) || c[11] != 3 {
+			t.Errorf("chunk %d numbered %d of %d", i, c[10], c[11])
+		}
+		if len(c) > 22 {
+			t.Errorf("chunk %d has %d bytes, more than the chunk size", i, len(c))
+		}
+		payload = append(payload, c[12:]...)
+	}
+	if string(payload) != msg {
+		t.Errorf("reassembled %q, want %q", payload, msg)
+	}
+
+	d = nil
+	if _, err := w.Write(bytes.Repeat([]byte{'x'}, 128*10+1)); err == nil || len(d) != 0 {
+		t.Errorf("message needing 129 chunks: err = %v, %d chunks sent", err, len(d))
+	}
+
+	d = nil
+	tiny := NewGELFChunkWriter(&d, 5) // raised to one byte of payload
+	if _, err := tiny.Write([]byte("abcdefghijklmn")); err != nil {
+		t.Fatal(err)
+	}
+	if len(d) != 14 || string(d[13][12:]) != "n" {
+		t.Errorf("chunks of a chunk size below the header = %q", d)
+	}
+}
+
+func TestGELFTCPWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewGELFTCPWriter(&buf)
+	w.Write([]byte(`{"short_message":"a"}` + "\n"))
+	w.Write([]byte(`{"short_message":"b"}`))
+	if got, want := buf.String(), `{"short_message":"a"}`+"\x00"+`{"short_message":"b"}`+"\x00"; got != want {
+		t.Errorf("stream = %q, want %q", got, want)
+	}
+}
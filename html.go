@@ -0,0 +1,206 @@
+package slogger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTMLHandler collects records in memory and renders them as a standalone HTML page with
+// a filterable table when closed, useful for attaching test-run or migration logs to tickets.
+type HTMLHandler struct {
+	inner  slog.Handler
+	report *htmlReport
+}
+
+// htmlReport is the state shared by an HTMLHandler and the handlers derived from it.
+type htmlReport struct {
+	mu     sync.Mutex
+	out    io.Writer
+	title  string
+	keys   htmlKeys
+	rows   []htmlRow
+	closed bool
+}
+
+// htmlKeys are the keys of the built-in fields after ReplaceAttr; an empty key is one
+// ReplaceAttr drops.
+type htmlKeys struct {
+	time, level, message, source string
+}
+
+// htmlRow is one record in the report.
+type htmlRow struct {
+	Time    string
+	Level   string
+	Class   string
+	Message string
+	Source  string
+	Attrs   string
+}
+
+// NewHTMLHandler creates a handler that writes an HTML report titled title to out on Close.
+// It accepts the same options as NewPrettyHandler; the output format is fixed.
+func NewHTMLHandler(out io.Writer, title string, opts ...Option) *HTMLHandler {
+	report := &htmlReport{out: out, title: title}
+	inner := NewPrettyHandler(report, append(opts, WithFormat(FormatJSON), WithGroupStyle(GroupNested))...)
+	report.keys = inner.htmlKeys()
+	return &HTMLHandler{inner: inner, report: report}
+}
+
+// htmlKeys finds the keys h writes the built-in fields under by passing sample values
+// through ReplaceAttr.
+func (h *PrettyHandler) htmlKeys() htmlKeys {
+	key := func(a slog.Attr) string {
+		a, ok := h.replace(nil, a)
+		if !ok {
+			return ""
+		}
+		return a.Key
+	}
+	return htmlKeys{
+		time:    key(slog.Time(slog.TimeKey, time.Now())),
+		level:   key(slog.Any(slog.LevelKey, slog.LevelInfo)),
+		message: key(slog.String(slog.MessageKey, "")),
+		source:  key(slog.Any(slog.SourceKey, &slog.Source{})),
+	}
+}
+
+// Write decodes a JSON record produced by the inner handler into a row.
+func (r *htmlReport) Write(p []byte) (int, error) {
+	var rec fields
+	if err := json.Unmarshal(p, &rec); err != nil {
+		return 0, err
+	}
+
+	take := func(key string) (interface{}, bool) {
+		if key == "" {
+			return nil, false
+		}
+		return rec.take(key)
+	}
+	text := func(key string) string {
+		if v, ok := take(key); ok {
+			return fmt.Sprint(v)
+		}
+		return ""
+	}
+
+	row := htmlRow{
+		Time:    text(r.keys.time),
+		Level:   text(r.keys.level),
+		Message: text(r.keys.message),
+	}
+	row.Class = "level-" + strings.ToLower(strings.SplitN(row.Level, "+", 2)[0])
+	if src, ok := take(r.keys.source); ok {
+		if fs, ok := src.(fields); ok {
+			file, _ := fs.take("file")
+			line, _ := fs.take("line")
+			row.Source = fmt.Sprintf("%v:%v", file, line)
+		} else {
+			row.Source = fmt.Sprint(src)
+		}
+	}
+	if len(rec) > 0 {
+		b, err := json.MarshalIndent(rec, "", "  ")
+		if err != nil {
+			return 0, err
+		}
+		row.Attrs = string(b)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, fmt.Errorf("slogger: HTML report already closed")
+	}
+	r.rows = append(r.rows, row)
+	return len(p), nil
+}
+
+func (h *HTMLHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *HTMLHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *HTMLHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &HTMLHandler{inner: h.inner.WithAttrs(attrs), report: h.report}
+}
+
+func (h *HTMLHandler) WithGroup(name string) slog.Handler {
+	return &HTMLHandler{inner: h.inner.WithGroup(name), report: h.report}
+}
+
+// Close renders the report. Records handled afterwards are rejected. Closing more than
+// once does nothing; the output writer is not closed.
+func (h *HTMLHandler) Close() error {
+	r := h.report
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return htmlTemplate.Execute(r.out, struct {
+		Title string
+		Rows  []htmlRow
+	}{r.title, r.rows})
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ddd; padding: 4px 8px; text-align: left; vertical-align: top; }
+td pre { margin: 0; font-size: 0.9em; }
+.level-debug { color: #8e44ad; }
+.level-info { color: #27ae60; }
+.level-warn { color: #d68910; }
+.level-error, .level-fatal { color: #c0392b; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>
+<input id="filter" type="search" placeholder="Filter" oninput="apply()">
+<select id="level" onchange="apply()">
+<option value="">All levels</option>
+<option value="level-debug">DEBUG</option>
+<option value="level-info">INFO</option>
+<option value="level-warn">WARN</option>
+<option value="level-error">ERROR</option>
+<option value="level-fatal">FATAL</option>
+</select>
+</p>
+<table>
+<thead><tr><th>Time</th><th>Level</th><th>Message</th><th>Source</th><th>Attributes</th></tr></thead>
+<tbody>
+{{range .Rows}}<tr class="{{.Class}}"><td>{{.Time}}</td><td>{{.Level}}</td><td>{{.Message}}</td><td>{{.Source}}</td><td>{{if .Attrs}}<pre>{{.Attrs}}</pre>{{end}}</td></tr>
+{{end}}</tbody>
+</table>
+<script>
+function apply() {
+  var text = document.getElementById("filter").value.toLowerCase();
+  var level = document.getElementById("level").value;
+  document.querySelectorAll("tbody tr").forEach(function (tr) {
+    var show = (!level || tr.className === level) && tr.textContent.toLowerCase().indexOf(text) >= 0;
+    tr.style.display = show ? "" : "none";
+  });
+}
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,243 @@
+package slogger
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AccessLogFormat selects the Apache access log layout written by the HTTP middleware.
+type AccessLogFormat int
+
+const (
+	// AccessLogCommon is the Common Log Format: %h %l %u %t "%r" %>s %b.
+	AccessLogCommon AccessLogFormat = iota
+	// AccessLogCombined is the Combined Log Format, which adds the Referer and User-Agent headers.
+	AccessLogCombined
+)
+
+// AccessRecord describes one served request.
+type AccessRecord struct {
+	RemoteAddr string
+	User       string
+	Time       time.Time
+	Method     string
+	URI        string
+	Proto      string
+	Status     int
+	Size       int64
+	Referer    string
+	UserAgent  string
+	Duration   time.Duration
+}
+
+// String renders r in the Common Log Format.
+func (r AccessRecord) String() string {
+	return r.Format(AccessLogCommon)
+}
+
+// Format renders r as an Apache access log line without the trailing newline.
+func (r AccessRecord) Format(format AccessLogFormat) string {
+	var b strings.Builder
+	b.WriteString(dash(r.RemoteAddr))
+	b.WriteString(" - ")
+	b.WriteString(dash(r.User))
+	b.WriteString(" [")
+	b.WriteString(r.Time.Format("02/Jan/2006:15:04:05 -0700"))
+	b.WriteString(`] "`)
+	b.WriteString(accessEscape(r.Method + " " + r.URI + " " + r.Proto))
+	b.WriteString(`" `)
+	b.WriteString(strconv.Itoa(r.Status))
+	b.WriteByte(' ')
+	if r.Size > 0 {
+		b.WriteString(strconv.FormatInt(r.Size, 10))
+	} else {
+		b.WriteByte('-')
+	}
+	if format == AccessLogCombined {
+		b.WriteString(` "`)
+		b.WriteString(accessEscape(dash(r.Referer)))
+		b.WriteString(`" "`)
+		b.WriteString(accessEscape(dash(r.UserAgent)))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+// dash returns "-" for empty access log fields.
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+var accessEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+// accessEscape escapes quotes and control characters inside quoted access log fields.
+func accessEscape(s string) string {
+	return accessEscaper.Replace(s)
+}
+
+// HTTPOptions configures HTTPMiddleware.
+type HTTPOptions struct {
+	// Logger receives one structured record per request. Defaults to Default.
+	// Set NoStructured to only write the access log.
+	Logger *slog.Logger
+
+	// Level is the level of request records. Responses with a 5xx status are logged at Error.
+	Level slog.Level
+
+	// NoStructured disables the structured request records.
+	NoStructured bool
+
+	// AccessLog, if not nil, receives one Apache access log line per request.
+	AccessLog io.Writer
+
+	// AccessLogFormat selects the access log layout. Defaults to AccessLogCommon.
+	AccessLogFormat AccessLogFormat
+
+	// Traceparent gives each request a span context, logged as trace_id and span_id by the
+	// handlers: a child of the span of its W3C traceparent header, or the root of a new
+	// trace. A span context already in the request context is kept. Use
+	// TraceparentTransport to pass it on to the services called.
+	Traceparent bool
+}
+
+// HTTPMiddleware returns middleware that logs every request as a structured record and,
+// optionally, as an Apache access log line so existing access-log tooling keeps working.
+func HTTPMiddleware(opts HTTPOptions) func(http.Handler) http.Handler {
+	var mu sync.Mutex
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			if opts.Traceparent {
+				r = requestSpanContext(r)
+			}
+			rw := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			// A panicking handler is logged as a 500 on its way up. Not recovering keeps
+			// the original stack for the server to report.
+			completed := false
+			defer func() {
+				if !completed && !rw.wroteHeader {
+					rw.status = http.StatusInternalServerError
+				}
+				rec := newAccessRecord(r, rw, start)
+				if !opts.NoStructured {
+					logRequest(r, opts, rec)
+				}
+				if opts.AccessLog != nil {
+					line := rec.Format(opts.AccessLogFormat) + "\n"
+					mu.Lock()
+					io.WriteString(opts.AccessLog, line)
+					mu.Unlock()
+				}
+			}()
+			next.ServeHTTP(rw, r)
+			completed = true
+		})
+	}
+}
+
+// newAccessRecord collects the details of a served request.
+func newAccessRecord(r *http.Request, rw *responseRecorder, start time.Time) AccessRecord {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	user := ""
+	if r.URL.User != nil {
+		user = r.URL.User.Username()
+	} else if u, _, ok := r.BasicAuth(); ok {
+		user = u
+	}
+	return AccessRecord{
+		RemoteAddr: host,
+		User:       user,
+		Time:       start,
+		Method:     r.Method,
+		URI:        r.RequestURI,
+		Proto:      r.Proto,
+		Status:     rw.status,
+		Size:       rw.size,
+		Referer:    r.Referer(),
+		UserAgent:  r.UserAgent(),
+		Duration:   time.Since(start),
+	}
+}
+
+// logRequest writes the structured record of a served request.
+func logRequest(r *http.Request, opts HTTPOptions, rec AccessRecord) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = Default()
+	}
+	level := opts.Level
+	if rec.Status >= 500 && level < slog.LevelError {
+		level = slog.LevelError
+	}
+	ctx := r.Context()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	// The record has no PC: the middleware itself is not a useful source location.
+	record := slog.NewRecord(time.Now(), level, "http request", 0)
+	record.AddAttrs(
+		slog.String("method", rec.Method),
+		slog.String("uri", rec.URI),
+		slog.Int("status", rec.Status),
+		slog.Int64("size", rec.Size),
+		slog.Duration("duration", rec.Duration),
+		slog.String("remote", rec.RemoteAddr),
+		slog.String("user_agent", rec.UserAgent),
+	)
+	logger.Handler().Handle(ctx, record)
+}
+
+// responseRecorder captures the status code and body size written by a handler.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	wroteHeader bool
+}
+
+func (rw *responseRecorder) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.status = code
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseRecorder) Write(p []byte) (int, error) {
+	rw.wroteHeader = true
+	n, err := rw.ResponseWriter.Write(p)
+	rw.size += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher, so streaming handlers keep working behind the middleware.
+func (rw *responseRecorder) Flush() {
+	rw.wroteHeader = true
+	http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker, for handlers taking over the connection such as
+// WebSocket upgrades.
+func (rw *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(rw.ResponseWriter).Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying ResponseWriter.
+func (rw *responseRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
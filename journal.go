@@ -0,0 +1,138 @@
+package slogger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// JournalSocket is the path of the systemd journal native protocol socket.
+const JournalSocket = "/run/systemd/journal/socket"
+
+// NewJournalHandler creates a handler sending records to the systemd journal over its
+// native protocol, so that journalctl can filter them by priority and by field, e.g.
+// "journalctl -p warning HTTP_STATUS=500". Attribute keys become uppercase journal fields.
+//
+// When the process does not run under systemd or the journal cannot be reached, it falls
+// back to writing to stderr in the format selected by opts. It accepts the same options
+// as NewPrettyHandler.
+func NewJournalHandler(opts ...Option) *PrettyHandler {
+	if underSystemd() {
+		if w, err := NewJournalWriter(); err == nil {
+			return NewPrettyHandler(w, append(opts, WithFormat(FormatJournal))...)
+		}
+	}
+	return NewPrettyHandler(os.Stderr, opts...)
+}
+
+// underSystemd reports whether the process was started by systemd, which sets
+// INVOCATION_ID for units and JOURNAL_STREAM when stdout or stderr go to the journal.
+func underSystemd() bool {
+	return os.Getenv("INVOCATION_ID") != "" || os.Getenv("JOURNAL_STREAM") != ""
+}
+
+// JournalWriter is an io.Writer sending each Write as one entry to the journal socket.
+// Entries too large for a datagram are passed in a sealed memfd, as journald requires.
+// It is safe for concurrent use.
+type JournalWriter struct {
+	// conn is left unconnected because file descriptors can only be passed with an
+	// explicit destination.
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+// NewJournalWriter opens a socket for sending to JournalSocket.
+func NewJournalWriter() (*JournalWriter, error) {
+	addr := &net.UnixAddr{Name: JournalSocket, Net: "unixgram"}
+	if _, err := os.Stat(JournalSocket); err != nil {
+		return nil, fmt.Errorf("slogger: connect to journal: %w", err)
+	}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("slogger: connect to journal: %w", err)
+	}
+	return &JournalWriter{conn: conn, addr: addr}, nil
+}
+
+// Write sends p, a complete native protocol entry, to the journal.
+func (w *JournalWriter) Write(p []byte) (int, error) {
+	_, err := w.conn.WriteToUnix(p, w.addr)
+	if err != nil && isMessageTooLarge(err) {
+		err = w.sendFD(p)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("slogger: journal write: %w", err)
+	}
+	return len(p), nil
+}
+
+// Close closes the connection to the journal.
+func (w *JournalWriter) Close() error {
+	return w.conn.Close()
+}
+
+// journalIdentifier is the SYSLOG_IDENTIFIER attached to every journal entry.
+var journalIdentifier = filepath.Base(os.Args[0])
+
+// encodeJournal renders e as a journal native protocol entry. The trailing newline of the
+// last field is added by Handle.
+func (h *PrettyHandler) encodeJournal(e entry) ([]byte, error) {
+	var b bytes.Buffer
+	if e.message.Key != "" {
+		journalField(&b, "MESSAGE", e.message.Value.String())
+	}
+	if l, ok := e.level.Value.Any().(slog.Level); ok && e.level.Key != "" {
+		journalField(&b, "PRIORITY", strconv.Itoa(syslogSeverity(l)))
+	}
+	journalField(&b, "SYSLOG_IDENTIFIER", journalIdentifier)
+	if src, ok := e.source.Value.Any().(*slog.Source); ok && e.source.Key != "" {
+		journalField(&b, "CODE_FILE", src.File)
+		journalField(&b, "CODE_LINE", strconv.Itoa(src.Line))
+		journalField(&b, "CODE_FUNC", src.Function)
+	}
+	for _, f := range e.attrs.flatten("") {
+		if name := journalFieldName(f.key); name != "" {
+			journalField(&b, name, logfmtValue(f.value))
+		}
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte{'\n'}), nil
+}
+
+// journalField appends a single NAME=value field. Values containing newlines use the
+// length-prefixed binary form of the protocol.
+func journalField(b *bytes.Buffer, name, value string) {
+	b.WriteString(name)
+	if strings.IndexByte(value, '\n') < 0 {
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+		return
+	}
+	b.WriteByte('\n')
+	b.Write(binary.LittleEndian.AppendUint64(nil, uint64(len(value))))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// journalFieldName turns an attribute key into a valid journal field name: uppercase
+// letters, digits and '_', not starting with a digit or '_' (which marks trusted fields),
+// at most 64 characters. It returns "" when nothing usable is left.
+func journalFieldName(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			name[i] = '_'
+		}
+	}
+	s := strings.TrimLeft(string(name), "_0123456789")
+	if len(s) > 64 {
+		s = s[:64]
+	}
+	return s
+}
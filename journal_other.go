@@ -0,0 +1,16 @@
+//go:build !linux
+
+package slogger
+
+import "errors"
+
+// isMessageTooLarge reports whether a datagram was rejected for its size. Large entries
+// are only supported on Linux.
+func isMessageTooLarge(error) bool {
+	return false
+}
+
+// sendFD is not supported without memfd.
+func (w *JournalWriter) sendFD([]byte) error {
+	return errors.New("slogger: journal entry too large")
+}
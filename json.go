@@ -0,0 +1,12 @@
+package slogger
+
+import (
+	"io"
+)
+
+// NewJSONHandler creates a handler producing JSON Lines: one compact JSON object per record,
+// terminated by a newline, with the time, level, msg and source fields first, followed by
+// the trace-id and the attributes. It accepts the same options as NewPrettyHandler.
+func NewJSONHandler(out io.Writer, opts ...Option) *PrettyHandler {
+	return NewPrettyHandler(out, append(opts, WithFormat(FormatJSON))...)
+}
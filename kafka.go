@@ -0,0 +1,692 @@
+package slogger
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// KafkaOptions configures a KafkaSink.
+type KafkaOptions struct {
+	// Brokers lists "host:port" addresses used to discover the cluster. Defaults to
+	// localhost:9092.
+	Brokers []string
+
+	// Topic receives the records. It must exist or be created automatically by the brokers.
+	Topic string
+
+	// KeyAttr names the attribute used as the message key, for example TraceIDKey to keep
+	// the records of a trace in order on one partition; nested keys use dots. Records
+	// without it are sent without a key.
+	KeyAttr string
+
+	// Partitioner picks the partition of a keyed record. Defaults to the murmur2 hash
+	// used by the Java client, so keys map to the same partitions as in other producers.
+	// Records without a key are spread over the partitions batch by batch. A record given
+	// a partition outside [0, partitions) is rejected like one refused by the broker.
+	Partitioner func(key []byte, partitions int) int
+
+	// RequireAll waits for all in-sync replicas to acknowledge a write instead of only
+	// the partition leader.
+	RequireAll bool
+
+	// ClientID identifies the producer in broker logs and quotas. Defaults to "slogger".
+	ClientID string
+
+	// DialTimeout bounds connecting to a broker and each request. Defaults to ten seconds.
+	DialTimeout time.Duration
+
+	// BatchSize, FlushInterval, MaxInFlight, MaxRetries, MaxBuffered, DeadLetter and Breaker
+	// tune batching as documented on WebhookOptions. Leader changes and timeouts are retried
+	// with backoff; records a partition refuses for good go to DeadLetter right away.
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxInFlight   int
+	MaxRetries    int
+	MaxBuffered   int
+	DeadLetter    *DeadLetter
+	Breaker       *CircuitBreaker
+
+	// OnDelivery, if not nil, is called from a background goroutine for every batch of
+	// records acknowledged by a partition.
+	OnDelivery func(KafkaDelivery)
+
+	// OnError, if not nil, receives failed deliveries.
+	OnError func(error)
+}
+
+// KafkaDelivery reports records written to a partition.
+type KafkaDelivery struct {
+	Topic     string
+	Partition int32
+	Offset    int64 // offset of the first record
+	Count     int
+}
+
+// KafkaSink is an io.Writer producing the JSON records written by a FormatJSON handler
+// to a Kafka topic, speaking the Kafka protocol directly. Records are acknowledged
+// asynchronously; call Close to send the remaining records and wait for them.
+type KafkaSink struct {
+	*batcher
+	opts KafkaOptions
+
+	correlation atomic.Int32
+	next        atomic.Uint32 // partition of the next unkeyed batch
+
+	mu         sync.Mutex
+	brokers    map[int32]string
+	leaders    []int32 // leader of each partition
+	conns      map[string]*kafkaConn
+	closedOnce sync.Once
+}
+
+// NewKafkaHandler creates a JSON handler producing records to Kafka and returns the sink
+// so it can be closed on shutdown. Handler options are applied as for NewPrettyHandler.
+func NewKafkaHandler(kafka KafkaOptions, opts ...Option) (*PrettyHandler, *KafkaSink) {
+	s := NewKafkaSink(kafka)
+	return NewPrettyHandler(s, append(opts, WithFormat(FormatJSON), WithTimeFormat(TimeFormatRFC3339Nano))...), s
+}
+
+// NewKafkaSink starts a sink with the given options. Brokers are contacted with the
+// first batch.
+func NewKafkaSink(opts KafkaOptions) *KafkaSink {
+	if len(opts.Brokers) == 0 {
+		opts.Brokers = []string{"localhost:9092"}
+	}
+	if opts.Partitioner == nil {
+		opts.Partitioner = murmur2Partition
+	}
+	if opts.ClientID == "" {
+		opts.ClientID = "slogger"
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 10 * time.Second
+	}
+	s := &KafkaSink{opts: opts, conns: make(map[string]*kafkaConn)}
+	s.batcher = newBatcher(batchOptions{
+		size:        opts.BatchSize,
+		interval:    opts.FlushInterval,
+		maxInFlight: opts.MaxInFlight,
+		maxRetries:  opts.MaxRetries,
+		maxBuffered: opts.MaxBuffered,
+		deadLetter:  opts.DeadLetter,
+		breaker:     opts.Breaker,
+		onError:     opts.OnError,
+		send:        s.send,
+	})
+	return s
+}
+
+// Close sends the remaining records, waits for their acknowledgements and closes the
+// broker connections.
+func (s *KafkaSink) Close() error {
+	err := s.batcher.Close()
+	s.closedOnce.Do(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for addr, c := range s.conns {
+			c.conn.Close()
+			delete(s.conns, addr)
+		}
+	})
+	return err
+}
+
+// kafkaRecord is a record ready to be produced.
+type kafkaRecord struct {
+	raw  []byte
+	key  []byte
+	time time.Time
+}
+
+// send produces records, grouped by partition and by the broker leading it.
+func (s *KafkaSink) send(ctx context.Context, records [][]byte) error {
+	leaders, err := s.metadata(ctx, false)
+	if err != nil {
+		return &retryError{err: fmt.Errorf("slogger: kafka metadata: %w", err)}
+	}
+
+	unkeyed := int32(s.next.Add(1) % uint32(len(leaders)))
+	partitions := make(map[int32][]kafkaRecord)
+	var invalid []kafkaRecord
+	var invalidErr error
+	for _, raw := range records {
+		rec := s.record(raw)
+		p := unkeyed
+		if rec.key != nil {
+			n := s.opts.Partitioner(rec.key, len(leaders))
+			if n < 0 || n >= len(leaders) {
+				invalid = append(invalid, rec)
+				invalidErr = fmt.Errorf("slogger: kafka: partitioner returned %d for %d partitions", n, len(leaders))
+				continue
+			}
+			p = int32(n)
+		}
+		partitions[p] = append(partitions[p], rec)
+	}
+	if len(invalid) > 0 {
+		s.reject(invalidErr, invalid)
+	}
+
+	byBroker := make(map[int32]map[int32][]kafkaRecord)
+	for p, recs := range partitions {
+		leader := leaders[p]
+		if byBroker[leader] == nil {
+			byBroker[leader] = make(map[int32][]kafkaRecord)
+		}
+		byBroker[leader][p] = recs
+	}
+
+	var retry [][]byte
+	var first error
+	for leader, parts := range byBroker {
+		failed, err := s.produce(ctx, leader, parts)
+		for _, p := range failed {
+			for _, rec := range parts[p] {
+				retry = append(retry, rec.raw)
+			}
+		}
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	if len(retry) > 0 {
+		s.metadata(ctx, true)
+		return &retryError{err: first, records: retry}
+	}
+	return first
+}
+
+// record extracts the key and timestamp of a JSON record.
+func (s *KafkaSink) record(raw []byte) kafkaRecord {
+	rec := kafkaRecord{raw: raw, time: time.Now()}
+	var m map[string]interface{}
+	if json.Unmarshal(raw, &m) != nil {
+		return rec
+	}
+	if t, ok := m[slog.TimeKey].(string); ok {
+		if parsed, err := time.Parse(time.RFC3339Nano, t); err == nil {
+			rec.time = parsed
+		}
+	}
+	if s.opts.KeyAttr != "" {
+		if v, ok := lookupPath(m, s.opts.KeyAttr); ok {
+			rec.key = []byte(logfmtValue(v))
+		}
+	}
+	return rec
+}
+
+// Kafka protocol constants.
+const (
+	kafkaProduce  = 0
+	kafkaMetadata = 3
+
+	kafkaLeaderNotAvailable = 5
+	kafkaNotLeader          = 6
+	kafkaRequestTimedOut    = 7
+	kafkaNotEnoughReplicas  = 19
+)
+
+// kafkaRetriable reports whether a partition error code is worth retrying.
+func kafkaRetriable(code int16) bool {
+	switch code {
+	case kafkaLeaderNotAvailable, kafkaNotLeader, kafkaRequestTimedOut, kafkaNotEnoughReplicas:
+		return true
+	}
+	return false
+}
+
+// produce sends the records of the partitions led by one broker and returns the
+// partitions to retry. Rejected partitions are reported as an error.
+func (s *KafkaSink) produce(ctx context.Context, leader int32, parts map[int32][]kafkaRecord) ([]int32, error) {
+	var e kafkaEncoder
+	e.string16(nil) // transactional_id
+	acks := int16(1)
+	if s.opts.RequireAll {
+		acks = -1
+	}
+	e.int16(acks)
+	e.int32(int32(s.opts.DialTimeout / time.Millisecond))
+	e.int32(1)
+	e.string16([]byte(s.opts.Topic))
+	e.int32(int32(len(parts)))
+	for p, recs := range parts {
+		e.int32(p)
+		batch := recordBatch(recs)
+		e.int32(int32(len(batch)))
+		e.buf = append(e.buf, batch...)
+	}
+
+	all := func() []int32 {
+		ps := make([]int32, 0, len(parts))
+		for p := range parts {
+			ps = append(ps, p)
+		}
+		return ps
+	}
+
+	s.mu.Lock()
+	addr, ok := s.brokers[leader]
+	s.mu.Unlock()
+	if !ok {
+		return all(), fmt.Errorf("slogger: kafka: unknown broker %d", leader)
+	}
+	resp, err := s.request(ctx, addr, kafkaProduce, 3, e.buf)
+	if err != nil {
+		return all(), fmt.Errorf("slogger: kafka produce: %w", err)
+	}
+
+	d := kafkaDecoder{buf: resp}
+	var retry []int32
+	var rejected error
+	for range d.count(6) {
+		topic := d.string16()
+		for range d.count(22) {
+			p := d.int32()
+			code := d.int16()
+			offset := d.int64()
+			d.int64() // log_append_time
+			if d.err != nil {
+				break
+			}
+			switch {
+			case code == 0:
+				if s.opts.OnDelivery != nil {
+					s.opts.OnDelivery(KafkaDelivery{Topic: topic, Partition: p, Offset: offset, Count: len(parts[p])})
+				}
+			case kafkaRetriable(code):
+				retry = append(retry, p)
+				rejected = fmt.Errorf("slogger: kafka produce: partition %d: error code %d", p, code)
+			default:
+				s.reject(fmt.Errorf("slogger: kafka produce: partition %d: error code %d", p, code), parts[p])
+			}
+		}
+	}
+	if d.err != nil {
+		return all(), fmt.Errorf("slogger: kafka produce: %w", d.err)
+	}
+	return retry, rejected
+}
+
+// reject hands records that will never be accepted to the dead letter, if any, and
+// reports them, without failing the rest of their batch.
+func (s *KafkaSink) reject(err error, recs []kafkaRecord) {
+	if dl := s.opts.DeadLetter; dl != nil {
+		raw := make([][]byte, len(recs))
+		for i, rec := range recs {
+			raw[i] = rec.raw
+		}
+		n, serr := dl.spool(raw)
+		err = errors.Join(fmt.Errorf("%w (spooled %d records to %s)", err, n, dl.Path()), serr)
+	} else {
+		err = fmt.Errorf("%w (dropped %d records)", err, len(recs))
+	}
+	if s.opts.OnError != nil {
+		s.opts.OnError(err)
+	}
+}
+
+// metadata returns the leader of every partition of the topic, asking the brokers when
+// it is not known yet or refresh is set.
+func (s *KafkaSink) metadata(ctx context.Context, refresh bool) ([]int32, error) {
+	s.mu.Lock()
+	leaders := s.leaders
+	var addrs []string
+	for _, addr := range s.brokers {
+		addrs = append(addrs, addr)
+	}
+	s.mu.Unlock()
+	if leaders != nil && !refresh {
+		return leaders, nil
+	}
+	addrs = append(addrs, s.opts.Brokers...)
+
+	var e kafkaEncoder
+	e.int32(1)
+	e.string16([]byte(s.opts.Topic))
+
+	var err error
+	for _, addr := range addrs {
+		var resp []byte
+		if resp, err = s.request(ctx, addr, kafkaMetadata, 1, e.buf); err != nil {
+			continue
+		}
+		var brokers map[int32]string
+		if brokers, leaders, err = s.parseMetadata(resp); err != nil {
+			continue
+		}
+		s.mu.Lock()
+		s.brokers, s.leaders = brokers, leaders
+		s.mu.Unlock()
+		return leaders, nil
+	}
+	return nil, err
+}
+
+// parseMetadata decodes a Metadata v1 response.
+func (s *KafkaSink) parseMetadata(resp []byte) (map[int32]string, []int32, error) {
+	d := kafkaDecoder{buf: resp}
+	brokers := make(map[int32]string)
+	for range d.count(12) {
+		id := d.int32()
+		host := d.string16()
+		port := d.int32()
+		d.string16() // rack
+		brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	d.int32() // controller_id
+
+	var leaders []int32
+	for range d.count(9) {
+		code := d.int16()
+		d.string16() // name
+		d.int8()     // is_internal
+		if code != 0 && d.err == nil {
+			return nil, nil, fmt.Errorf("topic %q: error code %d", s.opts.Topic, code)
+		}
+		n := d.count(18)
+		leaders = make([]int32, n) // partition ids are 0..n-1
+		for range n {
+			d.int16() // error_code
+			id := d.int32()
+			leader := d.int32()
+			for range d.count(4) { // replicas
+				d.int32()
+			}
+			for range d.count(4) { // isr
+				d.int32()
+			}
+			if id >= 0 && int(id) < len(leaders) {
+				leaders[id] = leader
+			}
+		}
+	}
+	if d.err != nil {
+		return nil, nil, d.err
+	}
+	if len(leaders) == 0 {
+		return nil, nil, fmt.Errorf("topic %q has no partitions", s.opts.Topic)
+	}
+	return brokers, leaders, nil
+}
+
+// kafkaConn is a connection to one broker. Requests on it are serialized.
+type kafkaConn struct {
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// request sends one request to addr and returns the response body after the
+// correlation id. A connection that fails is dropped and redialed with the next request.
+func (s *KafkaSink) request(ctx context.Context, addr string, api, version int16, body []byte) ([]byte, error) {
+	c, err := s.conn(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	id := s.correlation.Add(1)
+	var e kafkaEncoder
+	e.int32(0) // size, set below
+	e.int16(api)
+	e.int16(version)
+	e.int32(id)
+	e.string16([]byte(s.opts.ClientID))
+	e.buf = append(e.buf, body...)
+	binary.BigEndian.PutUint32(e.buf, uint32(len(e.buf)-4))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resp, err := c.roundTrip(e.buf, s.opts.DialTimeout)
+	if err == nil && len(resp) >= 4 && int32(binary.BigEndian.Uint32(resp)) != id {
+		err = errors.New("correlation id mismatch")
+	}
+	if err != nil {
+		c.conn.Close()
+		s.mu.Lock()
+		if s.conns[addr] == c {
+			delete(s.conns, addr)
+		}
+		s.mu.Unlock()
+		return nil, err
+	}
+	return resp[4:], nil
+}
+
+// conn returns the connection to addr, dialing it if needed.
+func (s *KafkaSink) conn(ctx context.Context, addr string) (*kafkaConn, error) {
+	s.mu.Lock()
+	c, ok := s.conns[addr]
+	s.mu.Unlock()
+	if ok {
+		return c, nil
+	}
+
+	d := net.Dialer{Timeout: s.opts.DialTimeout}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c = &kafkaConn{conn: conn, r: bufio.NewReader(conn)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if prev, ok := s.conns[addr]; ok {
+		conn.Close()
+		return prev, nil
+	}
+	s.conns[addr] = c
+	return c, nil
+}
+
+// roundTrip writes a request and reads the size-prefixed response.
+func (c *kafkaConn) roundTrip(req []byte, timeout time.Duration) ([]byte, error) {
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := c.conn.Write(req); err != nil {
+		return nil, err
+	}
+	var size [4]byte
+	if _, err := io.ReadFull(c.r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n < 4 || n > maxResponseSize {
+		return nil, fmt.Errorf("invalid response size %d", n)
+	}
+	resp := make([]byte, n)
+	if _, err := io.ReadFull(c.r, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// recordBatch encodes records as an uncompressed v2 record batch.
+func recordBatch(recs []kafkaRecord) []byte {
+	first := recs[0].time.UnixMilli()
+	maxTime := first
+	var body []byte
+	for i, rec := range recs {
+		ts := rec.time.UnixMilli()
+		maxTime = max(maxTime, ts)
+
+		var r []byte
+		r = append(r, 0) // attributes
+		r = binary.AppendVarint(r, ts-first)
+		r = binary.AppendVarint(r, int64(i))
+		if rec.key == nil {
+			r = binary.AppendVarint(r, -1)
+		} else {
+			r = binary.AppendVarint(r, int64(len(rec.key)))
+			r = append(r, rec.key...)
+		}
+		r = binary.AppendVarint(r, int64(len(rec.raw)))
+		r = append(r, rec.raw...)
+		r = binary.AppendVarint(r, 0) // headers
+
+		body = binary.AppendVarint(body, int64(len(r)))
+		body = append(body, r...)
+	}
+
+	// The CRC covers everything from the attributes to the end of the batch.
+	var crced kafkaEncoder
+	crced.int16(0) // attributes: no compression
+	crced.int32(int32(len(recs) - 1))
+	crced.int64(first)
+	crced.int64(maxTime)
+	crced.int64(-1) // producer id
+	crced.int16(-1) // producer epoch
+	crced.int32(-1) // base sequence
+	crced.int32(int32(len(recs)))
+	crced.buf = append(crced.buf, body...)
+
+	var e kafkaEncoder
+	e.int64(0)                         // base offset
+	e.int32(int32(len(crced.buf) + 9)) // length after this field
+	e.int32(-1)                        // partition leader epoch
+	e.int8(2)                          // magic
+	e.int32(int32(crc32.Checksum(crced.buf, crc32.MakeTable(crc32.Castagnoli))))
+	return append(e.buf, crced.buf...)
+}
+
+// murmur2Partition maps a key to a partition like the default partitioner of the Java
+// client.
+func murmur2Partition(key []byte, partitions int) int {
+	return int(murmur2(key)&0x7fffffff) % partitions
+}
+
+// murmur2 is the 32-bit MurmurHash2 variant used by Kafka.
+func murmur2(data []byte) uint32 {
+	const (
+		seed = 0x9747b28c
+		m    = 0x5bd1e995
+		r    = 24
+	)
+	h := uint32(seed) ^ uint32(len(data))
+	for len(data) >= 4 {
+		k := binary.LittleEndian.Uint32(data)
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+		data = data[4:]
+	}
+	switch len(data) {
+	case 3:
+		h ^= uint32(data[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(data[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(data[0])
+		h *= m
+	}
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return h
+}
+
+// kafkaEncoder appends big-endian protocol primitives.
+type kafkaEncoder struct {
+	buf []byte
+}
+
+func (e *kafkaEncoder) int8(v int8)   { e.buf = append(e.buf, byte(v)) }
+func (e *kafkaEncoder) int16(v int16) { e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(v)) }
+func (e *kafkaEncoder) int32(v int32) { e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(v)) }
+func (e *kafkaEncoder) int64(v int64) { e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(v)) }
+
+// string16 appends a string with an int16 length, or a null string if s is nil.
+func (e *kafkaEncoder) string16(s []byte) {
+	if s == nil {
+		e.int16(-1)
+		return
+	}
+	e.int16(int16(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// kafkaDecoder reads big-endian protocol primitives, remembering the first error.
+// Reads after an error return zero values.
+type kafkaDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *kafkaDecoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.buf) < n {
+		d.err = errors.New("short response")
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *kafkaDecoder) int8() int8 {
+	if b := d.take(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int16() int16 {
+	if b := d.take(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int32() int32 {
+	if b := d.take(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int64() int64 {
+	if b := d.take(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+// count reads the int32 length of an array whose elements take at least size bytes. A
+// length the rest of the response cannot hold is an error rather than a loop through
+// billions of empty reads; null arrays have no elements.
+func (d *kafkaDecoder) count(size int) int {
+	n := int(d.int32())
+	if n <= 0 {
+		return 0
+	}
+	if n > len(d.buf)/size {
+		d.err = errors.New("short response")
+		return 0
+	}
+	return n
+}
+
+// string16 reads a string with an int16 length; null strings are returned as "".
+func (d *kafkaDecoder) string16() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.take(int(n)))
+}
@@ -0,0 +1,458 @@
+package slogger
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Kafka's own test vectors for the murmur2 partitioner (UtilsTest in the Java client).
+func TestMurmur2(t *testing.T) {
+	for in, want := range map[string]int32{
+		"21":                         -973932308,
+		"foobar":                     -790332482,
+		"a-little-bit-long-string":   -985981536,
+		"a-little-bit-longer-string": -1486304829,
+		"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8": -58897971,
+		"abc": 479470107,
+	} {
+		if got := int32(murmur2([]byte(in))); got != want {
+			t.Errorf("murmur2(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+// metadataFrame is a Metadata v1 response of a cluster with broker 1 at kafka:9092 and
+// topic "logs" with two partitions, both led by broker 1.
+const metadataFrame = "00000001" + // brokers
+	"00000001" + "0005" + "6b61666b61" + "00002384" + "ffff" + // id 1, "kafka", 9092, no rack
+	"00000001" + // controller
+	"00000001" + // topics
+	"0000" + "0004" + "6c6f6773" + "00" + // no error, "logs", not internal
+	"00000002" + // partitions
+	"0000" + "00000000" + "00000001" + "00000001" + "00000001" + "00000001" + "00000001" +
+	"0000" + "00000001" + "00000001" + "00000001" + "00000001" + "00000001" + "00000001"
+
+func TestKafkaParseMetadata(t *testing.T) {
+	frame, _ := hex.DecodeString(metadataFrame)
+	s := &KafkaSink{opts: KafkaOptions{Topic: "logs"}}
+
+	brokers, leaders, err := s.parseMetadata(frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := brokers[1]; got != "kafka:9092" {
+		t.Errorf("broker 1 = %q, want kafka:9092", got)
+	}
+	if fmt.Sprint(leaders) != "[1 1]" {
+		t.Errorf("leaders = %v, want [1 1]", leaders)
+	}
+
+	// Every truncation must fail, rather than panic or yield a partial topic.
+	for n := range len(frame) {
+		if _, _, err := s.parseMetadata(frame[:n]); err == nil {
+			t.Errorf("truncated to %d bytes: no error", n)
+		}
+	}
+
+	// A garbled count must not make the decoder spin through billions of empty reads.
+	huge, _ := hex.DecodeString("7fffffff")
+	done := make(chan error, 1)
+	go func() { _, _, err := s.parseMetadata(huge); done <- err }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("garbled count: no error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("garbled count: parseMetadata did not return")
+	}
+
+	topicErr, _ := hex.DecodeString("00000000" + "00000001" + "00000001" + "0003" + "0004" + "6c6f6773" + "00" + "00000000")
+	if _, _, err := s.parseMetadata(topicErr); err == nil || !strings.Contains(err.Error(), "error code 3") {
+		t.Errorf("unknown topic: err = %v, want error code 3", err)
+	}
+}
+
+// fakeKafka is a single broker speaking just enough of Metadata v1 and Produce v3.
+type fakeKafka struct {
+	t          *testing.T
+	ln         net.Listener
+	partitions int
+	codes      map[int32]int16 // error code answered per partition
+	truncate   bool            // cut produce responses short
+
+	mu       sync.Mutex
+	produced map[int32][]kafkaTestRecord
+}
+
+type kafkaTestRecord struct {
+	key, value string
+}
+
+func newFakeKafka(t *testing.T, partitions int, codes map[int32]int16) *fakeKafka {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := &fakeKafka{t: t, ln: ln, partitions: partitions, codes: codes, produced: make(map[int32][]kafkaTestRecord)}
+	go k.serve()
+	t.Cleanup(func() { ln.Close() })
+	return k
+}
+
+func (k *fakeKafka) serve() {
+	for {
+		conn, err := k.ln.Accept()
+		if err != nil {
+			return
+		}
+		go k.handle(conn)
+	}
+}
+
+func (k *fakeKafka) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return
+		}
+		req := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(r, req); err != nil {
+			return
+		}
+		d := kafkaDecoder{buf: req}
+		api, version, id := d.int16(), d.int16(), d.int32()
+		d.string16() // client id
+
+		var body []byte
+		switch {
+		case api == kafkaMetadata && version == 1:
+			body = k.metadata()
+		case api == kafkaProduce && version == 3:
+			var err error
+			if body, err = k.produce(&d); err != nil {
+				k.t.Errorf("fake kafka: %v", err)
+				return
+			}
+			if k.truncate {
+				body = body[:len(body)-5]
+			}
+		default:
+			k.t.Errorf("fake kafka: unexpected api %d version %d", api, version)
+			return
+		}
+
+		var e kafkaEncoder
+		e.int32(int32(4 + len(body)))
+		e.int32(id)
+		e.buf = append(e.buf, body...)
+		if _, err := conn.Write(e.buf); err != nil {
+			return
+		}
+	}
+}
+
+func (k *fakeKafka) metadata() []byte {
+	host, port, _ := net.SplitHostPort(k.ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	var e kafkaEncoder
+	e.int32(1)
+	e.int32(1)
+	e.string16([]byte(host))
+	e.int32(int32(p))
+	e.string16(nil)
+	e.int32(1)
+	e.int32(1)
+	e.int16(0)
+	e.string16([]byte("logs"))
+	e.int8(0)
+	e.int32(int32(k.partitions))
+	for i := range k.partitions {
+		e.int16(0)
+		e.int32(int32(i))
+		e.int32(1)
+		e.int32(1)
+		e.int32(1)
+		e.int32(1)
+		e.int32(1)
+	}
+	return e.buf
+}
+
+func (k *fakeKafka) produce(d *kafkaDecoder) ([]byte, error) {
+	d.string16() // transactional id
+	d.int16()    // acks
+	d.int32()    // timeout
+	var e kafkaEncoder
+	topics := d.int32()
+	e.int32(topics)
+	for range topics {
+		topic := d.string16()
+		e.string16([]byte(topic))
+		parts := d.int32()
+		e.int32(parts)
+		for range parts {
+			p := d.int32()
+			batch := d.take(int(d.int32()))
+			if d.err != nil {
+				return nil, d.err
+			}
+			recs, err := decodeRecordBatch(batch)
+			if err != nil {
+				return nil, err
+			}
+			code := k.codes[p]
+			k.mu.Lock()
+			offset := int64(len(k.produced[p]))
+			if code == 0 {
+				k.produced[p] = append(k.produced[p], recs...)
+			}
+			k.mu.Unlock()
+			e.int32(p)
+			e.int16(code)
+			e.int64(offset)
+			e.int64(-1)
+		}
+	}
+	e.int32(0) // throttle time
+	return e.buf, d.err
+}
+
+// decodeRecordBatch decodes a v2 record batch following the protocol specification,
+// independently of the encoder under test.
+func decodeRecordBatch(b []byte) ([]kafkaTestRecord, error) {
+	if len(b) < 61 {
+		return nil, errors.New("record batch too short")
+	}
+	if n := int(binary.BigEndian.Uint32(b[8:])); n != len(b)-12 {
+		return nil, fmt.Errorf("batch length %d, have %d bytes", n, len(b)-12)
+	}
+	if b[16] != 2 {
+		return nil, fmt.Errorf("magic %d", b[16])
+	}
+	if crc := binary.BigEndian.Uint32(b[17:]); crc != crc32.Checksum(b[21:], crc32.MakeTable(crc32.Castagnoli)) {
+		return nil, errors.New("crc mismatch")
+	}
+	count := int(binary.BigEndian.Uint32(b[57:]))
+	rest := b[61:]
+	varint := func() int64 {
+		v, n := binary.Varint(rest)
+		if n <= 0 {
+			rest = nil
+			return 0
+		}
+		rest = rest[n:]
+		return v
+	}
+	bytes := func(n int64) []byte {
+		if n < 0 || int64(len(rest)) < n {
+			return nil
+		}
+		v := rest[:n]
+		rest = rest[n:]
+		return v
+	}
+	var recs []kafkaTestRecord
+	for i := range count {
+		length := varint()
+		end := len(rest) - int(length)
+		if len(rest) < 1 {
+			return nil, fmt.Errorf("record %d truncated", i)
+		}
+		rest = rest[1:] // attributes
+		varint()        // timestamp delta
+		if delta := varint(); delta != int64(i) {
+			return nil, fmt.Errorf("record %d has offset delta %d", i, delta)
+		}
+		var rec kafkaTestRecord
+		if n := varint(); n >= 0 {
+			rec.key = string(bytes(n))
+		}
+		rec.value = string(bytes(varint()))
+		varint() // headers
+		if len(rest) != end {
+			return nil, fmt.Errorf("record %d has a wrong length", i)
+		}
+		recs = append(recs, rec)
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%d trailing bytes", len(rest))
+	}
+	return recs, nil
+}
+
+func TestKafkaSinkProduce(t *testing.T) {
+	broker := newFakeKafka(t, 3, nil)
+
+	var mu sync.Mutex
+	delivered := 0
+	h, sink := NewKafkaHandler(KafkaOptions{
+		Brokers: []string{broker.ln.Addr().String()},
+		Topic:   "logs",
+		KeyAttr: "user",
+		OnDelivery: func(d KafkaDelivery) {
+			mu.Lock()
+			delivered += d.Count
+			mu.Unlock()
+		},
+		OnError: func(err error) { t.Error(err) },
+	})
+	logger := slog.New(h)
+	users := []string{"alice", "bob", "carol", "dave"}
+	for i, user := range users {
+		logger.Info("login", "user", user, "n", i)
+	}
+	logger.Info("no key")
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	var msgs []string
+	for p, recs := range broker.produced {
+		for _, rec := range recs {
+			var v struct {
+				Msg  string
+				User string
+			}
+			if err := json.Unmarshal([]byte(rec.value), &v); err != nil {
+				t.Fatalf("partition %d: invalid record %q: %v", p, rec.value, err)
+			}
+			if v.User != rec.key {
+				t.Errorf("record of %q produced with key %q", v.User, rec.key)
+			}
+			if v.User != "" {
+				if want := int32(murmur2Partition([]byte(v.User), 3)); p != want {
+					t.Errorf("record of %q produced to partition %d, want %d", v.User, p, want)
+				}
+			}
+			msgs = append(msgs, v.Msg+":"+v.User)
+		}
+	}
+	sort.Strings(msgs)
+	if got, want := strings.Join(msgs, ","), "login:alice,login:bob,login:carol,login:dave,no key:"; got != want {
+		t.Errorf("produced %s, want %s", got, want)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if delivered != len(users)+1 {
+		t.Errorf("delivery reports for %d records, want %d", delivered, len(users)+1)
+	}
+}
+
+func TestKafkaSinkRejected(t *testing.T) {
+	const corruptMessage = 2 // not retriable
+	broker := newFakeKafka(t, 2, map[int32]int16{1: corruptMessage})
+	dl, err := NewDeadLetter(filepath.Join(t.TempDir(), "kafka.dead"), DeadLetterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dl.Close()
+
+	var mu sync.Mutex
+	var errs []string
+	h, sink := NewKafkaHandler(KafkaOptions{
+		Brokers:    []string{broker.ln.Addr().String()},
+		Topic:      "logs",
+		KeyAttr:    "p",
+		DeadLetter: dl,
+		Partitioner: func(key []byte, partitions int) int {
+			n, _ := strconv.Atoi(string(key))
+			return n
+		},
+		OnError: func(err error) {
+			mu.Lock()
+			errs = append(errs, err.Error())
+			mu.Unlock()
+		},
+	})
+	logger := slog.New(h)
+	logger.Info("accepted", "p", 0)
+	logger.Info("refused", "p", 1)
+	logger.Info("out of range", "p", 7)
+	logger.Info("negative", "p", -1)
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	broker.mu.Lock()
+	if n := len(broker.produced[0]); n != 1 {
+		t.Errorf("partition 0 got %d records, want 1", n)
+	}
+	broker.mu.Unlock()
+
+	b, err := os.ReadFile(dl.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spooled := string(b)
+	for _, msg := range []string{"refused", "out of range", "negative"} {
+		if !strings.Contains(spooled, `"msg":"`+msg+`"`) {
+			t.Errorf("%q not spooled to the dead letter:\n%s", msg, spooled)
+		}
+	}
+	if strings.Contains(spooled, "accepted") {
+		t.Errorf("accepted record spooled:\n%s", spooled)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if all := strings.Join(errs, "\n"); !strings.Contains(all, "error code 2") || !strings.Contains(all, "partitioner returned") {
+		t.Errorf("errors reported:\n%s", all)
+	}
+}
+
+func TestKafkaSinkTruncatedResponse(t *testing.T) {
+	broker := newFakeKafka(t, 1, nil)
+	broker.truncate = true
+	dl, err := NewDeadLetter(filepath.Join(t.TempDir(), "kafka.dead"), DeadLetterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dl.Close()
+
+	var mu sync.Mutex
+	var errs []string
+	h, sink := NewKafkaHandler(KafkaOptions{
+		Brokers:    []string{broker.ln.Addr().String()},
+		Topic:      "logs",
+		MaxRetries: -1,
+		DeadLetter: dl,
+		OnError: func(err error) {
+			mu.Lock()
+			errs = append(errs, err.Error())
+			mu.Unlock()
+		},
+	})
+	slog.New(h).Info("unacknowledged")
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, _ := os.ReadFile(dl.Path()); !strings.Contains(string(b), "unacknowledged") {
+		t.Errorf("record not spooled to the dead letter: %q", b)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if all := strings.Join(errs, "\n"); !strings.Contains(all, "short response") {
+		t.Errorf("errors reported:\n%s", all)
+	}
+}
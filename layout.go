@@ -0,0 +1,124 @@
+package slogger
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Segment is a column of the pretty and text output.
+type Segment int
+
+const (
+	SegmentTime    Segment = iota // the timestamp
+	SegmentLevel                  // the level name
+	SegmentMessage                // the log message
+	SegmentFunc                   // the calling function
+	SegmentSource                 // the calling file:line
+	SegmentAttrs                  // the attributes as JSON
+)
+
+// segmentNames are the names accepted by ParseSegment.
+var segmentNames = map[string]Segment{
+	"time":    SegmentTime,
+	"level":   SegmentLevel,
+	"message": SegmentMessage,
+	"msg":     SegmentMessage,
+	"func":    SegmentFunc,
+	"source":  SegmentSource,
+	"attrs":   SegmentAttrs,
+}
+
+// ParseSegment parses a segment name: "time", "level", "message" (also "msg"), "func",
+// "source" or "attrs".
+func ParseSegment(s string) (Segment, error) {
+	if seg, ok := segmentNames[strings.ToLower(s)]; ok {
+		return seg, nil
+	}
+	return 0, fmt.Errorf("slogger: unknown segment %q", s)
+}
+
+// DefaultSegments is the classic "time | level | msg | func | file:line {attrs}" layout.
+var DefaultSegments = []Segment{SegmentTime, SegmentLevel, SegmentMessage, SegmentFunc, SegmentSource, SegmentAttrs}
+
+// LayoutData holds the rendered, possibly colored, segments of a record. It is the data
+// passed to a Layout template. Segments that were dropped are empty.
+type LayoutData struct {
+	Time    string
+	Level   string
+	Message string
+	Func    string
+	Source  string
+	Attrs   string
+}
+
+// ParseLayout compiles a layout template such as
+//
+//	{{.Time}} [{{.Level}}] {{.Message}} {{.Attrs}}
+//
+// for use with WithLayout. The fields available are those of LayoutData.
+func ParseLayout(layout string) (*template.Template, error) {
+	return template.New("layout").Parse(layout)
+}
+
+// hide clears the given segments.
+func (d *LayoutData) hide(segments []Segment) {
+	for _, s := range segments {
+		switch s {
+		case SegmentTime:
+			d.Time = ""
+		case SegmentLevel:
+			d.Level = ""
+		case SegmentMessage:
+			d.Message = ""
+		case SegmentFunc:
+			d.Func = ""
+		case SegmentSource:
+			d.Source = ""
+		case SegmentAttrs:
+			d.Attrs = ""
+		}
+	}
+}
+
+// segment returns the rendered text of s.
+func (d LayoutData) segment(s Segment) string {
+	switch s {
+	case SegmentTime:
+		return d.Time
+	case SegmentLevel:
+		return d.Level
+	case SegmentMessage:
+		return d.Message
+	case SegmentFunc:
+		return d.Func
+	case SegmentSource:
+		return d.Source
+	case SegmentAttrs:
+		return d.Attrs
+	}
+	return ""
+}
+
+// join renders segments in order. Columns are separated by " | ", the attributes by a
+// space unless they start on a line of their own.
+func (d LayoutData) join(segments []Segment) string {
+	var b strings.Builder
+	for _, s := range segments {
+		text := d.segment(s)
+		if text == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			if s == SegmentAttrs {
+				if text[0] != '\n' {
+					b.WriteByte(' ')
+				}
+			} else {
+				b.WriteString(" | ")
+			}
+		}
+		b.WriteString(text)
+	}
+	return b.String()
+}
@@ -0,0 +1,137 @@
+package slogger
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+)
+
+// defaultLevel is the level returned by DefaultLevel.
+var defaultLevel = NewAtomicLevel(slog.LevelInfo)
+
+// DefaultLevel returns the level of the loggers built by New and MakeLogger: changing it,
+// for example with LevelHandler or HandleSignals, changes them all. Only MakeLogger sets
+// it; the presets, NewFromConfig and Reloader have levels of their own.
+func DefaultLevel() AtomicLevel {
+	return defaultLevel
+}
+
+// AtomicLevel is a slog.Leveler whose level can be changed at runtime. Every handler
+// created with it, for example via WithLevel, observes a change immediately.
+//
+// Copies of an AtomicLevel share the same underlying level. The zero value is not
+// usable; create one with NewAtomicLevel.
+type AtomicLevel struct {
+	l *atomic.Int64
+}
+
+// NewAtomicLevel returns an AtomicLevel set to level.
+func NewAtomicLevel(level slog.Level) AtomicLevel {
+	a := AtomicLevel{l: &atomic.Int64{}}
+	a.SetLevel(level)
+	return a
+}
+
+// Level implements slog.Leveler.
+func (a AtomicLevel) Level() slog.Level {
+	return slog.Level(a.l.Load())
+}
+
+// SetLevel changes the level.
+func (a AtomicLevel) SetLevel(level slog.Level) {
+	a.l.Store(int64(level))
+}
+
+// String returns the level name, honoring LevelNames.
+func (a AtomicLevel) String() string {
+	return levelName(slog.AnyValue(a.Level()))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (a AtomicLevel) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseLevel.
+// It initializes a zero AtomicLevel.
+func (a *AtomicLevel) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	if a.l == nil {
+		a.l = &atomic.Int64{}
+	}
+	a.SetLevel(level)
+	return nil
+}
+
+// levelPayload is the JSON body served and accepted by the level endpoints.
+type levelPayload struct {
+	Level string `json:"level"`
+}
+
+// ServeHTTP exposes the level over HTTP. GET returns {"level":"INFO"}; PUT with a body
+// such as {"level":"debug"} changes it and returns the new level.
+func (a AtomicLevel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveLevel(w, r, a.Level, a.SetLevel)
+}
+
+// LevelHandler returns an http.Handler to inspect and change levels on a live service.
+// It controls DefaultLevel, or the level of Named(name) when called with ?name=<name>.
+// Loggers with a level of their own, such as the presets or New(WithLevel(slog.LevelInfo)),
+// don't follow it.
+//
+//	curl -X PUT -d '{"level":"debug"}' http://localhost:8080/log/level
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			defaultLevel.ServeHTTP(w, r)
+			return
+		}
+
+		nl := lookupNamedLevel(name)
+		get := func() slog.Level {
+			if nl.set.Load() {
+				return nl.level.Level()
+			}
+			return defaultLevel.Level()
+		}
+		serveLevel(w, r, get, func(level slog.Level) { SetNamedLevel(name, level) })
+	})
+}
+
+// serveLevel implements the GET/PUT level protocol over the given accessors.
+func serveLevel(w http.ResponseWriter, r *http.Request, get func() slog.Level, set func(slog.Level)) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var p levelPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			writeLevelError(w, http.StatusBadRequest, err)
+			return
+		}
+		level, err := ParseLevel(p.Level)
+		if err != nil {
+			writeLevelError(w, http.StatusBadRequest, err)
+			return
+		}
+		set(level)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "only GET and PUT are supported"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(levelPayload{Level: levelName(slog.AnyValue(get()))})
+}
+
+func writeLevelError(w http.ResponseWriter, code int, err error) {
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,191 @@
+package slogger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+)
+
+// Flush writes the records buffered below the handler of Default: it drains the queues
+// of AsyncHandlers and flushes the sinks, following Tee, NewRouter, Leveled and the
+// other wrappers of this package. It returns when everything is written or ctx is done.
+func Flush(ctx context.Context) error {
+	return FlushHandler(ctx, Default().Handler())
+}
+
+// Close flushes and then closes everything below the handler of Default, for the
+// shutdown path of main:
+//
+//	func main() {
+//		slogger.SetDefault(slog.New(slogger.NewAsyncHandler(h, slogger.AsyncOptions{})))
+//		defer func() {
+//			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//			defer cancel()
+//			slogger.Close(ctx)
+//		}()
+//		...
+//	}
+//
+// Outputs implementing io.Closer are closed, except os.Stdout and os.Stderr. Close
+// returns ctx.Err() if the deadline passes first, leaving the rest to finish in the
+// background.
+func Close(ctx context.Context) error {
+	return CloseHandler(ctx, Default().Handler())
+}
+
+// FlushHandler is Flush for the given handler.
+func FlushHandler(ctx context.Context, h slog.Handler) error {
+	return withDeadline(ctx, func() error {
+		var errs []error
+		for _, n := range lifecycleNodes(h) {
+			if err := flushNode(ctx, n); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// CloseHandler is Close for the given handler.
+func CloseHandler(ctx context.Context, h slog.Handler) error {
+	return withDeadline(ctx, func() error {
+		nodes := lifecycleNodes(h)
+		var errs []error
+		for _, n := range nodes {
+			if err := flushNode(ctx, n); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		// Parents come first, so queues are drained into outputs before those close.
+		for _, n := range nodes {
+			c, ok := n.(io.Closer)
+			if !ok || n == any(os.Stdout) || n == any(os.Stderr) {
+				continue
+			}
+			if err := c.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// lifecycleParent is implemented by the handlers of this package wrapping other handlers,
+// writers or closers, so Flush and Close reach every buffer below them.
+type lifecycleParent interface {
+	children() []any
+}
+
+// lifecycleNodes returns h and everything below it, parents before their children and
+// each value once.
+func lifecycleNodes(h slog.Handler) []any {
+	var nodes []any
+	seen := make(map[any]bool)
+	var visit func(n any)
+	visit = func(n any) {
+		if n == nil {
+			return
+		}
+		if key, ok := identity(n); ok {
+			if seen[key] {
+				return
+			}
+			seen[key] = true
+		}
+		nodes = append(nodes, n)
+		if p, ok := n.(lifecycleParent); ok {
+			for _, c := range p.children() {
+				visit(c)
+			}
+		}
+	}
+	visit(h)
+	return nodes
+}
+
+// identity returns a map key identifying n, if n is a pointer. Derived handlers share
+// queues and outputs, which are pointers, so those are visited once.
+func identity(n any) (any, bool) {
+	v := reflect.ValueOf(n)
+	if v.Kind() != reflect.Pointer {
+		return nil, false
+	}
+	return struct {
+		t reflect.Type
+		p uintptr
+	}{v.Type(), v.Pointer()}, true
+}
+
+// flushNode flushes n if it buffers anything.
+func flushNode(ctx context.Context, n any) error {
+	switch f := n.(type) {
+	case interface{ Flush(context.Context) error }:
+		return f.Flush(ctx)
+	case interface{ Flush() error }: // bufio.Writer and similar
+		return f.Flush()
+	}
+	return nil
+}
+
+// withDeadline runs f, returning early with ctx.Err() if ctx is done first.
+func withDeadline(ctx context.Context, f func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- f() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *PrettyHandler) children() []any      { return []any{h.out, h.split} }
+func (h *AsyncHandler) children() []any       { return []any{h.next} }
+func (h *RingHandler) children() []any        { return []any{h.next} }
+func (h *SamplingHandler) children() []any    { return []any{h.next} }
+func (h *RateLimitHandler) children() []any   { return []any{h.next} }
+func (h *DedupHandler) children() []any       { return []any{h.next} }
+func (h *SentryHandler) children() []any      { return []any{h.next} }
+func (h *HTMLHandler) children() []any        { return []any{h.inner} }
+func (f *levelFilter) children() []any        { return []any{f.Handler} }
+func (h *FilterHandler) children() []any      { return []any{h.next} }
+func (h *ModuleLevelHandler) children() []any { return []any{h.next} }
+func (h *transformHandler) children() []any   { return []any{h.next} }
+func (h *traceHandler) children() []any       { return []any{h.next} }
+func (h *SpanEventHandler) children() []any   { return []any{h.next} }
+
+func (t *teeHandler) children() []any {
+	c := make([]any, len(t.handlers))
+	for i, h := range t.handlers {
+		c[i] = h
+	}
+	return c
+}
+
+func (m multiOutput) children() []any {
+	c := make([]any, len(m))
+	for i, w := range m {
+		c[i] = w
+	}
+	return c
+}
+
+func (h *routerHandler) children() []any {
+	c := []any{h.fallback}
+	for _, rt := range h.routes {
+		c = append(c, rt.Handler)
+	}
+	return c
+}
+
+func (s *swapHandler) children() []any {
+	st := s.root.Load()
+	c := []any{st.handler}
+	for _, cl := range st.closers {
+		c = append(c, cl)
+	}
+	return c
+}
@@ -0,0 +1,62 @@
+package slogger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+// closeRecorder is an output noting whether it was closed.
+type closeRecorder struct {
+	closed bool
+}
+
+func (c *closeRecorder) Write(p []byte) (int, error) { return len(p), nil }
+func (c *closeRecorder) Close() error                { c.closed = true; return nil }
+
+var _ io.WriteCloser = (*closeRecorder)(nil)
+
+func TestCloseHandlerReachesOutputs(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		wrap func(slog.Handler) slog.Handler
+	}{
+		{"filter", func(h slog.Handler) slog.Handler {
+			f, err := NewFilterHandler(h, FilterRule{Action: FilterDrop, Message: "^noise"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return f
+		}},
+		{"modules", func(h slog.Handler) slog.Handler {
+			return NewModuleLevelHandler(h, ModuleLevels{Modules: map[string]slog.Level{"net/http": slog.LevelWarn}})
+		}},
+		{"transform", func(h slog.Handler) slog.Handler {
+			return TransformMiddleware(AttrTransform{Remove: []string{"password"}})(h)
+		}},
+		{"trace", func(h slog.Handler) slog.Handler {
+			prev := Default()
+			defer SetDefault(prev)
+			SetDefault(slog.New(h))
+			_, l := NewTraceContext(context.Background())
+			return l.Handler()
+		}},
+		{"span events", func(h slog.Handler) slog.Handler {
+			return NewSpanEventHandler(h, SpanEventOptions{})
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &closeRecorder{}
+			h := tt.wrap(NewAsyncHandler(NewPrettyHandler(out), AsyncOptions{}))
+			// Derived handlers lead to the same output.
+			h = h.WithAttrs([]slog.Attr{slog.String("k", "v")}).WithGroup("g")
+			if err := CloseHandler(context.Background(), h); err != nil {
+				t.Fatal(err)
+			}
+			if !out.closed {
+				t.Error("output not closed")
+			}
+		})
+	}
+}
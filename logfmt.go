@@ -0,0 +1,86 @@
+package slogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// NewLogfmtHandler creates a handler producing logfmt lines such as
+//
+//	time=2024-05-01T12:00:00Z level=INFO msg="request served" status=200
+//
+// Groups are always rendered as dotted keys. It accepts the same options as NewPrettyHandler.
+func NewLogfmtHandler(out io.Writer, opts ...Option) *PrettyHandler {
+	return NewPrettyHandler(out, append(opts, WithFormat(FormatLogfmt))...)
+}
+
+// encodeLogfmt renders e as a single logfmt line.
+func (h *PrettyHandler) encodeLogfmt(e entry) ([]byte, error) {
+	fs := make(fields, 0, len(e.attrs)+4)
+	if e.time.Key != "" {
+		fs = append(fs, field{key: e.time.Key, value: h.timeValue(e.time.Value, time.RFC3339Nano)})
+	}
+	if e.level.Key != "" {
+		fs = append(fs, field{key: e.level.Key, value: levelName(e.level.Value)})
+	}
+	if e.message.Key != "" {
+		fs = append(fs, field{key: e.message.Key, value: e.message.Value.Any()})
+	}
+	if e.source.Key != "" {
+		fs = append(fs, field{key: e.source.Key, value: sourceString(e.source.Value.Any())})
+	}
+	fs = append(fs, e.attrs.flatten("")...)
+
+	var b strings.Builder
+	for i, f := range fs {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(logfmtQuote(f.key))
+		b.WriteByte('=')
+		b.WriteString(logfmtQuote(logfmtValue(f.value)))
+	}
+	return []byte(b.String()), nil
+}
+
+// logfmtValue converts a field value to its unquoted logfmt text.
+func logfmtValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case time.Duration:
+		return v.String()
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(v)
+	}
+	if b, err := json.Marshal(v); err == nil {
+		return string(b)
+	}
+	return fmt.Sprintf("%+v", v)
+}
+
+// logfmtQuote quotes s when it is empty or contains spaces, '=', '"' or control characters.
+func logfmtQuote(s string) string {
+	if s == "" {
+		return `""`
+	}
+	for _, r := range s {
+		if r == '=' || r == '"' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
@@ -0,0 +1,205 @@
+package slogger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LokiOptions configures a LokiSink.
+type LokiOptions struct {
+	// URL is the push endpoint. Defaults to http://localhost:3100/loki/api/v1/push.
+	URL string
+
+	// Labels are attached to every stream, for example {"service": "api", "env": "prod"}.
+	Labels map[string]string
+
+	// LabelKeys lists attribute keys promoted to stream labels; nested keys use dots, as in
+	// "http.method". The level is always a label. Keep the list short: every distinct
+	// combination of values is a separate stream in Loki.
+	LabelKeys []string
+
+	// TenantID is sent as X-Scope-OrgID for multi-tenant Loki installations.
+	TenantID string
+
+	// Headers are added to every request, for example for authentication.
+	Headers map[string]string
+
+	// BatchSize, FlushInterval, MaxInFlight, MaxRetries, MaxBuffered, DeadLetter and Breaker
+	// tune batching as documented on WebhookOptions. Rate-limit responses (429) are retried
+	// with backoff, honoring Retry-After.
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxInFlight   int
+	MaxRetries    int
+	MaxBuffered   int
+	DeadLetter    *DeadLetter
+	Breaker       *CircuitBreaker
+
+	// Client sends the requests. Defaults to a client with a ten second timeout.
+	Client *http.Client
+
+	// OnError, if not nil, receives failed deliveries.
+	OnError func(error)
+}
+
+// LokiSink is an io.Writer pushing the JSON records written by a FormatJSON handler to
+// Grafana Loki. Each record becomes a log line in the stream selected by its labels.
+// Call Close to send the remaining records.
+type LokiSink struct {
+	*batcher
+	opts LokiOptions
+}
+
+// NewLokiHandler creates a JSON handler pushing records to Loki and returns the sink so it
+// can be closed on shutdown. Handler options are applied as for NewPrettyHandler.
+func NewLokiHandler(loki LokiOptions, opts ...Option) (*PrettyHandler, *LokiSink) {
+	s := NewLokiSink(loki)
+	return NewPrettyHandler(s, append(opts, WithFormat(FormatJSON), WithTimeFormat(TimeFormatRFC3339Nano))...), s
+}
+
+// NewLokiSink starts a sink with the given options.
+func NewLokiSink(opts LokiOptions) *LokiSink {
+	if opts.URL == "" {
+		opts.URL = "http://localhost:3100/loki/api/v1/push"
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.TenantID != "" {
+		headers := map[string]string{"X-Scope-OrgID": opts.TenantID}
+		for k, v := range opts.Headers {
+			headers[k] = v
+		}
+		opts.Headers = headers
+	}
+	s := &LokiSink{opts: opts}
+	s.batcher = newBatcher(batchOptions{
+		size:        opts.BatchSize,
+		interval:    opts.FlushInterval,
+		maxInFlight: opts.MaxInFlight,
+		maxRetries:  opts.MaxRetries,
+		maxBuffered: opts.MaxBuffered,
+		deadLetter:  opts.DeadLetter,
+		breaker:     opts.Breaker,
+		onError:     opts.OnError,
+		send:        s.send,
+	})
+	return s
+}
+
+// lokiStream is one stream of a push request.
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// send pushes records, grouped into streams by their labels.
+func (s *LokiSink) send(ctx context.Context, records [][]byte) error {
+	streams := make(map[string]*lokiStream)
+	var order []string
+	for _, rec := range records {
+		labels, ts := s.labels(rec)
+		key := lokiStreamKey(labels)
+		st, ok := streams[key]
+		if !ok {
+			st = &lokiStream{Stream: labels}
+			streams[key] = st
+			order = append(order, key)
+		}
+		st.Values = append(st.Values, [2]string{strconv.FormatInt(ts.UnixNano(), 10), string(rec)})
+	}
+
+	push := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{}
+	for _, key := range order {
+		push.Streams = append(push.Streams, streams[key])
+	}
+	body, err := json.Marshal(push)
+	if err != nil {
+		return err
+	}
+	if _, err := httpPost(ctx, s.opts.Client, s.opts.URL, "application/json", s.opts.Headers, body, false); err != nil {
+		return fmt.Errorf("slogger: loki push: %w", err)
+	}
+	return nil
+}
+
+// labels returns the stream labels and the timestamp of a JSON record.
+func (s *LokiSink) labels(rec []byte) (map[string]string, time.Time) {
+	labels := make(map[string]string, len(s.opts.Labels)+len(s.opts.LabelKeys)+1)
+	for k, v := range s.opts.Labels {
+		labels[k] = v
+	}
+
+	var m map[string]interface{}
+	ts := time.Now()
+	if err := json.Unmarshal(rec, &m); err != nil {
+		return labels, ts
+	}
+	if t, ok := m[slog.TimeKey].(string); ok {
+		if parsed, err := time.Parse(time.RFC3339Nano, t); err == nil {
+			ts = parsed
+		}
+	}
+	if level, ok := m[slog.LevelKey].(string); ok {
+		labels["level"] = strings.ToLower(level)
+	}
+	for _, key := range s.opts.LabelKeys {
+		if v, ok := lookupPath(m, key); ok {
+			labels[lokiLabelName(key)] = logfmtValue(v)
+		}
+	}
+	return labels, ts
+}
+
+// lookupPath finds a dotted key in a decoded JSON object, either nested or flat.
+func lookupPath(m map[string]interface{}, key string) (interface{}, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	head, rest, ok := strings.Cut(key, ".")
+	if !ok {
+		return nil, false
+	}
+	sub, ok := m[head].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookupPath(sub, rest)
+}
+
+// lokiLabelName turns an attribute key into a valid Prometheus label name.
+func lokiLabelName(key string) string {
+	name := []byte(key)
+	for i, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= '0' && c <= '9' && i > 0) {
+			name[i] = '_'
+		}
+	}
+	return string(name)
+}
+
+// lokiStreamKey identifies a label set.
+func lokiStreamKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[k]))
+		b.WriteByte(',')
+	}
+	return b.String()
+}
@@ -0,0 +1,96 @@
+package slogger
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+// Middleware wraps a handler with behavior of its own, such as sampling, enrichment or
+// routing. It is the extension point for composing handlers declaratively with Chain:
+// any func(slog.Handler) slog.Handler is one, including those of other packages.
+type Middleware func(slog.Handler) slog.Handler
+
+// Chain returns a middleware applying mws in order, the first one outermost, so records
+// go through them in the order listed:
+//
+//	h := slogger.Chain(
+//		slogger.FilterMiddleware(rules...),
+//		slogger.SamplingMiddleware(sampling),
+//		slogger.AsyncMiddleware(slogger.AsyncOptions{}),
+//	)(slogger.NewPrettyHandler(os.Stdout))
+//
+// Nil middlewares are skipped, so optional stages can be left out in place.
+func Chain(mws ...Middleware) Middleware {
+	return func(h slog.Handler) slog.Handler {
+		for i := len(mws) - 1; i >= 0; i-- {
+			if mws[i] != nil {
+				h = mws[i](h)
+			}
+		}
+		return h
+	}
+}
+
+// AsyncMiddleware is NewAsyncHandler as a middleware.
+func AsyncMiddleware(opts AsyncOptions) Middleware {
+	return func(h slog.Handler) slog.Handler { return NewAsyncHandler(h, opts) }
+}
+
+// SamplingMiddleware is NewSamplingHandler as a middleware.
+func SamplingMiddleware(opts SamplingOptions) Middleware {
+	return func(h slog.Handler) slog.Handler { return NewSamplingHandler(h, opts) }
+}
+
+// RateLimitMiddleware is NewRateLimitHandler as a middleware.
+func RateLimitMiddleware(opts RateLimitOptions) Middleware {
+	return func(h slog.Handler) slog.Handler { return NewRateLimitHandler(h, opts) }
+}
+
+// DedupMiddleware is NewDedupHandler as a middleware.
+func DedupMiddleware(opts DedupOptions) Middleware {
+	return func(h slog.Handler) slog.Handler { return NewDedupHandler(h, opts) }
+}
+
+// RingMiddleware is NewRingHandler as a middleware.
+func RingMiddleware(opts RingOptions) Middleware {
+	return func(h slog.Handler) slog.Handler { return NewRingHandler(h, opts) }
+}
+
+// ModuleLevelMiddleware is NewModuleLevelHandler as a middleware.
+func ModuleLevelMiddleware(levels ModuleLevels) Middleware {
+	return func(h slog.Handler) slog.Handler { return NewModuleLevelHandler(h, levels) }
+}
+
+// FilterMiddleware is NewFilterHandler as a middleware. It panics if the Message of a
+// rule is not a valid regular expression, like regexp.MustCompile. Each handler it
+// builds starts with a copy of rules, so SetRules on one leaves the others as they are;
+// use NewFilterHandler to keep hold of the handler whose rules change at runtime.
+func FilterMiddleware(rules ...FilterRule) Middleware {
+	// Compile once, so the error surfaces where the chain is built.
+	probe, err := NewFilterHandler(nil, rules...)
+	if err != nil {
+		panic(err)
+	}
+	compiled := probe.rules.Load()
+	return func(h slog.Handler) slog.Handler {
+		fh := &FilterHandler{next: h, rules: &atomic.Pointer[[]FilterRule]{}}
+		fh.rules.Store(compiled)
+		return fh
+	}
+}
+
+// SpanEventMiddleware is NewSpanEventHandler as a middleware.
+func SpanEventMiddleware(opts SpanEventOptions) Middleware {
+	return func(h slog.Handler) slog.Handler { return NewSpanEventHandler(h, opts) }
+}
+
+// LevelMiddleware is Leveled as a middleware.
+func LevelMiddleware(level slog.Leveler) Middleware {
+	return func(h slog.Handler) slog.Handler { return Leveled(h, level) }
+}
+
+// RouterMiddleware is NewRouter as a middleware: the records matching a route go to its
+// handler, and the others to the wrapped handler.
+func RouterMiddleware(routes ...Route) Middleware {
+	return func(h slog.Handler) slog.Handler { return NewRouter(h, routes...) }
+}
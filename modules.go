@@ -0,0 +1,188 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ModuleLevels are minimum levels by source package.
+type ModuleLevels struct {
+	// Modules maps package paths to levels. A path also covers the packages below it and
+	// the longest matching path applies.
+	Modules map[string]slog.Level
+
+	// Default, if set, is the level of the packages matching no path. Otherwise they
+	// follow the level of the wrapped handler.
+	Default slog.Leveler
+}
+
+// ParseModuleLevels parses comma-separated rules of the form "path=level", with a bare
+// level setting the default:
+//
+//	github.com/acme/app/storage=debug, github.com/acme/app/http=warn, info
+func ParseModuleLevels(spec string) (ModuleLevels, error) {
+	ml := ModuleLevels{Modules: make(map[string]slog.Level)}
+	for _, rule := range strings.Split(spec, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		module, name, ok := strings.Cut(rule, "=")
+		if !ok {
+			module, name = "", module
+		}
+		level, err := ParseLevel(strings.TrimSpace(name))
+		if err != nil {
+			return ModuleLevels{}, err
+		}
+		if module = strings.TrimSpace(module); module == "" {
+			ml.Default = level
+		} else {
+			ml.Modules[module] = level
+		}
+	}
+	return ml, nil
+}
+
+// ModuleLevelHandler filters records by the package logging them, found from their
+// source, so verbosity can be tuned per package without touching the call sites:
+//
+//	levels, err := slogger.ParseModuleLevels("github.com/acme/app/storage=debug, info")
+//	...
+//	logger := slog.New(slogger.NewModuleLevelHandler(h, levels))
+//
+// The package is only known once the record exists, so Enabled reports the lowest level
+// of all rules and records below the level of their package are dropped in Handle.
+type ModuleLevelHandler struct {
+	next   slog.Handler
+	levels *atomic.Pointer[moduleRules]
+}
+
+// moduleRules are ModuleLevels prepared for matching.
+type moduleRules struct {
+	modules  []string // longest first
+	levels   map[string]slog.Level
+	base     slog.Leveler // level of the wrapped handler
+	fallback slog.Leveler // level of the unmatched packages
+	lowest   slog.Level   // lowest level of the modules
+}
+
+// NewModuleLevelHandler wraps h with the given levels. A PrettyHandler gets its level
+// replaced, so a package can be more verbose than the handler; other handlers can only be
+// filtered further.
+func NewModuleLevelHandler(h slog.Handler, levels ModuleLevels) *ModuleLevelHandler {
+	mh := &ModuleLevelHandler{levels: &atomic.Pointer[moduleRules]{}}
+	if ph, ok := h.(*PrettyHandler); ok {
+		fallback := ph.opts.SlogOpts.Level
+		if fallback == nil {
+			fallback = slog.LevelInfo
+		}
+		mh.next = ph.withLeveler(moduleMinLeveler{mh.levels})
+		mh.store(levels, fallback)
+	} else {
+		mh.next = h
+		mh.store(levels, slog.Level(math.MinInt))
+	}
+	return mh
+}
+
+// SetLevels replaces the levels of h and of the handlers derived from it.
+func (h *ModuleLevelHandler) SetLevels(levels ModuleLevels) {
+	h.store(levels, h.levels.Load().base)
+}
+
+// store prepares levels, with base as the level of unmatched packages when levels has
+// no Default.
+func (h *ModuleLevelHandler) store(levels ModuleLevels, base slog.Leveler) {
+	mr := &moduleRules{
+		levels:   make(map[string]slog.Level, len(levels.Modules)),
+		base:     base,
+		fallback: base,
+		lowest:   slog.Level(math.MaxInt),
+	}
+	if levels.Default != nil {
+		mr.fallback = levels.Default
+	}
+	for m, l := range levels.Modules {
+		m = strings.TrimSuffix(m, "/")
+		mr.modules = append(mr.modules, m)
+		mr.levels[m] = l
+		mr.lowest = min(mr.lowest, l)
+	}
+	sort.Slice(mr.modules, func(i, j int) bool { return len(mr.modules[i]) > len(mr.modules[j]) })
+	h.levels.Store(mr)
+}
+
+// min returns the lowest level of all rules.
+func (mr *moduleRules) min() slog.Level {
+	return min(mr.lowest, mr.fallback.Level())
+}
+
+// level returns the minimum level of the package logging at pc.
+func (mr *moduleRules) level(pc uintptr) slog.Level {
+	if pc != 0 {
+		pkg := pcPackage(pc)
+		for _, m := range mr.modules {
+			if pkg == m || strings.HasPrefix(pkg, m+"/") {
+				return mr.levels[m]
+			}
+		}
+	}
+	return mr.fallback.Level()
+}
+
+// moduleMinLeveler reports the lowest level of the current rules.
+type moduleMinLeveler struct {
+	levels *atomic.Pointer[moduleRules]
+}
+
+func (l moduleMinLeveler) Level() slog.Level {
+	return l.levels.Load().min()
+}
+
+// pcPackages caches the package path of program counters.
+var pcPackages sync.Map // uintptr → string
+
+// pcPackage returns the import path of the package of the function at pc.
+func pcPackage(pc uintptr) string {
+	if pkg, ok := pcPackages.Load(pc); ok {
+		return pkg.(string)
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	fn := frame.Function // such as github.com/acme/app/storage.(*Store).Get
+	pkg := fn
+	slash := strings.LastIndexByte(fn, '/')
+	if dot := strings.IndexByte(fn[slash+1:], '.'); dot >= 0 {
+		pkg = fn[:slash+1+dot]
+	}
+	pcPackages.Store(pc, pkg)
+	return pkg
+}
+
+func (h *ModuleLevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.levels.Load().min() && h.next.Enabled(ctx, level)
+}
+
+func (h *ModuleLevelHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < h.levels.Load().level(r.PC) && !dumping(ctx) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *ModuleLevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ModuleLevelHandler{next: h.next.WithAttrs(attrs), levels: h.levels}
+}
+
+func (h *ModuleLevelHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &ModuleLevelHandler{next: h.next.WithGroup(name), levels: h.levels}
+}
@@ -0,0 +1,88 @@
+package slogger
+
+import (
+	"strings"
+)
+
+// Multiline selects how the pretty and text output render messages and attribute values
+// spanning several lines.
+type Multiline int
+
+const (
+	// MultilineFold keeps the first line of the message in the record and moves its
+	// continuation lines and multi-line string attributes, such as stack traces, below the
+	// record as indented blocks.
+	MultilineFold Multiline = iota
+	// MultilineEscape keeps every record on one line by escaping newlines in the message as \n.
+	MultilineEscape
+	// MultilineRaw writes messages as they are.
+	MultilineRaw
+)
+
+// foldIndent prefixes the continuation lines of folded messages and attributes.
+const foldIndent = "    "
+
+// WithMultiline sets how messages and attributes spanning several lines are rendered.
+func WithMultiline(mode Multiline) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.Multiline = mode
+	})
+}
+
+// foldMessage renders msg according to mode. When folding, it returns the first line and,
+// separately, the continuation lines to write below the record.
+func foldMessage(msg string, mode Multiline) (line, rest string) {
+	if !strings.Contains(msg, "\n") {
+		return msg, ""
+	}
+	switch mode {
+	case MultilineFold:
+		line, rest, _ = strings.Cut(strings.TrimRight(msg, "\n"), "\n")
+		return line, "\n" + foldIndent + strings.ReplaceAll(rest, "\n", "\n"+foldIndent)
+	case MultilineEscape:
+		return strings.ReplaceAll(msg, "\n", `\n`), ""
+	default:
+		return msg, ""
+	}
+}
+
+// splitMultiline removes string values containing newlines from fs, returning the
+// remaining fields and the removed ones under their dotted keys. Groups left empty are dropped.
+func (fs fields) splitMultiline(prefix string) (rest, blocks fields) {
+	rest = make(fields, 0, len(fs))
+	for _, f := range fs {
+		key := f.key
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := f.value.(type) {
+		case fields:
+			r, b := v.splitMultiline(key)
+			if len(r) > 0 {
+				rest = append(rest, field{key: f.key, value: r})
+			}
+			blocks = append(blocks, b...)
+		case string:
+			if strings.Contains(v, "\n") {
+				blocks = append(blocks, field{key: key, value: v})
+				continue
+			}
+			rest = append(rest, f)
+		default:
+			rest = append(rest, f)
+		}
+	}
+	return rest, blocks
+}
+
+// foldBlocks renders multi-line attributes as indented blocks following the record.
+func foldBlocks(blocks fields) string {
+	var b strings.Builder
+	for _, f := range blocks {
+		b.WriteString("\n" + foldIndent + f.key + ":")
+		for _, line := range strings.Split(strings.TrimRight(f.value.(string), "\n"), "\n") {
+			b.WriteString("\n" + foldIndent + foldIndent + line)
+		}
+	}
+	return b.String()
+}
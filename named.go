@@ -0,0 +1,153 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// NameKey is the attribute key under which a named logger records its name.
+const NameKey = "logger"
+
+// namedLevels is the central registry of per-name levels.
+var namedLevels = struct {
+	sync.RWMutex
+	m map[string]*namedLevel
+}{m: make(map[string]*namedLevel)}
+
+// namedLevel is the configured level of a single name.
+type namedLevel struct {
+	set   atomic.Bool
+	level slog.LevelVar
+}
+
+// lookupNamedLevel returns the registry entry for name, creating it if needed.
+func lookupNamedLevel(name string) *namedLevel {
+	namedLevels.RLock()
+	nl, ok := namedLevels.m[name]
+	namedLevels.RUnlock()
+	if ok {
+		return nl
+	}
+
+	namedLevels.Lock()
+	defer namedLevels.Unlock()
+	if nl, ok = namedLevels.m[name]; !ok {
+		nl = &namedLevel{}
+		namedLevels.m[name] = nl
+	}
+	return nl
+}
+
+// SetNamedLevel sets the minimum level of every logger returned by Named(name).
+// The change applies immediately, including to loggers created earlier. Below the level
+// of the default handler, it only takes effect when that handler is built from this
+// package's handlers; see withNamedLevel.
+func SetNamedLevel(name string, level slog.Level) {
+	nl := lookupNamedLevel(name)
+	nl.level.Set(level)
+	nl.set.Store(true)
+}
+
+// ResetNamedLevel makes loggers returned by Named(name) use the level of the
+// underlying handler again.
+func ResetNamedLevel(name string) {
+	lookupNamedLevel(name).set.Store(false)
+}
+
+// NamedLevels returns the names that have a level configured with SetNamedLevel.
+func NamedLevels() map[string]slog.Level {
+	namedLevels.RLock()
+	defer namedLevels.RUnlock()
+
+	levels := make(map[string]slog.Level, len(namedLevels.m))
+	for name, nl := range namedLevels.m {
+		if nl.set.Load() {
+			levels[name] = nl.level.Level()
+		}
+	}
+	return levels
+}
+
+// Named returns a logger for the subsystem name, derived from Default and tagged with
+// a "logger" attribute. Its minimum level can be configured centrally with SetNamedLevel;
+// until then it follows the level of the default handler.
+func Named(name string) *slog.Logger {
+	return slog.New(withNamedLevel(Default().Handler(), lookupNamedLevel(name))).With(NameKey, name)
+}
+
+// nameLeveler reports the configured level of a name, or fallback when none is set.
+type nameLeveler struct {
+	nl       *namedLevel
+	fallback slog.Leveler
+}
+
+func (l nameLeveler) Level() slog.Level {
+	if l.nl.set.Load() {
+		return l.nl.level.Level()
+	}
+	return l.fallback.Level()
+}
+
+// withNamedLevel applies the level of nl to h. The PrettyHandlers that h ends in get their
+// level replaced, so a name can be more verbose than the handler, also behind the wrappers
+// of this package such as the SamplingHandler of NewProduction; other handlers can only
+// be filtered further.
+func withNamedLevel(h slog.Handler, nl *namedLevel) slog.Handler {
+	return relevel(h, func(fallback slog.Leveler) slog.Leveler {
+		return nameLeveler{nl: nl, fallback: fallback}
+	})
+}
+
+// relevel returns h with the level of each PrettyHandler it ends in replaced by the result
+// of leveler called with that level. It looks through SamplingHandler, DedupHandler,
+// RateLimitHandler, AsyncHandler and Tee, whose copies share the state of the originals.
+// Any other handler is wrapped in a levelFilter, which can only restrict it.
+func relevel(h slog.Handler, leveler func(fallback slog.Leveler) slog.Leveler) slog.Handler {
+	switch h := h.(type) {
+	case *PrettyHandler:
+		fallback := h.opts.SlogOpts.Level
+		if fallback == nil {
+			fallback = slog.LevelInfo
+		}
+		return h.withLeveler(leveler(fallback))
+	case *SamplingHandler:
+		return &SamplingHandler{next: relevel(h.next, leveler), s: h.s}
+	case *DedupHandler:
+		return &DedupHandler{next: relevel(h.next, leveler), s: h.s, prefix: h.prefix, bound: h.bound}
+	case *RateLimitHandler:
+		return &RateLimitHandler{next: relevel(h.next, leveler), s: h.s, prefix: h.prefix, bound: h.bound}
+	case *AsyncHandler:
+		return &AsyncHandler{next: relevel(h.next, leveler), q: h.q, gid: h.gid}
+	case *teeHandler:
+		return h.derive(func(h slog.Handler) slog.Handler { return relevel(h, leveler) })
+	}
+	return &levelFilter{Handler: h, leveler: leveler(slog.Level(math.MinInt))}
+}
+
+// levelFilter drops records below a minimum level before they reach the wrapped handler.
+type levelFilter struct {
+	slog.Handler
+	leveler slog.Leveler
+}
+
+func (f *levelFilter) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= f.leveler.Level() && f.Handler.Enabled(ctx, level)
+}
+
+func (f *levelFilter) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < f.leveler.Level() && !dumping(ctx) {
+		return nil
+	}
+	return f.Handler.Handle(ctx, r)
+}
+
+func (f *levelFilter) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelFilter{Handler: f.Handler.WithAttrs(attrs), leveler: f.leveler}
+}
+
+func (f *levelFilter) WithGroup(name string) slog.Handler {
+	return &levelFilter{Handler: f.Handler.WithGroup(name), leveler: f.leveler}
+}
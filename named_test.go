@@ -0,0 +1,79 @@
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNamedLevelThroughWrappers(t *testing.T) {
+	pretty := func(buf *bytes.Buffer) slog.Handler {
+		return NewPrettyHandler(buf, WithFormat(FormatJSON), WithLevel(slog.LevelInfo), WithSource(false))
+	}
+	for _, tc := range []struct {
+		name      string
+		handler   func(*bytes.Buffer) slog.Handler
+		verbosity bool // whether SetNamedLevel can go below Info
+	}{
+		{"pretty", pretty, true},
+		{"sampling", func(buf *bytes.Buffer) slog.Handler {
+			return NewSamplingHandler(pretty(buf), ProductionSampling())
+		}, true},
+		{"dedup and rate limit", func(buf *bytes.Buffer) slog.Handler {
+			return NewDedupHandler(NewRateLimitHandler(pretty(buf), RateLimitOptions{Burst: 100}), DedupOptions{})
+		}, true},
+		{"async tee", func(buf *bytes.Buffer) slog.Handler {
+			return NewAsyncHandler(Tee(pretty(buf)), AsyncOptions{Policy: OverflowBlock})
+		}, true},
+		{"foreign", func(buf *bytes.Buffer) slog.Handler {
+			return slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+		}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := tc.handler(&buf)
+			prev := Default()
+			defer SetDefault(prev)
+			SetDefault(slog.New(h))
+			name := "db-" + tc.name
+			defer ResetNamedLevel(name)
+
+			db := Named(name)
+			db.Debug("hidden")
+			SetNamedLevel(name, slog.LevelDebug)
+			db.Debug("verbose")
+			// The level is checked again when a queued record is written.
+			if err := Flush(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			SetNamedLevel(name, slog.LevelWarn)
+			db.Info("quiet")
+			db.Warn("loud")
+			if err := Flush(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if a, ok := h.(*AsyncHandler); ok {
+				a.Close()
+			}
+
+			var msgs []string
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				var rec struct{ Msg string }
+				if err := json.Unmarshal([]byte(line), &rec); err != nil {
+					t.Fatalf("invalid JSON line %q: %v", line, err)
+				}
+				msgs = append(msgs, rec.Msg)
+			}
+			want := "loud"
+			if tc.verbosity {
+				want = "verbose loud"
+			}
+			if got := strings.Join(msgs, " "); got != want {
+				t.Errorf("records = %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package slogger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// natsPeer is the server side of a connection to newFakeNATS.
+type natsPeer struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// line reads one protocol line without its CRLF.
+func (p *natsPeer) line() (string, bool) {
+	line, err := p.r.ReadString('\n')
+	if err != nil {
+		if err != io.EOF { // the client hung up
+			p.t.Errorf("fake nats: %v", err)
+		}
+		return "", false
+	}
+	if !strings.HasSuffix(line, "\r\n") {
+		p.t.Errorf("fake nats: line %q not terminated with CRLF", line)
+	}
+	return strings.TrimSuffix(line, "\r\n"), true
+}
+
+func (p *natsPeer) send(s string) {
+	if _, err := io.WriteString(p.conn, s); err != nil {
+		p.t.Errorf("fake nats: %v", err)
+	}
+}
+
+// handshake sends the INFO banner and answers CONNECT and its PING, returning CONNECT.
+func (p *natsPeer) handshake(info string) (string, bool) {
+	p.send("INFO " + info + "\r\n")
+	connect, ok := p.line()
+	if !ok {
+		return "", false
+	}
+	if ping, ok := p.line(); !ok || ping != "PING" {
+		p.t.Errorf("fake nats: %q after CONNECT, want PING", ping)
+		return "", false
+	}
+	p.send("PONG\r\n")
+	return connect, true
+}
+
+// natsPub is a PUB read by publishes.
+type natsPub struct {
+	subject, reply, payload string
+}
+
+// publishes reads the PUB frames of a batch up to its PING, and the SUB of JetStream.
+func (p *natsPeer) publishes() (pubs []natsPub, sub string, ok bool) {
+	for {
+		line, ok := p.line()
+		if !ok {
+			return nil, "", false
+		}
+		op, args, _ := strings.Cut(line, " ")
+		switch op {
+		case "PING":
+			return pubs, sub, true
+		case "SUB":
+			sub = args
+		case "PUB":
+			f := strings.Fields(args)
+			if len(f) < 2 || len(f) > 3 {
+				p.t.Errorf("fake nats: malformed %q", line)
+				return nil, "", false
+			}
+			n, err := strconv.Atoi(f[len(f)-1])
+			if err != nil || n < 0 {
+				p.t.Errorf("fake nats: malformed %q", line)
+				return nil, "", false
+			}
+			payload := make([]byte, n+2)
+			if _, err := io.ReadFull(p.r, payload); err != nil || string(payload[n:]) != "\r\n" {
+				p.t.Errorf("fake nats: payload of %q: %q, %v", line, payload, err)
+				return nil, "", false
+			}
+			pub := natsPub{subject: f[0], payload: string(payload[:n])}
+			if len(f) == 3 {
+				pub.reply = f[1]
+			}
+			pubs = append(pubs, pub)
+		default:
+			p.t.Errorf("fake nats: unexpected %q", line)
+			return nil, "", false
+		}
+	}
+}
+
+// newFakeNATS serves every connection with script. It returns the URL of the server and
+// a function stopping it and waiting for the scripts to return.
+func newFakeNATS(t *testing.T, userinfo string, script func(p *natsPeer)) (string, func()) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	stop := sync.OnceFunc(func() {
+		ln.Close()
+		wg.Wait()
+	})
+	t.Cleanup(stop)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer conn.Close()
+				script(&natsPeer{t: t, conn: conn, r: bufio.NewReader(conn)})
+			}()
+		}
+	}()
+	return "nats://" + userinfo + ln.Addr().String(), stop
+}
+
+func TestNATSSinkPublish(t *testing.T) {
+	var pubs []natsPub
+	var connect string
+	url, stop := newFakeNATS(t, "app:s3cret@", func(p *natsPeer) {
+		var ok bool
+		if connect, ok = p.handshake(`{"server_id":"test","version":"2.10.0","max_payload":512}`); !ok {
+			return
+		}
+		if pubs, _, ok = p.publishes(); !ok {
+			return
+		}
+		// The client must answer the pings of the server while it waits for its pong.
+		p.send("PING\r\n")
+		if pong, _ := p.line(); pong != "PONG" {
+			t.Errorf("fake nats: %q after PING, want PONG", pong)
+		}
+		p.send("PONG\r\n")
+		p.line() // until the client closes
+	})
+
+	var errs []string
+	h, sink := NewNATSHandler(NATSOptions{
+		URL:     url,
+		Subject: "logs.{service}.{level}",
+		OnError: func(err error) { errs = append(errs, err.Error()) },
+	})
+	logger := slog.New(h)
+	logger.Info("started", "service", "api")
+	logger.Error("failed", "service", "web app.v2")
+	logger.Info("no service")
+	logger.Info("too large", "service", "api", "blob", strings.Repeat("x", 600))
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	stop()
+
+	const wantConnect = `CONNECT {"lang":"go","name":"slogger","pass":"s3cret","pedantic":false,"protocol":1,"user":"app","verbose":false,"version":"slogger"}`
+	if connect != wantConnect {
+		t.Errorf("connect = %s\nwant      %s", connect, wantConnect)
+	}
+	var got []string
+	for _, pub := range pubs {
+		var rec struct{ Msg string }
+		if err := json.Unmarshal([]byte(pub.payload), &rec); err != nil {
+			t.Errorf("invalid payload %q: %v", pub.payload, err)
+		}
+		if pub.reply != "" {
+			t.Errorf("core publish with reply subject %q", pub.reply)
+		}
+		got = append(got, pub.subject+" "+rec.Msg)
+	}
+	if want := "logs.api.INFO started,logs.web_app_v2.ERROR failed,logs._.INFO no service"; strings.Join(got, ",") != want {
+		t.Errorf("published %q, want %q", got, want)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0], "dropped 1 records over the 512 bytes limit") {
+		t.Errorf("errors = %q", errs)
+	}
+}
+
+func TestNATSSinkJetStream(t *testing.T) {
+	var batches [][]string
+	url, stop := newFakeNATS(t, "", func(p *natsPeer) {
+		if _, ok := p.handshake(`{"max_payload":1048576}`); !ok {
+			return
+		}
+		for attempt := 0; ; attempt++ {
+			pubs, sub, ok := p.publishes()
+			if !ok {
+				return
+			}
+			if (attempt == 0) != (sub != "") {
+				t.Errorf("attempt %d subscribed to %q", attempt, sub)
+			}
+			var msgs []string
+			p.send("PONG\r\n")
+			p.send("MSG _INBOX.stale.0 1 2\r\n{}\r\n")
+			for _, pub := range pubs {
+				var rec struct{ Msg string }
+				json.Unmarshal([]byte(pub.payload), &rec)
+				msgs = append(msgs, rec.Msg)
+				ack := `{"stream":"LOGS","seq":1}`
+				switch {
+				case rec.Msg == "unavailable" && attempt == 0:
+					ack = `{"error":{"code":503,"err_code":10077,"description":"no responders"}}`
+				case rec.Msg == "refused":
+					ack = `{"error":{"code":400,"err_code":10054,"description":"maximum messages exceeded"}}`
+				}
+				p.send(fmt.Sprintf("MSG %s 1 %d\r\n%s\r\n", pub.reply, len(ack), ack))
+			}
+			sort.Strings(msgs)
+			batches = append(batches, msgs)
+		}
+	})
+
+	var errs []string
+	h, sink := NewNATSHandler(NATSOptions{
+		URL:       url,
+		JetStream: true,
+		OnError:   func(err error) { errs = append(errs, err.Error()) },
+	})
+	logger := slog.New(h)
+	logger.Info("acknowledged")
+	logger.Info("unavailable")
+	logger.Info("refused")
+	// Flush waits for the retries, Close would give them up.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sink.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	stop()
+
+	if got, want := fmt.Sprint(batches), "[[acknowledged refused unavailable] [unavailable]]"; got != want {
+		t.Errorf("batches = %s, want %s", got, want)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0], "maximum messages exceeded (dropped 1 record)") {
+		t.Errorf("errors = %q", errs)
+	}
+}
+
+func TestNATSSinkProtocolErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		script func(p *natsPeer)
+		want   string
+	}{
+		{
+			name: "auth",
+			script: func(p *natsPeer) {
+				p.send("INFO {}\r\n")
+				p.line()
+				p.line()
+				p.send("-ERR 'Authorization Violation'\r\n")
+			},
+			want: "slogger: nats connect: Authorization Violation",
+		},
+		{
+			name:   "banner",
+			script: func(p *natsPeer) { p.send("HELLO\r\n") },
+			want:   `unexpected "HELLO\r\n"`,
+		},
+		{
+			name:   "hangup",
+			script: func(p *natsPeer) {},
+			want:   "slogger: nats connect: EOF",
+		},
+		{
+			name: "publish",
+			script: func(p *natsPeer) {
+				if _, ok := p.handshake("{}"); ok {
+					p.publishes()
+					p.send("-ERR 'Permissions Violation for Publish to logs'\r\n")
+				}
+			},
+			want: "slogger: nats publish: Permissions Violation for Publish to logs",
+		},
+		{
+			name: "malformed",
+			script: func(p *natsPeer) {
+				if _, ok := p.handshake("{}"); ok {
+					p.publishes()
+					p.send("MSG logs\r\n")
+				}
+			},
+			want: `malformed "MSG logs"`,
+		},
+		{
+			name: "size",
+			script: func(p *natsPeer) {
+				if _, ok := p.handshake("{}"); ok {
+					p.publishes()
+					p.send("MSG logs 1 -5\r\n")
+				}
+			},
+			want: `malformed "MSG logs 1 -5"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			url, _ := newFakeNATS(t, "", tt.script)
+			var errs []string
+			h, sink := NewNATSHandler(NATSOptions{
+				URL:        url,
+				JetStream:  true,
+				MaxRetries: -1,
+				OnError:    func(err error) { errs = append(errs, err.Error()) },
+			})
+			slog.New(h).Info("lost")
+			if err := sink.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0], tt.want) || !strings.Contains(errs[0], "dropped 1 records") {
+				t.Errorf("errors = %q, want %q", errs, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package slogger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// NetFraming selects how a NetSink delimits records on the wire.
+type NetFraming int
+
+const (
+	// FramingNewline terminates every record with a newline, as expected by the tcp and
+	// udp inputs of Logstash and Vector with the json_lines or newline codecs.
+	FramingNewline NetFraming = iota
+	// FramingLength prefixes every record with its length as a 4-byte big-endian integer.
+	FramingLength
+)
+
+// ErrBufferFull is returned by a sink's Write when its buffer has no room for a record.
+var ErrBufferFull = errors.New("slogger: sink buffer full")
+
+// NetSinkOptions configures a NetSink.
+type NetSinkOptions struct {
+	// Network is "tcp", "udp" or any other network accepted by net.Dial. Defaults to "tcp".
+	Network string
+
+	// Address is the host:port to connect to.
+	Address string
+
+	// Framing selects how records are delimited. Defaults to FramingNewline.
+	Framing NetFraming
+
+	// BufferSize is the number of bytes buffered while disconnected. Records that do not
+	// fit are rejected with ErrBufferFull. Defaults to 1 MiB.
+	BufferSize int
+
+	// DeadLetter, if set, receives the records that find the buffer full or are still
+	// queued when Close gives up on the endpoint, instead of dropping them. See
+	// DeadLetter.Replay.
+	DeadLetter *DeadLetter
+
+	// Breaker, if set, stops connecting while the endpoint keeps failing, see
+	// CircuitBreaker; records are buffered meanwhile. It may be shared by several sinks
+	// talking to the same backend.
+	Breaker *CircuitBreaker
+
+	// DialTimeout bounds each connection attempt. Defaults to five seconds.
+	DialTimeout time.Duration
+
+	// MinBackoff and MaxBackoff bound the exponential delay between reconnection
+	// attempts. They default to 100ms and 30s.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// OnError, if not nil, receives connection and write failures.
+	OnError func(error)
+}
+
+// NetSink is an io.Writer shipping records to a TCP or UDP endpoint, the base for
+// shipping to Logstash, Vector or similar collectors. Records are buffered and sent from
+// a background goroutine which reconnects with exponential backoff, so a collector
+// restart does not block or fail logging until the buffer fills up. Call Close to send
+// the remaining records and stop it.
+type NetSink struct {
+	opts NetSinkOptions
+
+	mu     sync.Mutex
+	queue  [][]byte
+	queued int
+
+	kick chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// NewNetSink starts a sink with the given options. The first connection is made in the
+// background.
+func NewNetSink(opts NetSinkOptions) *NetSink {
+	if opts.Network == "" {
+		opts.Network = "tcp"
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 1 << 20
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 5 * time.Second
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = 100 * time.Millisecond
+	}
+	if opts.MaxBackoff < opts.MinBackoff {
+		opts.MaxBackoff = max(30*time.Second, opts.MinBackoff)
+	}
+
+	s := &NetSink{
+		opts: opts,
+		kick: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+// Write frames and queues one record.
+func (s *NetSink) Write(p []byte) (int, error) {
+	frame := s.frame(p)
+
+	s.mu.Lock()
+	if s.queued+len(frame) > s.opts.BufferSize {
+		s.mu.Unlock()
+		if s.opts.DeadLetter != nil {
+			if _, err := s.opts.DeadLetter.spool([][]byte{s.unframe(frame)}); err == nil {
+				return len(p), nil
+			}
+		}
+		return 0, ErrBufferFull
+	}
+	s.queue = append(s.queue, frame)
+	s.queued += len(frame)
+	s.mu.Unlock()
+
+	select {
+	case s.kick <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+// Close sends the queued records if the endpoint is reachable and stops the sink. Records
+// it could not send are spooled to the DeadLetter, if any.
+func (s *NetSink) Close() error {
+	var err error
+	s.once.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+
+		s.mu.Lock()
+		queue := s.queue
+		s.queue, s.queued = nil, 0
+		s.mu.Unlock()
+		if len(queue) == 0 || s.opts.DeadLetter == nil {
+			return
+		}
+		records := make([][]byte, len(queue))
+		for i, frame := range queue {
+			records[i] = s.unframe(frame)
+		}
+		_, err = s.opts.DeadLetter.spool(records)
+	})
+	return err
+}
+
+// frame copies p, without its trailing newline, into a frame.
+func (s *NetSink) frame(p []byte) []byte {
+	p = bytes.TrimSuffix(p, []byte{'\n'})
+	switch s.opts.Framing {
+	case FramingLength:
+		frame := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(p)), uint32(len(p)))
+		return append(frame, p...)
+	default:
+		frame := make([]byte, 0, len(p)+1)
+		return append(append(frame, p...), '\n')
+	}
+}
+
+// unframe returns the record of a frame.
+func (s *NetSink) unframe(frame []byte) []byte {
+	if s.opts.Framing == FramingLength {
+		return frame[4:]
+	}
+	return bytes.TrimSuffix(frame, []byte{'\n'})
+}
+
+func (s *NetSink) run() {
+	defer s.wg.Done()
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	backoff := s.opts.MinBackoff
+	for {
+		stopping := false
+		select {
+		case <-s.done:
+			stopping = true
+		case <-s.kick:
+		}
+
+		cb := s.opts.Breaker
+		for {
+			if conn == nil {
+				if cb != nil && !cb.allow() {
+					if stopping || !s.wait(backoff) {
+						return
+					}
+					backoff = min(2*backoff, s.opts.MaxBackoff)
+					continue
+				}
+				var err error
+				if conn, err = net.DialTimeout(s.opts.Network, s.opts.Address, s.opts.DialTimeout); err != nil {
+					conn = nil
+					if cb != nil {
+						cb.done(err)
+					}
+					s.report(fmt.Errorf("slogger: net sink: %w", err))
+					if stopping || !s.wait(backoff) {
+						return
+					}
+					backoff = min(2*backoff, s.opts.MaxBackoff)
+					continue
+				}
+				backoff = s.opts.MinBackoff
+			}
+
+			err := s.send(conn)
+			if cb != nil {
+				cb.done(err)
+			}
+			if err != nil {
+				s.report(fmt.Errorf("slogger: net sink: %w", err))
+				conn.Close()
+				conn = nil
+				continue
+			}
+			break
+		}
+		if stopping {
+			return
+		}
+	}
+}
+
+// send writes the queued frames to conn. A frame is removed from the queue only once it
+// was written, so nothing is lost when the connection breaks.
+func (s *NetSink) send(conn net.Conn) error {
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			return nil
+		}
+		frame := s.queue[0]
+		s.mu.Unlock()
+
+		if _, err := conn.Write(frame); err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		s.queue = s.queue[1:]
+		s.queued -= len(frame)
+		s.mu.Unlock()
+	}
+}
+
+// wait sleeps for d, reporting false when the sink is closed meanwhile.
+func (s *NetSink) wait(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-s.done:
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// report passes err to OnError.
+func (s *NetSink) report(err error) {
+	if s.opts.OnError != nil {
+		s.opts.OnError(err)
+	}
+}
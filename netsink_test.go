@@ -0,0 +1,111 @@
+package slogger
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// acceptOne returns everything written to the first connection accepted by ln.
+func acceptOne(ln net.Listener) <-chan string {
+	out := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			out <- ""
+			return
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		b, _ := io.ReadAll(conn)
+		out <- string(b)
+	}()
+	return out
+}
+
+func TestNetSinkFraming(t *testing.T) {
+	for _, tt := range []struct {
+		framing NetFraming
+		want    string
+	}{
+		{FramingNewline, "{\"msg\":\"a\"}\n{\"msg\":\"b\"}\n"},
+		{FramingLength, "\x00\x00\x00\x0b{\"msg\":\"a\"}\x00\x00\x00\x0b{\"msg\":\"b\"}"},
+	} {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+		got := acceptOne(ln)
+		s := NewNetSink(NetSinkOptions{Address: ln.Addr().String(), Framing: tt.framing})
+		s.Write([]byte(`{"msg":"a"}` + "\n"))
+		s.Write([]byte(`{"msg":"b"}`))
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if g := <-got; g != tt.want {
+			t.Errorf("framing %d: stream = %q, want %q", tt.framing, g, tt.want)
+		}
+	}
+}
+
+func TestNetSinkReconnect(t *testing.T) {
+	// Reserve an address with nothing listening on it yet.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	dialErrors := make(chan error, 100)
+	s := NewNetSink(NetSinkOptions{
+		Address:    addr,
+		BufferSize: 32,
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 20 * time.Millisecond,
+		OnError: func(err error) {
+			select {
+			case dialErrors <- err:
+			default:
+			}
+		},
+	})
+	if _, err := s.Write([]byte("buffered-while-down\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Write([]byte("does-not-fit-in-the-buffer\n")); !errors.Is(err, ErrBufferFull) {
+		t.Errorf("Write beyond BufferSize = %v, want ErrBufferFull", err)
+	}
+	select {
+	case <-dialErrors:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no connection error reported")
+	}
+
+	if ln, err = net.Listen("tcp", addr); err != nil {
+		s.Close()
+		t.Skipf("address taken meanwhile: %v", err)
+	}
+	defer ln.Close()
+	got := acceptOne(ln)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		queued := s.queued
+		s.mu.Unlock()
+		if queued == 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.Write([]byte("after-reconnect\n"))
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if g, want := <-got, "buffered-while-down\nafter-reconnect\n"; g != want {
+		t.Errorf("stream = %q, want %q", g, want)
+	}
+}
@@ -0,0 +1,214 @@
+package slogger
+
+import (
+	"io"
+	"log/slog"
+	"text/template"
+	"time"
+)
+
+// Option configures a PrettyHandler created with NewPrettyHandler.
+//
+// A PrettyHandlerOptions value is itself an Option that replaces every option applied
+// before it, so existing callers passing the options struct keep working.
+type Option interface {
+	apply(*PrettyHandlerOptions)
+}
+
+type optionFunc func(*PrettyHandlerOptions)
+
+func (f optionFunc) apply(o *PrettyHandlerOptions) { f(o) }
+
+func (o PrettyHandlerOptions) apply(dst *PrettyHandlerOptions) { *dst = o }
+
+// WithLevel sets the minimum level of records the handler emits.
+func WithLevel(level slog.Leveler) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.SlogOpts.Level = level
+	})
+}
+
+// WithTimeFormat sets the layout used to render timestamps: a time.Format layout or one of
+// the shortcuts TimeFormatRFC3339, TimeFormatRFC3339Nano, TimeFormatKitchen,
+// TimeFormatDateTime, TimeFormatUnix and TimeFormatUnixMilli.
+func WithTimeFormat(layout string) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.TimeFormat = layout
+	})
+}
+
+// WithUTC renders timestamps in UTC.
+func WithUTC() Option {
+	return WithLocation(time.UTC)
+}
+
+// WithLocation renders timestamps in the given time zone.
+func WithLocation(loc *time.Location) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.Location = loc
+	})
+}
+
+// WithZoneSuffix enables or disables printing the time zone abbreviation after the
+// timestamps of the pretty and text output.
+func WithZoneSuffix(enabled bool) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.ZoneSuffix = enabled
+	})
+}
+
+// WithColor enables or disables ANSI colors in the pretty output.
+func WithColor(enabled bool) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.NoColor = !enabled
+	})
+}
+
+// WithSource enables or disables the source columns.
+func WithSource(enabled bool) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.NoSource = !enabled
+		o.SlogOpts.AddSource = enabled
+	})
+}
+
+// WithIndent enables or disables indentation of the attributes in the pretty output.
+func WithIndent(enabled bool) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.NoIndent = !enabled
+	})
+}
+
+// WithFormat sets the output encoding.
+func WithFormat(format Format) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.Format = format
+	})
+}
+
+// WithGroupStyle sets how grouped attributes are rendered.
+func WithGroupStyle(style GroupStyle) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.GroupStyle = style
+	})
+}
+
+// WithReplaceAttr sets the ReplaceAttr callback applied to every attribute, see slog.HandlerOptions.
+func WithReplaceAttr(fn func(groups []string, a slog.Attr) slog.Attr) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.SlogOpts.ReplaceAttr = fn
+	})
+}
+
+// WithLayout renders the pretty and text output with a template, see ParseLayout.
+func WithLayout(layout *template.Template) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.Layout = layout
+	})
+}
+
+// WithPattern renders the pretty and text output with a format string, see ParsePattern.
+func WithPattern(p *Pattern) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.Pattern = p
+	})
+}
+
+// WithHiddenSegments leaves the given segments out of the pretty and text output, e.g.
+// WithHiddenSegments(SegmentTime, SegmentFunc) when the timestamp is added by the
+// environment. It applies to Segments, Pattern and Layout alike.
+func WithHiddenSegments(segments ...Segment) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.Hidden = append(o.Hidden, segments...)
+	})
+}
+
+// WithFullPath enables or disables rendering the full path of the calling file.
+func WithFullPath(enabled bool) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.FullPath = enabled
+	})
+}
+
+// WithLevelOptions renders records at or above level with opts applied on top of the
+// other handler options, e.g. a compact single line for info and debug but indented
+// attributes and full paths for errors:
+//
+//	slogger.NewPrettyHandler(os.Stdout,
+//		slogger.WithIndent(false),
+//		slogger.WithLevelOptions(slog.LevelError, slogger.WithIndent(true), slogger.WithFullPath(true)),
+//	)
+//
+// The minimum level of the handler is not affected.
+func WithLevelOptions(level slog.Level, opts ...Option) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.Levels = append(o.Levels, LevelOptions{Level: level, Options: opts})
+	})
+}
+
+// WithSplitOutput writes records at or above level to w instead of the handler output,
+// the stream classification many container platforms expect:
+//
+//	slogger.NewPrettyHandler(os.Stdout, slogger.WithSplitOutput(slog.LevelWarn, os.Stderr))
+//
+// Both outputs share the lock of the handler, so records never interleave.
+func WithSplitOutput(level slog.Level, w io.Writer) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.SplitOutput = w
+		o.SplitLevel = level
+	})
+}
+
+// WithContextAttrs logs the values found in the context of a record under the given
+// keys as attributes, next to the trace ID, for correlation fields set once per request
+// by middleware:
+//
+//	slogger.NewPrettyHandler(os.Stdout, slogger.WithContextAttrs(
+//		slogger.ContextAttr{Key: auth.UserKey, Name: "user-id"},
+//	))
+//
+// Keys without a value in the context are skipped.
+func WithContextAttrs(attrs ...ContextAttr) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.ContextAttrs = append(o.ContextAttrs, attrs...)
+	})
+}
+
+// WithContextKeys is WithContextAttrs for keys of this package, such as
+// RequestIDContextKey, each logged under its own name.
+func WithContextKeys(keys ...ContextKey) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		for _, k := range keys {
+			o.ContextAttrs = append(o.ContextAttrs, ContextAttr{Key: k, Name: string(k)})
+		}
+	})
+}
+
+// WithBaggage logs the baggage members of the context of a record with the given keys as
+// attributes under the same keys, so the correlation values set at the edge of a system,
+// such as a customer or tenant, appear in the logs of every service downstream. Only the
+// listed keys are logged: baggage comes from callers and may hold anything. See
+// SetBaggageFunc for OpenTelemetry baggage.
+func WithBaggage(keys ...string) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.BaggageKeys = append(o.BaggageKeys, keys...)
+	})
+}
+
+// WithGoroutineID logs the ID of the calling goroutine with every record under
+// GoroutineIDKey, to tell apart the output of concurrent workers or find the goroutines
+// of a deadlock in a stack dump. Reading the ID costs a short stack trace per record, paid
+// only when enabled. Behind an AsyncHandler, the ID is still that of the goroutine that
+// logged the record, not that of the worker.
+func WithGoroutineID(enabled bool) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.GoroutineID = enabled
+	})
+}
+
+// WithSegments sets the columns of the pretty and text output and their order.
+func WithSegments(segments ...Segment) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.Segments = segments
+	})
+}
@@ -0,0 +1,221 @@
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// otlpScope is the instrumentation scope reported for exported records.
+const otlpScope = "github.com/StasTolmachov/slogger"
+
+// encodeOTLP renders e as an OpenTelemetry LogRecord in the OTLP/JSON encoding.
+// OTLPExporter batches these records into export requests.
+func (h *PrettyHandler) encodeOTLP(e entry) ([]byte, error) {
+	fs := make(fields, 0, 8)
+	if e.time.Key != "" && e.time.Value.Kind() == slog.KindTime {
+		fs = append(fs, field{key: "timeUnixNano", value: strconv.FormatInt(e.time.Value.Time().UnixNano(), 10)})
+	}
+	fs = append(fs, field{key: "observedTimeUnixNano", value: strconv.FormatInt(time.Now().UnixNano(), 10)})
+	if l, ok := e.level.Value.Any().(slog.Level); ok && e.level.Key != "" {
+		fs = append(fs,
+			field{key: "severityNumber", value: otlpSeverity(l)},
+			field{key: "severityText", value: levelName(e.level.Value)},
+		)
+	}
+	if e.message.Key != "" {
+		fs = append(fs, field{key: "body", value: otlpValue(e.message.Value.String())})
+	}
+
+	attrs := make([]interface{}, 0, len(e.attrs)+3)
+	if src, ok := e.source.Value.Any().(*slog.Source); ok && e.source.Key != "" {
+		attrs = append(attrs,
+			otlpKeyValue("code.file.path", src.File),
+			otlpKeyValue("code.line.number", int64(src.Line)),
+			otlpKeyValue("code.function.name", src.Function),
+		)
+	}
+	span := e.attrs.has(SpanTraceIDKey)
+	for _, f := range e.attrs {
+		switch f.key {
+		case TraceIDKey:
+			if id, ok := f.value.(uuid.UUID); ok && !span {
+				fs = append(fs, field{key: "traceId", value: hex.EncodeToString(id[:])})
+				continue
+			}
+		case SpanTraceIDKey:
+			fs = append(fs, field{key: "traceId", value: f.value})
+			continue
+		case SpanIDKey:
+			fs = append(fs, field{key: "spanId", value: f.value})
+			continue
+		}
+		attrs = append(attrs, otlpKeyValue(f.key, f.value))
+	}
+	if len(attrs) > 0 {
+		fs = append(fs, field{key: "attributes", value: attrs})
+	}
+	return json.Marshal(fs)
+}
+
+// otlpSeverity maps a slog level to an OpenTelemetry SeverityNumber:
+// Debug→5, Info→9, Warn→13, Error→17, Fatal→21.
+func otlpSeverity(l slog.Level) int {
+	n := int(l) + 9
+	if n < 1 {
+		return 1
+	}
+	if n > 24 {
+		return 24
+	}
+	return n
+}
+
+// otlpKeyValue encodes an OTLP KeyValue.
+func otlpKeyValue(key string, v interface{}) fields {
+	return fields{{key: "key", value: key}, {key: "value", value: otlpValue(v)}}
+}
+
+// otlpValue encodes an OTLP AnyValue.
+func otlpValue(v interface{}) fields {
+	switch v := v.(type) {
+	case string:
+		return fields{{key: "stringValue", value: v}}
+	case bool:
+		return fields{{key: "boolValue", value: v}}
+	case int64:
+		return fields{{key: "intValue", value: strconv.FormatInt(v, 10)}}
+	case int:
+		return fields{{key: "intValue", value: strconv.Itoa(v)}}
+	case uint64:
+		return fields{{key: "intValue", value: strconv.FormatUint(v, 10)}}
+	case float64:
+		return fields{{key: "doubleValue", value: v}}
+	case time.Time:
+		return fields{{key: "stringValue", value: v.Format(time.RFC3339Nano)}}
+	case time.Duration:
+		return fields{{key: "stringValue", value: v.String()}}
+	case error:
+		return fields{{key: "stringValue", value: v.Error()}}
+	case fields:
+		kvs := make([]interface{}, 0, len(v))
+		for _, f := range v {
+			kvs = append(kvs, otlpKeyValue(f.key, f.value))
+		}
+		return fields{{key: "kvlistValue", value: fields{{key: "values", value: kvs}}}}
+	case fmt.Stringer:
+		return fields{{key: "stringValue", value: v.String()}}
+	}
+	return fields{{key: "stringValue", value: string(marshalValue(v))}}
+}
+
+// OTLPOptions configures an OTLPExporter.
+type OTLPOptions struct {
+	// Endpoint is the OTLP/HTTP logs URL. Defaults to http://localhost:4318/v1/logs.
+	Endpoint string
+
+	// Headers are added to every request, for example for authentication.
+	Headers map[string]string
+
+	// Resource lists the resource attributes, such as service.name, of every record.
+	Resource []slog.Attr
+
+	// BatchSize is the number of records sent per request. Defaults to 512.
+	BatchSize int
+
+	// FlushInterval is the longest a record waits before being sent. Defaults to one second.
+	FlushInterval time.Duration
+
+	// MaxInFlight, MaxRetries, MaxBuffered, DeadLetter and Breaker tune delivery as
+	// documented on WebhookOptions. Network errors, 429 and 5xx responses are retried
+	// with backoff.
+	MaxInFlight int
+	MaxRetries  int
+	MaxBuffered int
+	DeadLetter  *DeadLetter
+	Breaker     *CircuitBreaker
+
+	// Client sends the requests. Defaults to a client with a ten second timeout.
+	Client *http.Client
+
+	// OnError, if not nil, receives failed deliveries.
+	OnError func(error)
+}
+
+// OTLPExporter is an io.Writer that ships records encoded by FormatOTLP to an OpenTelemetry
+// collector using OTLP/HTTP with JSON encoding. Records are batched and sent from
+// background goroutines; call Close to send the remaining records and stop them.
+type OTLPExporter struct {
+	*batcher
+	opts     OTLPOptions
+	resource []interface{}
+}
+
+// NewOTLPHandler creates a handler exporting records to an OpenTelemetry collector and
+// returns the exporter so it can be closed on shutdown. Handler options are applied as
+// for NewPrettyHandler.
+func NewOTLPHandler(otlp OTLPOptions, opts ...Option) (*PrettyHandler, *OTLPExporter) {
+	exp := NewOTLPExporter(otlp)
+	return NewPrettyHandler(exp, append(opts, WithFormat(FormatOTLP))...), exp
+}
+
+// NewOTLPExporter starts an exporter with the given options.
+func NewOTLPExporter(opts OTLPOptions) *OTLPExporter {
+	if opts.Endpoint == "" {
+		opts.Endpoint = "http://localhost:4318/v1/logs"
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 512
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	e := &OTLPExporter{opts: opts}
+	for _, a := range opts.Resource {
+		e.resource = append(e.resource, otlpKeyValue(a.Key, a.Value.Resolve().Any()))
+	}
+	e.batcher = newBatcher(batchOptions{
+		size:        opts.BatchSize,
+		interval:    opts.FlushInterval,
+		maxInFlight: opts.MaxInFlight,
+		maxRetries:  opts.MaxRetries,
+		maxBuffered: opts.MaxBuffered,
+		deadLetter:  opts.DeadLetter,
+		breaker:     opts.Breaker,
+		onError:     opts.OnError,
+		send:        e.send,
+	})
+	return e
+}
+
+// send posts one ExportLogsServiceRequest.
+func (e *OTLPExporter) send(ctx context.Context, records [][]byte) error {
+	logRecords := make([]json.RawMessage, len(records))
+	for i, rec := range records {
+		logRecords[i] = bytes.TrimSpace(rec)
+	}
+	body, err := json.Marshal(fields{{key: "resourceLogs", value: []interface{}{fields{
+		{key: "resource", value: fields{{key: "attributes", value: e.resource}}},
+		{key: "scopeLogs", value: []interface{}{fields{
+			{key: "scope", value: fields{{key: "name", value: otlpScope}}},
+			{key: "logRecords", value: logRecords},
+		}}},
+	}}}})
+	if err != nil {
+		return err
+	}
+
+	if _, err := httpPost(ctx, e.opts.Client, e.opts.Endpoint, "application/json", e.opts.Headers, body, false); err != nil {
+		return fmt.Errorf("slogger: otlp export: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package slogger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Pattern is a compiled format string for the pretty and text output, see ParsePattern.
+type Pattern struct {
+	parts    []patternPart
+	override bool // some token has a color override and needs the uncolored text
+}
+
+// patternPart is either a literal or a segment, optionally with its own color.
+type patternPart struct {
+	literal string
+	segment Segment
+	token   bool
+	color   *color.Attribute // overrides the default color when set
+	plain   bool             // renders the segment without any color
+}
+
+// patternTokens maps the pattern verbs to the segments they render.
+var patternTokens = map[byte]Segment{
+	't': SegmentTime,
+	'l': SegmentLevel,
+	'm': SegmentMessage,
+	'f': SegmentFunc,
+	'c': SegmentSource,
+	'a': SegmentAttrs,
+}
+
+// patternColors lists the color names accepted in token overrides.
+var patternColors = map[string]color.Attribute{
+	"black":   color.FgBlack,
+	"red":     color.FgRed,
+	"green":   color.FgGreen,
+	"yellow":  color.FgYellow,
+	"blue":    color.FgBlue,
+	"magenta": color.FgMagenta,
+	"cyan":    color.FgCyan,
+	"white":   color.FgWhite,
+	"gray":    color.FgHiBlack,
+	"bold":    color.Bold,
+}
+
+// ParsePattern compiles a compact format string such as
+//
+//	%t %l %c > %m %a
+//
+// for use with WithPattern. The verbs are %t (time), %l (level), %m (message), %f (calling
+// function), %c (calling file:line) and %a (attributes); %% is a literal percent sign.
+// A verb may carry a color override in braces, e.g. %{gray}t or %{none}l, using one of
+// black, red, green, yellow, blue, magenta, cyan, white, gray, bold or none.
+// The pattern is compiled once, so rendering a record only concatenates its parts.
+func ParsePattern(s string) (*Pattern, error) {
+	p := &Pattern{}
+	var lit strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			lit.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return nil, fmt.Errorf("slogger: pattern %q ends with %%", s)
+		}
+		if s[i] == '%' {
+			lit.WriteByte('%')
+			continue
+		}
+
+		part := patternPart{token: true}
+		if s[i] == '{' {
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("slogger: pattern %q has an unclosed color", s)
+			}
+			name := strings.ToLower(s[i+1 : i+end])
+			if name == "none" {
+				part.plain = true
+			} else if attr, ok := patternColors[name]; ok {
+				part.color = &attr
+			} else {
+				return nil, fmt.Errorf("slogger: pattern %q has unknown color %q", s, name)
+			}
+			p.override = true
+			i += end + 1
+			if i == len(s) {
+				return nil, fmt.Errorf("slogger: pattern %q ends with a color", s)
+			}
+		}
+
+		seg, ok := patternTokens[s[i]]
+		if !ok {
+			return nil, fmt.Errorf("slogger: pattern %q has unknown verb %%%c", s, s[i])
+		}
+		part.segment = seg
+		if lit.Len() > 0 {
+			p.parts = append(p.parts, patternPart{literal: lit.String()})
+			lit.Reset()
+		}
+		p.parts = append(p.parts, part)
+	}
+	if lit.Len() > 0 {
+		p.parts = append(p.parts, patternPart{literal: lit.String()})
+	}
+	return p, nil
+}
+
+// MustParsePattern is like ParsePattern but panics on error. It is meant for patterns
+// known at compile time.
+func MustParsePattern(s string) *Pattern {
+	p, err := ParsePattern(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// render executes the pattern over the colored segments d. plain holds the same segments
+// without colors and is only used when the pattern has color overrides.
+func (p *Pattern) render(h *PrettyHandler, d, plain LayoutData) string {
+	var b strings.Builder
+	for _, part := range p.parts {
+		switch {
+		case !part.token:
+			b.WriteString(part.literal)
+		case part.plain:
+			b.WriteString(plain.segment(part.segment))
+		case part.color != nil:
+			if text := plain.segment(part.segment); text != "" {
+				b.WriteString(h.colorize(*part.color, text))
+			}
+		default:
+			b.WriteString(d.segment(part.segment))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,178 @@
+package slogger
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PostgresOptions configures a PostgresSink.
+type PostgresOptions struct {
+	// Table receives the records, optionally qualified with a schema as in "audit.logs".
+	// It is created with indices on time and attrs if it does not exist. Defaults to
+	// "logs".
+	Table string
+
+	// Copy loads batches with COPY FROM STDIN through a prepared statement, the fastest
+	// way to insert many rows. It requires a driver supporting COPY in database/sql, such
+	// as github.com/lib/pq; without it batches use multi-row INSERT statements.
+	Copy bool
+
+	// BatchSize, FlushInterval, MaxInFlight, MaxRetries, MaxBuffered, DeadLetter and Breaker
+	// tune batching as documented on WebhookOptions. Each batch is inserted in one
+	// transaction.
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxInFlight   int
+	MaxRetries    int
+	MaxBuffered   int
+	DeadLetter    *DeadLetter
+	Breaker       *CircuitBreaker
+
+	// OnError, if not nil, receives failed inserts.
+	OnError func(error)
+}
+
+// PostgresSink is an io.Writer inserting the JSON records written by a FormatJSON handler
+// into a PostgreSQL table with the columns id, time (timestamptz), level, msg, source
+// ("file:line") and attrs (jsonb), for example to keep audit logs next to the data they
+// describe:
+//
+//	SELECT time, msg FROM logs WHERE attrs @> '{"channel": "audit"}'
+//
+// Call Close to insert the remaining records; the database is left open.
+type PostgresSink struct {
+	*batcher
+	db      *sql.DB
+	opts    PostgresOptions
+	table   string
+	columns string
+}
+
+// NewPostgresHandler creates a JSON handler inserting records into db and returns the
+// sink so it can be closed on shutdown. Handler options are applied as for
+// NewPrettyHandler.
+func NewPostgresHandler(db *sql.DB, pg PostgresOptions, opts ...Option) (*PrettyHandler, *PostgresSink, error) {
+	s, err := NewPostgresSink(db, pg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewPrettyHandler(s, append(opts, WithFormat(FormatJSON), WithTimeFormat(TimeFormatRFC3339Nano))...), s, nil
+}
+
+// NewPostgresSink creates the table if needed and starts a sink writing to it. db is
+// opened with the PostgreSQL driver of your choice.
+func NewPostgresSink(db *sql.DB, opts PostgresOptions) (*PostgresSink, error) {
+	if opts.Table == "" {
+		opts.Table = "logs"
+	}
+	parts := strings.Split(opts.Table, ".")
+	quoted := make([]string, len(parts))
+	for i, p := range parts {
+		quoted[i] = sqlIdent(p)
+	}
+	table := strings.Join(quoted, ".")
+	name := parts[len(parts)-1]
+
+	schema := []string{
+		`CREATE TABLE IF NOT EXISTS ` + table + ` (
+	id BIGSERIAL PRIMARY KEY,
+	time TIMESTAMPTZ NOT NULL,
+	level TEXT NOT NULL,
+	msg TEXT NOT NULL,
+	source TEXT,
+	attrs JSONB
+)`,
+		`CREATE INDEX IF NOT EXISTS ` + sqlIdent(name+"_time") + ` ON ` + table + ` (time)`,
+		`CREATE INDEX IF NOT EXISTS ` + sqlIdent(name+"_attrs") + ` ON ` + table + ` USING GIN (attrs)`,
+	}
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, fmt.Errorf("slogger: postgres schema: %w", err)
+		}
+	}
+
+	s := &PostgresSink{db: db, opts: opts, table: table, columns: `(time, level, msg, source, attrs)`}
+	s.batcher = newBatcher(batchOptions{
+		size:        opts.BatchSize,
+		interval:    opts.FlushInterval,
+		maxInFlight: opts.MaxInFlight,
+		maxRetries:  opts.MaxRetries,
+		maxBuffered: opts.MaxBuffered,
+		deadLetter:  opts.DeadLetter,
+		breaker:     opts.Breaker,
+		onError:     opts.OnError,
+		send:        s.send,
+	})
+	return s, nil
+}
+
+// postgresMaxRows bounds the rows of one INSERT statement, keeping it well below the
+// limit of 65535 parameters.
+const postgresMaxRows = 1000
+
+// send inserts one batch in a transaction. A failed transaction is rolled back and
+// retried.
+func (s *PostgresSink) send(ctx context.Context, records [][]byte) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return &retryError{err: fmt.Errorf("slogger: postgres insert: %w", err)}
+	}
+	defer tx.Rollback()
+
+	if s.opts.Copy {
+		err = s.copy(ctx, tx, records)
+	} else {
+		for len(records) > 0 && err == nil {
+			n := min(len(records), postgresMaxRows)
+			err = s.insert(ctx, tx, records[:n])
+			records = records[n:]
+		}
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		return &retryError{err: fmt.Errorf("slogger: postgres insert: %w", err)}
+	}
+	return nil
+}
+
+// insert adds records with a single multi-row INSERT statement.
+func (s *PostgresSink) insert(ctx context.Context, tx *sql.Tx, records [][]byte) error {
+	var b strings.Builder
+	b.WriteString(`INSERT INTO ` + s.table + ` ` + s.columns + ` VALUES `)
+	args := make([]interface{}, 0, 5*len(records))
+	for i, rec := range records {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		n := len(args)
+		b.WriteString("($" + strconv.Itoa(n+1) + ", $" + strconv.Itoa(n+2) + ", $" + strconv.Itoa(n+3) +
+			", $" + strconv.Itoa(n+4) + ", $" + strconv.Itoa(n+5) + "::jsonb)")
+		row := parseLogRow(rec)
+		args = append(args, row.time, row.level, row.msg, row.source, row.attrs)
+	}
+	_, err := tx.ExecContext(ctx, b.String(), args...)
+	return err
+}
+
+// copy streams records with COPY FROM STDIN.
+func (s *PostgresSink) copy(ctx context.Context, tx *sql.Tx, records [][]byte) error {
+	stmt, err := tx.PrepareContext(ctx, `COPY `+s.table+` `+s.columns+` FROM STDIN`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, rec := range records {
+		row := parseLogRow(rec)
+		if _, err := stmt.ExecContext(ctx, row.time, row.level, row.msg, row.source, row.attrs); err != nil {
+			return err
+		}
+	}
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
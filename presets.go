@@ -0,0 +1,49 @@
+package slogger
+
+import (
+	"log/slog"
+	"os"
+	"time"
+)
+
+// NewDevelopment returns a logger suited for local development: colored pretty output
+// on stdout at Debug level with full source information. Additional options are applied
+// on top of the preset; pass WithLevel with an AtomicLevel to change the level at runtime.
+func NewDevelopment(opts ...Option) *slog.Logger {
+	preset := []Option{
+		WithFormat(FormatPretty),
+		WithLevel(NewAtomicLevel(slog.LevelDebug)),
+		WithColor(true),
+		WithSource(true),
+	}
+	return slog.New(NewPrettyHandler(os.Stdout, append(preset, opts...)...))
+}
+
+// NewProduction returns a logger suited for production: one compact JSON object per
+// record on stdout at Info level, without colors. Debug and Info records are sampled with
+// ProductionSampling. Additional options are applied on top of the preset; pass WithLevel
+// with an AtomicLevel to change the level at runtime.
+func NewProduction(opts ...Option) *slog.Logger {
+	preset := []Option{
+		WithFormat(FormatJSON),
+		WithLevel(NewAtomicLevel(slog.LevelInfo)),
+		WithColor(false),
+		WithSource(true),
+	}
+	h := NewPrettyHandler(os.Stdout, append(preset, opts...)...)
+	return slog.New(NewSamplingHandler(h, ProductionSampling()))
+}
+
+// ProductionSampling returns the sampling of NewProduction: in every second, the first
+// 100 Debug and Info records with a given message are kept, then one in 100. Warnings
+// and errors always pass.
+func ProductionSampling() SamplingOptions {
+	rule := SampleRule{First: 100, Thereafter: 100}
+	return SamplingOptions{
+		Rules: map[slog.Level]SampleRule{
+			slog.LevelDebug: rule,
+			slog.LevelInfo:  rule,
+		},
+		Tick: time.Second,
+	}
+}
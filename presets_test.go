@@ -0,0 +1,39 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestPresetsKeepTheirOwnLevel(t *testing.T) {
+	before := DefaultLevel().Level()
+	prod := NewProduction()
+	dev := NewDevelopment()
+
+	ctx := context.Background()
+	if prod.Enabled(ctx, slog.LevelDebug) {
+		t.Error("NewDevelopment enabled Debug on a NewProduction logger")
+	}
+	if !dev.Enabled(ctx, slog.LevelDebug) {
+		t.Error("NewDevelopment logger does not log Debug")
+	}
+	if got := DefaultLevel().Level(); got != before {
+		t.Errorf("DefaultLevel = %v after building the presets, want %v", got, before)
+	}
+}
+
+func TestMakeLoggerSetsDefaultLevel(t *testing.T) {
+	prev := Default()
+	defer SetDefault(prev)
+	defer DefaultLevel().SetLevel(DefaultLevel().Level())
+
+	l := MakeLogger(true)
+	if got := DefaultLevel().Level(); got != slog.LevelDebug {
+		t.Errorf("DefaultLevel after MakeLogger(true) = %v, want DEBUG", got)
+	}
+	MakeLogger(false)
+	if l.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("MakeLogger(false) left an earlier MakeLogger logger at Debug")
+	}
+}
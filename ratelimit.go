@@ -0,0 +1,219 @@
+package slogger
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SuppressedKey is the attribute key under which summary records count the records
+// suppressed since the previous one.
+const SuppressedKey = "suppressed"
+
+// RateLimitOptions configures a RateLimitHandler.
+type RateLimitOptions struct {
+	// Interval is the window over which Burst records per key pass. Defaults to ten
+	// seconds.
+	Interval time.Duration
+
+	// Burst is the number of records per key and window. Defaults to 1.
+	Burst int
+
+	// Keys are attribute keys, dotted for attributes inside groups, whose values are
+	// added to the message to form the key, for example "host" to limit each host on its
+	// own. Empty keys the records by message alone.
+	Keys []string
+
+	// Key, if set, computes the key instead of the message and Keys. attrs holds the
+	// attributes of the record, including those bound with WithAttrs, by dotted key.
+	Key func(r slog.Record, attrs map[string]slog.Value) string
+
+	// MaxKeys bounds the number of keys tracked; the least recently used one is
+	// forgotten beyond it. Defaults to 10000.
+	MaxKeys int
+}
+
+// RateLimitHandler passes at most Burst records per key and Interval, such as one
+// "connection refused" per host every ten seconds:
+//
+//	h := slogger.NewRateLimitHandler(next, slogger.RateLimitOptions{Keys: []string{"host"}})
+//
+// The suppressed records aren't lost silently: when a key passes again after some were
+// suppressed, the last suppressed record is written first with a SuppressedKey attribute
+// counting them. Flush writes the pending summaries of all keys.
+type RateLimitHandler struct {
+	next   slog.Handler
+	s      *rateLimitState
+	prefix string
+	bound  []flatAttr
+}
+
+// rateLimitState is the state shared by a RateLimitHandler and the handlers derived
+// from it.
+type rateLimitState struct {
+	opts RateLimitOptions
+
+	mu      sync.Mutex
+	windows map[string]*list.Element // of *rateWindow, most recently used at the front
+	lru     *list.List
+}
+
+// rateWindow counts the records of one key in the current window.
+type rateWindow struct {
+	key        string
+	start      time.Time
+	n          int
+	suppressed int
+	last       asyncItem // the last suppressed record
+}
+
+// NewRateLimitHandler wraps next with the rate limit configured by opts.
+func NewRateLimitHandler(next slog.Handler, opts RateLimitOptions) *RateLimitHandler {
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+	if opts.Burst <= 0 {
+		opts.Burst = 1
+	}
+	if opts.MaxKeys <= 0 {
+		opts.MaxKeys = 10000
+	}
+	return &RateLimitHandler{next: next, s: &rateLimitState{
+		opts:    opts,
+		windows: make(map[string]*list.Element),
+		lru:     list.New(),
+	}}
+}
+
+// Flush writes a summary record for every key with suppressed records.
+func (h *RateLimitHandler) Flush(ctx context.Context) error {
+	h.s.mu.Lock()
+	var summaries []asyncItem
+	for e := h.s.lru.Front(); e != nil; e = e.Next() {
+		if w := e.Value.(*rateWindow); w.suppressed > 0 {
+			summaries = append(summaries, w.summary())
+		}
+	}
+	h.s.mu.Unlock()
+	return handleSummaries(summaries)
+}
+
+func (h *RateLimitHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *RateLimitHandler) Handle(ctx context.Context, r slog.Record) error {
+	key := h.key(r)
+	now := time.Now()
+
+	s := h.s
+	s.mu.Lock()
+	var summaries []asyncItem
+	var w *rateWindow
+	if e, ok := s.windows[key]; ok {
+		s.lru.MoveToFront(e)
+		w = e.Value.(*rateWindow)
+	} else {
+		if s.lru.Len() >= s.opts.MaxKeys {
+			oldest := s.lru.Back()
+			s.lru.Remove(oldest)
+			old := oldest.Value.(*rateWindow)
+			delete(s.windows, old.key)
+			if old.suppressed > 0 {
+				summaries = append(summaries, old.summary())
+			}
+		}
+		w = &rateWindow{key: key, start: now}
+		s.windows[key] = s.lru.PushFront(w)
+	}
+	if now.Sub(w.start) >= s.opts.Interval {
+		if w.suppressed > 0 {
+			summaries = append(summaries, w.summary())
+		}
+		w.start, w.n = now, 0
+	}
+	w.n++
+	pass := w.n <= s.opts.Burst
+	if !pass {
+		w.suppressed++
+		// The summary may be written after Handle returns, so the record must not share
+		// the caller's attribute storage.
+		w.last = asyncItem{ctx: ctx, h: h.next, r: r.Clone()}
+	}
+	s.mu.Unlock()
+
+	err := handleSummaries(summaries)
+	if pass {
+		err = errors.Join(err, h.next.Handle(ctx, r))
+	}
+	return err
+}
+
+func (h *RateLimitHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &RateLimitHandler{
+		next:   h.next.WithAttrs(attrs),
+		s:      h.s,
+		prefix: h.prefix,
+		bound:  bindFlatAttrs(h.bound, h.prefix, attrs),
+	}
+}
+
+func (h *RateLimitHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &RateLimitHandler{
+		next:   h.next.WithGroup(name),
+		s:      h.s,
+		prefix: h.prefix + name + ".",
+		bound:  h.bound,
+	}
+}
+
+// key returns the rate limiting key of r.
+func (h *RateLimitHandler) key(r slog.Record) string {
+	o := h.s.opts
+	if o.Key == nil && len(o.Keys) == 0 {
+		return r.Message
+	}
+	attrs := recordAttrs(h.bound, h.prefix, r)
+	if o.Key != nil {
+		return o.Key(r, attrs)
+	}
+	var b strings.Builder
+	b.WriteString(r.Message)
+	for _, k := range o.Keys {
+		b.WriteByte(0)
+		if v, ok := attrs[k]; ok {
+			b.WriteString(v.String())
+		}
+	}
+	return b.String()
+}
+
+// summary returns the last suppressed record of w counting the suppressed records, and
+// resets the count. The state lock must be held.
+func (w *rateWindow) summary() asyncItem {
+	item := w.last
+	item.r.AddAttrs(slog.Int(SuppressedKey, w.suppressed))
+	w.suppressed, w.last = 0, asyncItem{}
+	return item
+}
+
+// handleSummaries writes summary records to the handlers they were logged with.
+func handleSummaries(items []asyncItem) error {
+	var errs []error
+	for _, item := range items {
+		if !item.h.Enabled(item.ctx, item.r.Level) {
+			continue
+		}
+		if err := handleIsolated(item.ctx, item.h, item.r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
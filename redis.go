@@ -0,0 +1,287 @@
+package slogger
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedisOptions configures a RedisSink.
+type RedisOptions struct {
+	// URL is the server address, for example "redis://:secret@cache:6379/2" to
+	// authenticate and select database 2. Defaults to redis://localhost:6379.
+	URL string
+
+	// Stream is the key of the stream receiving the records. Defaults to "logs".
+	Stream string
+
+	// Field names the stream entry field holding the JSON record. Defaults to "record".
+	Field string
+
+	// MaxLen caps the stream at about this many entries, trimming the oldest ones as new
+	// ones are added (MAXLEN ~). Zero leaves the stream unbounded.
+	MaxLen int64
+
+	// Timeout bounds connecting and each batch. Defaults to five seconds.
+	Timeout time.Duration
+
+	// BatchSize, FlushInterval, MaxRetries, MaxBuffered, DeadLetter and Breaker tune
+	// batching as documented on WebhookOptions. Each batch is sent as one pipeline over a
+	// single connection.
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxRetries    int
+	MaxBuffered   int
+	DeadLetter    *DeadLetter
+	Breaker       *CircuitBreaker
+
+	// OnError, if not nil, receives failed deliveries.
+	OnError func(error)
+}
+
+// RedisSink is an io.Writer appending the JSON records written by a FormatJSON handler to
+// a Redis stream with XADD, a lightweight central buffer that another process drains
+// with XREAD or consumer groups. Call Close to send the remaining records.
+type RedisSink struct {
+	*batcher
+	opts RedisOptions
+
+	mu   sync.Mutex // guards conn for Close
+	conn *redisConn
+}
+
+// redisConn is a connected and authenticated client.
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewRedisHandler creates a JSON handler appending records to a Redis stream and returns
+// the sink so it can be closed on shutdown. Handler options are applied as for
+// NewPrettyHandler.
+func NewRedisHandler(redis RedisOptions, opts ...Option) (*PrettyHandler, *RedisSink) {
+	s := NewRedisSink(redis)
+	return NewPrettyHandler(s, append(opts, WithFormat(FormatJSON))...), s
+}
+
+// NewRedisSink starts a sink with the given options. The server is contacted with the
+// first batch.
+func NewRedisSink(opts RedisOptions) *RedisSink {
+	if opts.URL == "" {
+		opts.URL = "redis://localhost:6379"
+	}
+	if opts.Stream == "" {
+		opts.Stream = "logs"
+	}
+	if opts.Field == "" {
+		opts.Field = "record"
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	s := &RedisSink{opts: opts}
+	s.batcher = newBatcher(batchOptions{
+		size:        opts.BatchSize,
+		interval:    opts.FlushInterval,
+		maxInFlight: 1,
+		maxRetries:  opts.MaxRetries,
+		maxBuffered: opts.MaxBuffered,
+		deadLetter:  opts.DeadLetter,
+		breaker:     opts.Breaker,
+		onError:     opts.OnError,
+		send:        s.send,
+	})
+	return s
+}
+
+// Close sends the remaining records and closes the connection.
+func (s *RedisSink) Close() error {
+	err := s.batcher.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		s.conn.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+// send appends one batch with pipelined XADD commands.
+func (s *RedisSink) send(ctx context.Context, records [][]byte) error {
+	c, err := s.connect(ctx)
+	if err != nil {
+		return &retryError{err: fmt.Errorf("slogger: redis connect: %w", err)}
+	}
+
+	args := []string{"XADD", s.opts.Stream}
+	if s.opts.MaxLen > 0 {
+		args = append(args, "MAXLEN", "~", strconv.FormatInt(s.opts.MaxLen, 10))
+	}
+	args = append(args, "*", s.opts.Field, "")
+
+	w := bufio.NewWriter(c.conn)
+	for _, rec := range records {
+		args[len(args)-1] = string(rec)
+		writeRESP(w, args)
+	}
+
+	c.conn.SetDeadline(time.Now().Add(s.opts.Timeout))
+	if err := w.Flush(); err != nil {
+		s.drop(c)
+		return &retryError{err: fmt.Errorf("slogger: redis xadd: %w", err)}
+	}
+	var rejected int
+	var first error
+	for range records {
+		if err := c.reply(); err != nil {
+			var re redisError
+			if !errors.As(err, &re) {
+				s.drop(c)
+				return &retryError{err: fmt.Errorf("slogger: redis xadd: %w", err)}
+			}
+			if rejected++; first == nil {
+				first = err
+			}
+		}
+	}
+	if rejected > 0 && s.opts.OnError != nil {
+		s.opts.OnError(fmt.Errorf("slogger: redis xadd: %d records rejected: %w", rejected, first))
+	}
+	return nil
+}
+
+// connect returns the current connection or dials a new one, authenticating and
+// selecting the database given in the URL.
+func (s *RedisSink) connect(ctx context.Context) (*redisConn, error) {
+	s.mu.Lock()
+	c := s.conn
+	s.mu.Unlock()
+	if c != nil {
+		return c, nil
+	}
+
+	u, err := url.Parse(s.opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	d := net.Dialer{Timeout: s.opts.Timeout}
+	conn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	c = &redisConn{conn: conn, r: bufio.NewReader(conn)}
+	conn.SetDeadline(time.Now().Add(s.opts.Timeout))
+
+	var cmds [][]string
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			if user := u.User.Username(); user != "" {
+				cmds = append(cmds, []string{"AUTH", user, pass})
+			} else {
+				cmds = append(cmds, []string{"AUTH", pass})
+			}
+		}
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" && db != "0" {
+		cmds = append(cmds, []string{"SELECT", db})
+	}
+	for _, cmd := range cmds {
+		w := bufio.NewWriter(conn)
+		writeRESP(w, cmd)
+		if err = w.Flush(); err == nil {
+			err = c.reply()
+		}
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s: %w", cmd[0], err)
+		}
+	}
+
+	s.mu.Lock()
+	s.conn = c
+	s.mu.Unlock()
+	return c, nil
+}
+
+// drop closes a failed connection so the next batch reconnects.
+func (s *RedisSink) drop(c *redisConn) {
+	c.conn.Close()
+	s.mu.Lock()
+	if s.conn == c {
+		s.conn = nil
+	}
+	s.mu.Unlock()
+}
+
+// writeRESP encodes a command as an array of bulk strings.
+func writeRESP(w *bufio.Writer, args []string) {
+	w.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, a := range args {
+		w.WriteString("$" + strconv.Itoa(len(a)) + "\r\n")
+		w.WriteString(a)
+		w.WriteString("\r\n")
+	}
+}
+
+// redisError is an error reply from the server.
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+// reply reads and discards one reply, returning error replies as redisError.
+func (c *redisConn) reply() error {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return errors.New("malformed reply")
+	}
+	switch line[0] {
+	case '-':
+		return redisError(line[1:])
+	case '+', ':':
+		return nil
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return fmt.Errorf("malformed reply %q", line)
+		}
+		if n < 0 {
+			return nil
+		}
+		_, err = io.CopyN(io.Discard, c.r, int64(n)+2)
+		return err
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return fmt.Errorf("malformed reply %q", line)
+		}
+		var first error
+		for range n {
+			err := c.reply()
+			var re redisError
+			if err != nil && !errors.As(err, &re) {
+				return err
+			}
+			if first == nil {
+				first = err
+			}
+		}
+		return first
+	}
+	return fmt.Errorf("malformed reply %q", line)
+}
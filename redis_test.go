@@ -0,0 +1,212 @@
+package slogger
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWriteRESP(t *testing.T) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	writeRESP(w, []string{"XADD", "logs", "MAXLEN", "~", "1000", "*", "record", "{\"msg\":\"a\\r\\nb\"}\r\n", ""})
+	w.Flush()
+	const want = "*9\r\n$4\r\nXADD\r\n$4\r\nlogs\r\n$6\r\nMAXLEN\r\n$1\r\n~\r\n$4\r\n1000\r\n$1\r\n*\r\n" +
+		"$6\r\nrecord\r\n$18\r\n{\"msg\":\"a\\r\\nb\"}\r\n\r\n$0\r\n\r\n"
+	if got := buf.String(); got != want {
+		t.Errorf("writeRESP = %q\nwant       %q", got, want)
+	}
+}
+
+func TestRedisReply(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want string // error, "" for none
+	}{
+		{in: "+OK\r\n"},
+		{in: ":1\r\n"},
+		{in: "$-1\r\n"},
+		{in: "$15\r\n1700000000000-0\r\n"},
+		{in: "$0\r\n\r\n"},
+		{in: "*-1\r\n"},
+		{in: "*2\r\n$3\r\nfoo\r\n*1\r\n:7\r\n"},
+		{in: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n", want: "redis: WRONGTYPE Operation against a key holding the wrong kind of value"},
+		{in: "*3\r\n+OK\r\n-ERR first\r\n-ERR second\r\n", want: "redis: ERR first"},
+		{in: "", want: "EOF"},
+		{in: "\r\n", want: "malformed reply"},
+		{in: "$x\r\n", want: `malformed reply "$x"`},
+		{in: "*x\r\n", want: `malformed reply "*x"`},
+		{in: "?1\r\n", want: `malformed reply "?1"`},
+		{in: "$5\r\nhel", want: "EOF"},
+		{in: "*2\r\n+OK\r\n", want: "EOF"},
+	} {
+		c := &redisConn{r: bufio.NewReader(strings.NewReader(tt.in))}
+		err := c.reply()
+		var got string
+		if err != nil {
+			got = err.Error()
+			var re redisError
+			if errors.As(err, &re) {
+				got = "redis: " + got
+			}
+		}
+		if got != tt.want {
+			t.Errorf("reply(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if err == nil {
+			if rest, _ := io.ReadAll(c.r); len(rest) > 0 {
+				t.Errorf("reply(%q) left %q unread", tt.in, rest)
+			}
+		}
+	}
+}
+
+// readRESP reads one command as sent by writeRESP.
+func readRESP(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "*"), "\r\n"))
+	if err != nil || line[0] != '*' {
+		return nil, fmt.Errorf("malformed command %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "$"), "\r\n"))
+		if err != nil || line[0] != '$' {
+			return nil, fmt.Errorf("malformed argument %q", line)
+		}
+		b := make([]byte, size+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		if string(b[size:]) != "\r\n" {
+			return nil, fmt.Errorf("argument %q not terminated with CRLF", b)
+		}
+		args[i] = string(b[:size])
+	}
+	return args, nil
+}
+
+// newFakeRedis serves one connection, answering each command with answer. It returns
+// the address of the server and a function stopping it and returning the commands read.
+func newFakeRedis(t *testing.T, answer func(args []string) string) (string, func() [][]string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cmds [][]string
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			args, err := readRESP(r)
+			if err != nil {
+				if err != io.EOF {
+					t.Errorf("fake redis: %v", err)
+				}
+				return
+			}
+			cmds = append(cmds, args)
+			if _, err := io.WriteString(conn, answer(args)); err != nil {
+				return
+			}
+		}
+	}()
+	stop := sync.OnceValue(func() [][]string {
+		ln.Close()
+		wg.Wait()
+		return cmds
+	})
+	t.Cleanup(func() { stop() })
+	return ln.Addr().String(), stop
+}
+
+func TestRedisSinkXAdd(t *testing.T) {
+	addr, stop := newFakeRedis(t, func(args []string) string {
+		switch {
+		case args[0] == "XADD" && strings.Contains(args[len(args)-1], `"msg":"refused"`):
+			return "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"
+		case args[0] == "XADD":
+			return "$15\r\n1700000000000-0\r\n"
+		}
+		return "+OK\r\n"
+	})
+
+	var errs []string
+	h, sink := NewRedisHandler(RedisOptions{
+		URL:     "redis://app:s3cret@" + addr + "/2",
+		Stream:  "app:logs",
+		MaxLen:  1000,
+		OnError: func(err error) { errs = append(errs, err.Error()) },
+	})
+	logger := slog.New(h)
+	logger.Info("stored")
+	logger.Info("refused")
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	cmds := stop()
+
+	var got []string
+	for _, args := range cmds {
+		if args[0] == "XADD" {
+			if !strings.HasPrefix(args[len(args)-1], "{") {
+				t.Errorf("XADD of %q", args[len(args)-1])
+			}
+			args = args[:len(args)-1]
+		}
+		got = append(got, strings.Join(args, " "))
+	}
+	want := []string{
+		"AUTH app s3cret",
+		"SELECT 2",
+		"XADD app:logs MAXLEN ~ 1000 * record",
+		"XADD app:logs MAXLEN ~ 1000 * record",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("commands:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if len(errs) != 1 || errs[0] != "slogger: redis xadd: 1 records rejected: WRONGTYPE Operation against a key holding the wrong kind of value" {
+		t.Errorf("errors = %q", errs)
+	}
+}
+
+func TestRedisSinkAuthFailure(t *testing.T) {
+	addr, _ := newFakeRedis(t, func(args []string) string {
+		return "-WRONGPASS invalid username-password pair or user is disabled.\r\n"
+	})
+	var errs []string
+	h, sink := NewRedisHandler(RedisOptions{
+		URL:        "redis://:wrong@" + addr,
+		MaxRetries: -1,
+		OnError:    func(err error) { errs = append(errs, err.Error()) },
+	})
+	slog.New(h).Info("lost")
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	const want = "slogger: redis connect: AUTH: WRONGPASS invalid username-password pair or user is disabled. (dropped 1 records)"
+	if len(errs) != 1 || errs[0] != want {
+		t.Errorf("errors = %q, want %q", errs, want)
+	}
+}
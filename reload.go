@@ -0,0 +1,290 @@
+package slogger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Reloader is a logger whose configuration can be replaced at runtime. Applying a new
+// Config swaps level, format and outputs atomically: records already being handled finish
+// on the old outputs before those are closed, and loggers derived with With or WithGroup
+// follow the new configuration.
+type Reloader struct {
+	handler *swapHandler
+	level   AtomicLevel
+}
+
+// NewReloader builds a Reloader from cfg.
+func NewReloader(cfg Config) (*Reloader, error) {
+	level := NewAtomicLevel(slog.LevelInfo)
+	h, closers, err := cfg.handler(level)
+	if err != nil {
+		return nil, err
+	}
+	return &Reloader{handler: newSwapHandler(h, closers), level: level}, nil
+}
+
+// Apply replaces the configuration. On error the previous configuration stays active.
+func (r *Reloader) Apply(cfg Config) error {
+	h, closers, err := cfg.handler(r.level)
+	if err != nil {
+		return err
+	}
+	return r.handler.swap(h, closers)
+}
+
+// Logger returns a logger that always writes with the current configuration.
+func (r *Reloader) Logger() *slog.Logger {
+	return slog.New(r.handler)
+}
+
+// Level returns the level of the Reloader. Apply sets it to the level of the new
+// configuration; in between it can be changed at runtime, for example with HandleSignals.
+func (r *Reloader) Level() AtomicLevel {
+	return r.level
+}
+
+// Handler returns a handler that always writes with the current configuration.
+func (r *Reloader) Handler() slog.Handler {
+	return r.handler
+}
+
+// Close closes the files opened by the current configuration. Records logged afterwards
+// are discarded, until Apply installs a new configuration.
+func (r *Reloader) Close() error {
+	return r.handler.close()
+}
+
+// ConfigWatcher keeps a Reloader in sync with a configuration file, reloading it
+// whenever the file changes.
+type ConfigWatcher struct {
+	*Reloader
+	path    string
+	watcher *fsnotify.Watcher
+	onError func(error)
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// WatchConfig loads the configuration at path and starts watching it for changes.
+// onError, if not nil, is called when a reload fails; the previous configuration stays active.
+func WatchConfig(path string, onError func(error)) (*ConfigWatcher, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReloader(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	// Watch the directory rather than the file so editors that replace the file keep working.
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		r.Close()
+		return nil, err
+	}
+
+	w := &ConfigWatcher{
+		Reloader: r,
+		path:     filepath.Clean(path),
+		watcher:  watcher,
+		onError:  onError,
+		done:     make(chan struct{}),
+	}
+	w.wg.Add(1)
+	go w.run()
+
+	return w, nil
+}
+
+// Reload reads the configuration file and applies it.
+func (w *ConfigWatcher) Reload() error {
+	cfg, err := LoadConfig(w.path)
+	if err != nil {
+		return err
+	}
+	return w.Apply(cfg)
+}
+
+// Reopen reloads the configuration, reopening its outputs. It implements Reopener.
+func (w *ConfigWatcher) Reopen() error {
+	return w.Reload()
+}
+
+// Close stops watching and closes the files opened by the current configuration.
+func (w *ConfigWatcher) Close() error {
+	close(w.done)
+	err := w.watcher.Close()
+	w.wg.Wait()
+	if cerr := w.Reloader.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (w *ConfigWatcher) run() {
+	defer w.wg.Done()
+	for {
+		select {
+		case <-w.done:
+			return
+		case ev, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(ev.Name) != w.path || !ev.Has(fsnotify.Write|fsnotify.Create) {
+				continue
+			}
+			if err := w.Reload(); err != nil {
+				w.reportError(err)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.reportError(err)
+		}
+	}
+}
+
+func (w *ConfigWatcher) reportError(err error) {
+	if w.onError != nil {
+		w.onError(err)
+	}
+}
+
+// swapHandler delegates to a handler that can be replaced at runtime.
+// Handlers derived with WithAttrs and WithGroup follow every swap.
+type swapHandler struct {
+	root  *atomic.Pointer[swapState]
+	goas  []groupOrAttrs
+	cache *atomic.Pointer[swapCache]
+}
+
+// swapState is one generation of a swapHandler. The read lock is held while a record is
+// handled so the outputs of a retired generation are only closed once it is idle.
+type swapState struct {
+	mu      sync.RWMutex
+	handler slog.Handler
+	closers []io.Closer
+	retired bool
+}
+
+// swapCache remembers the derived handler built for a given generation.
+type swapCache struct {
+	state   *swapState
+	handler slog.Handler
+}
+
+func newSwapHandler(h slog.Handler, closers []io.Closer) *swapHandler {
+	root := &atomic.Pointer[swapState]{}
+	root.Store(&swapState{handler: h, closers: closers})
+	return &swapHandler{root: root, cache: &atomic.Pointer[swapCache]{}}
+}
+
+// swap installs h as the current handler and retires the previous one.
+func (s *swapHandler) swap(h slog.Handler, closers []io.Closer) error {
+	old := s.root.Swap(&swapState{handler: h, closers: closers})
+	return old.retire()
+}
+
+// close retires the current handler, leaving one that discards everything in its place
+// so acquire always finds a live generation.
+func (s *swapHandler) close() error {
+	old := s.root.Swap(&swapState{handler: discardHandler{}})
+	return old.retire()
+}
+
+// retire waits for in-flight records and closes the outputs of the generation.
+func (st *swapState) retire() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.retired {
+		return nil
+	}
+	st.retired = true
+	return closeAll(st.closers)
+}
+
+// acquire returns the current generation with its read lock held.
+func (s *swapHandler) acquire() *swapState {
+	for {
+		st := s.root.Load()
+		st.mu.RLock()
+		if !st.retired {
+			return st
+		}
+		st.mu.RUnlock()
+	}
+}
+
+// current returns the handler of st with the groups and attributes of s applied.
+func (s *swapHandler) current(st *swapState) slog.Handler {
+	if len(s.goas) == 0 {
+		return st.handler
+	}
+	if c := s.cache.Load(); c != nil && c.state == st {
+		return c.handler
+	}
+	h := st.handler
+	for _, goa := range s.goas {
+		if goa.group != "" {
+			h = h.WithGroup(goa.group)
+		} else {
+			h = h.WithAttrs(goa.attrs)
+		}
+	}
+	s.cache.Store(&swapCache{state: st, handler: h})
+	return h
+}
+
+func (s *swapHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.root.Load().handler.Enabled(ctx, level)
+}
+
+func (s *swapHandler) Handle(ctx context.Context, r slog.Record) error {
+	st := s.acquire()
+	defer st.mu.RUnlock()
+	return s.current(st).Handle(ctx, r)
+}
+
+func (s *swapHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return s
+	}
+	return s.with(groupOrAttrs{attrs: attrs})
+}
+
+func (s *swapHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return s
+	}
+	return s.with(groupOrAttrs{group: name})
+}
+
+func (s *swapHandler) with(goa groupOrAttrs) *swapHandler {
+	goas := make([]groupOrAttrs, len(s.goas)+1)
+	copy(goas, s.goas)
+	goas[len(goas)-1] = goa
+	return &swapHandler{root: s.root, goas: goas, cache: &atomic.Pointer[swapCache]{}}
+}
+
+// discardHandler drops every record.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
@@ -0,0 +1,84 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReloaderLogAfterClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	r, err := NewReloader(Config{Outputs: []string{path}, Format: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := r.Logger().With("k", "v")
+	logger.Info("before")
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		logger.Info("after")
+		r.Logger().Error("after")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging after Close did not return")
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); !strings.Contains(got, "before") || strings.Contains(got, "after") {
+		t.Errorf("output = %q, want only the record logged before Close", got)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestReloaderLevel(t *testing.T) {
+	before := DefaultLevel().Level()
+	other, err := NewFromConfig(Config{Level: "warn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReloader(Config{Level: "info"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if err := r.Apply(Config{Level: "debug"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if got := r.Level().Level(); got != slog.LevelDebug {
+		t.Errorf("Level after Apply = %v, want DEBUG", got)
+	}
+	if !r.Logger().Enabled(ctx, slog.LevelDebug) {
+		t.Error("reloaded logger does not log Debug")
+	}
+	if other.Enabled(ctx, slog.LevelInfo) {
+		t.Error("Apply changed the level of an unrelated NewFromConfig logger")
+	}
+	if got := DefaultLevel().Level(); got != before {
+		t.Errorf("DefaultLevel = %v, want %v", got, before)
+	}
+
+	if err := r.Apply(Config{Level: "loud"}); err == nil {
+		t.Fatal("Apply of an invalid level succeeded")
+	}
+	if got := r.Level().Level(); got != slog.LevelDebug {
+		t.Errorf("Level after a failed Apply = %v, want DEBUG", got)
+	}
+}
@@ -0,0 +1,185 @@
+package slogger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// backup is a rotated file of a FileSink.
+type backup struct {
+	path    string
+	size    int64
+	modTime time.Time
+	info    os.FileInfo
+}
+
+// backups lists the rotated files of s, newest first. Only names produced by the sink
+// are considered, so unrelated files sharing the directory are never touched.
+func (s *FileSink) backups() []backup {
+	entries, err := os.ReadDir(filepath.Dir(s.path))
+	if err != nil {
+		return nil
+	}
+	var list []backup
+	for _, e := range entries {
+		if e.IsDir() || !s.isBackup(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		list = append(list, backup{path: filepath.Join(filepath.Dir(s.path), e.Name()), size: info.Size(), modTime: info.ModTime(), info: info})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].modTime.After(list[j].modTime) })
+	return list
+}
+
+// isBackup reports whether name is a backup of s: app.log.N or app-<stamp>[-N].log,
+// optionally compressed.
+func (s *FileSink) isBackup(name string) bool {
+	name = strings.TrimSuffix(name, gzipExt)
+	base := filepath.Base(s.path)
+	if rest, ok := strings.CutPrefix(name, base+"."); ok {
+		n, err := strconv.Atoi(rest)
+		return err == nil && n > 0
+	}
+
+	ext := filepath.Ext(base)
+	stamp, ok := strings.CutPrefix(name, strings.TrimSuffix(base, ext)+"-")
+	if !ok {
+		return false
+	}
+	if stamp, ok = strings.CutSuffix(stamp, ext); !ok {
+		return false
+	}
+	if isStamp(stamp) {
+		return true
+	}
+	if i := strings.LastIndexByte(stamp, '-'); i > 0 {
+		n, err := strconv.Atoi(stamp[i+1:])
+		return err == nil && n > 0 && isStamp(stamp[:i])
+	}
+	return false
+}
+
+// isStamp reports whether s is a timestamp or period name used in backup names.
+func isStamp(s string) bool {
+	for _, layout := range []string{backupTimeFormat, RotateHourly.layout(), RotateDaily.layout()} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// backupSize returns the total size of list.
+func backupSize(list []backup) int64 {
+	var total int64
+	for _, b := range list {
+		total += b.size
+	}
+	return total
+}
+
+// startMill starts the background goroutine compressing rotated files and applying the
+// retention policy after rotations.
+func (s *FileSink) startMill() {
+	s.millCh = make(chan struct{}, 1)
+	s.millDone = make(chan struct{})
+	go func() {
+		defer close(s.millDone)
+		for range s.millCh {
+			s.mill()
+		}
+	}()
+}
+
+// triggerMill schedules a retention pass. Passes requested while one is pending coalesce.
+func (s *FileSink) triggerMill() {
+	if s.millCh == nil {
+		return
+	}
+	select {
+	case s.millCh <- struct{}{}:
+	default:
+	}
+}
+
+// mill compresses new backups, then removes the backups exceeding MaxBackups, older
+// than MaxAge or, oldest first, not fitting into MaxTotalSize.
+func (s *FileSink) mill() {
+	if s.opts.Compress {
+		s.compressBackups()
+	}
+
+	var cutoff time.Time
+	if s.opts.MaxAge > 0 {
+		cutoff = s.now().Add(-s.opts.MaxAge)
+	}
+	s.bmu.Lock()
+	var expired []backup
+	for i, b := range s.backups() {
+		keep := s.opts.MaxBackups <= 0 || i < s.opts.MaxBackups
+		if !cutoff.IsZero() && b.modTime.Before(cutoff) {
+			keep = false
+		}
+		if !keep {
+			expired = append(expired, b)
+		}
+	}
+	s.bmu.Unlock()
+	for _, b := range expired {
+		s.removeBackup(b)
+	}
+
+	// Count what is left, removing the oldest backups while over the quota.
+	s.bmu.Lock()
+	list := s.backups()
+	s.bmu.Unlock()
+	total := backupSize(list)
+	if limit := s.opts.MaxTotalSize; limit > 0 && s.opts.QuotaPolicy == QuotaDeleteOldest {
+		for i := len(list) - 1; i >= 0 && total+s.used.Load() > limit; i-- {
+			if s.removeBackup(list[i]) {
+				total -= list[i].size
+			}
+		}
+	}
+	s.backed.Store(total)
+}
+
+// removeBackup passes b to BeforeRemove and deletes it, reporting whether it is gone.
+//
+// BeforeRemove is called without holding bmu, so a slow hook doesn't delay rotations and
+// the writes waiting for them. RotateIndex rotations may rename the backup meanwhile; it
+// is found again by identity before being removed.
+func (s *FileSink) removeBackup(b backup) bool {
+	if s.opts.BeforeRemove != nil {
+		if err := s.opts.BeforeRemove(b.path); err != nil {
+			s.report(err)
+			return false
+		}
+	}
+	s.bmu.Lock()
+	defer s.bmu.Unlock()
+	current := s.locate(b.path, b.info)
+	if current == "" {
+		return true
+	}
+	if err := os.Remove(current); err != nil && !os.IsNotExist(err) {
+		s.report(err)
+		return false
+	}
+	return true
+}
+
+// report passes a background error to OnError.
+func (s *FileSink) report(err error) {
+	if s.opts.OnError != nil {
+		s.opts.OnError(err)
+	}
+}
@@ -0,0 +1,220 @@
+package slogger
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync"
+)
+
+// RingOptions configures a RingHandler.
+type RingOptions struct {
+	// Size is the number of records kept per key. Defaults to 100.
+	Size int
+
+	// Level is the lowest level buffered. Defaults to slog.LevelDebug.
+	Level slog.Leveler
+
+	// PassLevel is the lowest level passed to the wrapped handler right away; records
+	// between Level and PassLevel are only buffered. Defaults to slog.LevelWarn.
+	PassLevel slog.Leveler
+
+	// DumpLevel is the lowest level writing the buffered records of its key before
+	// itself. Defaults to slog.LevelError.
+	DumpLevel slog.Leveler
+
+	// Key returns the buffer a record belongs to. Defaults to the trace-id of the
+	// context, or the ID of the logging goroutine when there is none.
+	Key func(ctx context.Context, r slog.Record) string
+
+	// MaxKeys bounds the number of buffers; the least recently used one is discarded
+	// beyond it. Defaults to 1000.
+	MaxKeys int
+}
+
+// RingHandler keeps the last records below PassLevel of every trace or goroutine in a
+// ring buffer instead of writing them, and writes them when an error occurs in the same
+// trace, so failures come with their debug context while the happy path costs a few
+// copies in memory:
+//
+//	h := slogger.NewRingHandler(slogger.NewPrettyHandler(os.Stdout), slogger.RingOptions{})
+//
+// The buffered records reach the wrapped handler even if it is not enabled for their
+// level, in their original order and with their original time: the level checks of the
+// handlers of this package, such as the Info level of the handler above, let the records
+// of a dump through. Handlers of other packages may still drop them.
+type RingHandler struct {
+	next slog.Handler
+	s    *ringState
+}
+
+// ringState is the state shared by a RingHandler and the handlers derived from it.
+type ringState struct {
+	opts RingOptions
+
+	mu    sync.Mutex
+	rings map[string]*list.Element // of *ring, most recently used at the front
+	lru   *list.List
+}
+
+// ring is the buffer of one key.
+type ring struct {
+	key     string
+	items   []asyncItem
+	head, n int
+}
+
+// NewRingHandler wraps next with per-key ring buffers.
+func NewRingHandler(next slog.Handler, opts RingOptions) *RingHandler {
+	if opts.Size <= 0 {
+		opts.Size = 100
+	}
+	if opts.Level == nil {
+		opts.Level = slog.LevelDebug
+	}
+	if opts.PassLevel == nil {
+		opts.PassLevel = slog.LevelWarn
+	}
+	if opts.DumpLevel == nil {
+		opts.DumpLevel = slog.LevelError
+	}
+	if opts.Key == nil {
+		opts.Key = ringKey
+	}
+	if opts.MaxKeys <= 0 {
+		opts.MaxKeys = 1000
+	}
+	return &RingHandler{next: next, s: &ringState{
+		opts:  opts,
+		rings: make(map[string]*list.Element),
+		lru:   list.New(),
+	}}
+}
+
+// dumpKey is the context key marking the records dumped by a RingHandler. It is
+// unexported so that only a RingHandler can let records through the level checks.
+type dumpKey struct{}
+
+// dumping reports whether ctx is that of a record dumped by a RingHandler, which level
+// checks let through.
+func dumping(ctx context.Context) bool {
+	d, _ := ctx.Value(dumpKey{}).(bool)
+	return d
+}
+
+// ringKey is the default key of RingOptions.
+func ringKey(ctx context.Context, _ slog.Record) string {
+	if id, ok := TraceIDFromContext(ctx); ok {
+		return id.String()
+	}
+	return "goroutine " + strconv.FormatUint(goroutineID(), 10)
+}
+
+// Len returns the number of records currently buffered.
+func (h *RingHandler) Len() int {
+	h.s.mu.Lock()
+	defer h.s.mu.Unlock()
+	n := 0
+	for e := h.s.lru.Front(); e != nil; e = e.Next() {
+		n += e.Value.(*ring).n
+	}
+	return n
+}
+
+// Reset discards the records buffered for key, for example when a request completes.
+func (h *RingHandler) Reset(key string) {
+	h.s.mu.Lock()
+	defer h.s.mu.Unlock()
+	if e, ok := h.s.rings[key]; ok {
+		h.s.lru.Remove(e)
+		delete(h.s.rings, key)
+	}
+}
+
+func (h *RingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.s.opts.Level.Level() || h.next.Enabled(ctx, level)
+}
+
+func (h *RingHandler) Handle(ctx context.Context, r slog.Record) error {
+	o := h.s.opts
+	if r.Level < o.PassLevel.Level() {
+		if r.Level >= o.Level.Level() {
+			// The record may be handled after Handle returns, so it must not share the
+			// caller's attribute storage.
+			h.s.add(o.Key(ctx, r), asyncItem{ctx: ctx, h: h.next, r: r.Clone()})
+		}
+		return nil
+	}
+
+	var errs []error
+	if r.Level >= o.DumpLevel.Level() {
+		for _, item := range h.s.take(o.Key(ctx, r)) {
+			if err := handleIsolated(context.WithValue(item.ctx, dumpKey{}, true), item.h, item.r); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if h.next.Enabled(ctx, r.Level) {
+		if err := h.next.Handle(ctx, r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *RingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &RingHandler{next: h.next.WithAttrs(attrs), s: h.s}
+}
+
+func (h *RingHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &RingHandler{next: h.next.WithGroup(name), s: h.s}
+}
+
+// add buffers item under key, overwriting the oldest record of a full buffer.
+func (s *ringState) add(key string, item asyncItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rg *ring
+	if e, ok := s.rings[key]; ok {
+		s.lru.MoveToFront(e)
+		rg = e.Value.(*ring)
+	} else {
+		if s.lru.Len() >= s.opts.MaxKeys {
+			oldest := s.lru.Back()
+			s.lru.Remove(oldest)
+			delete(s.rings, oldest.Value.(*ring).key)
+		}
+		rg = &ring{key: key, items: make([]asyncItem, s.opts.Size)}
+		s.rings[key] = s.lru.PushFront(rg)
+	}
+	if rg.n == len(rg.items) {
+		rg.items[rg.head] = item
+		rg.head = (rg.head + 1) % len(rg.items)
+		return
+	}
+	rg.items[(rg.head+rg.n)%len(rg.items)] = item
+	rg.n++
+}
+
+// take removes the buffer of key and returns its records, oldest first.
+func (s *ringState) take(key string) []asyncItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.rings[key]
+	if !ok {
+		return nil
+	}
+	s.lru.Remove(e)
+	delete(s.rings, key)
+	rg := e.Value.(*ring)
+	items := make([]asyncItem, rg.n)
+	for i := range items {
+		items[i] = rg.items[(rg.head+i)%len(rg.items)]
+	}
+	return items
+}
@@ -0,0 +1,58 @@
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRingHandlerDumpsBelowNextLevel(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		next func(*bytes.Buffer) slog.Handler
+	}{
+		{"pretty", func(buf *bytes.Buffer) slog.Handler {
+			return NewPrettyHandler(buf, WithFormat(FormatJSON), WithLevel(slog.LevelInfo))
+		}},
+		{"leveled", func(buf *bytes.Buffer) slog.Handler {
+			return Leveled(NewPrettyHandler(buf, WithFormat(FormatJSON), WithLevel(slog.LevelDebug)), slog.LevelWarn)
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(NewRingHandler(tc.next(&buf), RingOptions{}))
+
+			logger.Debug("step one")
+			logger.Info("step two")
+			if buf.Len() != 0 {
+				t.Fatalf("records written before the error: %q", buf.String())
+			}
+			logger.Error("failed")
+
+			var msgs []string
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				var rec struct{ Msg string }
+				if err := json.Unmarshal([]byte(line), &rec); err != nil {
+					t.Fatalf("invalid JSON line %q: %v", line, err)
+				}
+				msgs = append(msgs, rec.Msg)
+			}
+			if got, want := strings.Join(msgs, ","), "step one,step two,failed"; got != want {
+				t.Errorf("messages = %s, want %s\n%s", got, want, buf.String())
+			}
+		})
+	}
+}
+
+func TestRingDumpCannotBeForged(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewPrettyHandler(&buf, WithFormat(FormatJSON), WithLevel(slog.LevelInfo)))
+	ctx := context.WithValue(context.Background(), ContextKey("ring-dump"), true)
+	logger.DebugContext(ctx, "forged")
+	if buf.Len() != 0 {
+		t.Errorf("a context value of the caller let Debug through: %q", buf.String())
+	}
+}
@@ -0,0 +1,158 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Route directs the records matching it to Handler, see NewRouter.
+type Route struct {
+	// Key and Value match records with an attribute Key, dotted for attributes inside
+	// groups, whose value renders as Value. An empty Value matches any value of Key.
+	Key   string
+	Value string
+
+	// Match, if set, decides instead of Key and Value. attrs holds the attributes of the
+	// record, including those bound with WithAttrs, by dotted key.
+	Match func(r slog.Record, attrs map[string]slog.Value) bool
+
+	Handler slog.Handler
+}
+
+// matches reports whether the record r with the flattened attributes attrs matches rt.
+func (rt Route) matches(r slog.Record, attrs map[string]slog.Value) bool {
+	if rt.Match != nil {
+		return rt.Match(r, attrs)
+	}
+	v, ok := attrs[rt.Key]
+	return ok && (rt.Value == "" || v.String() == rt.Value)
+}
+
+// NewRouter returns a handler sending each record to the handler of the first route it
+// matches, and the records matching none to fallback, which may be nil to drop them:
+//
+//	slogger.NewRouter(app,
+//		slogger.Route{Key: "channel", Value: "audit", Handler: audit},
+//		slogger.Route{Key: "component", Value: "http", Handler: access},
+//	)
+//
+// A route handler built with Tee receives a record and also passes it on to others.
+func NewRouter(fallback slog.Handler, routes ...Route) slog.Handler {
+	return &routerHandler{fallback: fallback, routes: append([]Route(nil), routes...)}
+}
+
+type routerHandler struct {
+	fallback slog.Handler
+	routes   []Route
+	prefix   string
+	bound    []flatAttr
+}
+
+func (h *routerHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.fallback != nil && h.fallback.Enabled(ctx, level) {
+		return true
+	}
+	for _, rt := range h.routes {
+		if rt.Handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *routerHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := recordAttrs(h.bound, h.prefix, r)
+	next := h.fallback
+	for _, rt := range h.routes {
+		if rt.matches(r, attrs) {
+			next = rt.Handler
+			break
+		}
+	}
+	if next == nil || !next.Enabled(ctx, r.Level) && !dumping(ctx) {
+		return nil
+	}
+	return next.Handle(ctx, r)
+}
+
+func (h *routerHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := h.derive(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+	h2.bound = bindFlatAttrs(h.bound, h.prefix, attrs)
+	return h2
+}
+
+func (h *routerHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := h.derive(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+	h2.prefix = h.prefix + name + "."
+	return h2
+}
+
+// derive returns a copy of h with f applied to the fallback and every route handler.
+func (h *routerHandler) derive(f func(slog.Handler) slog.Handler) *routerHandler {
+	h2 := *h
+	if h.fallback != nil {
+		h2.fallback = f(h.fallback)
+	}
+	h2.routes = make([]Route, len(h.routes))
+	for i, rt := range h.routes {
+		rt.Handler = f(rt.Handler)
+		h2.routes[i] = rt
+	}
+	return &h2
+}
+
+// flatAttr is an attribute flattened to a dotted key.
+type flatAttr struct {
+	key   string
+	value slog.Value
+}
+
+// appendFlatAttr flattens a into dotted keys.
+func appendFlatAttr(attrs []flatAttr, prefix string, a slog.Attr) []flatAttr {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return attrs
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		p := prefix
+		if a.Key != "" {
+			p += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			attrs = appendFlatAttr(attrs, p, ga)
+		}
+		return attrs
+	}
+	return append(attrs, flatAttr{key: prefix + a.Key, value: a.Value})
+}
+
+// bindFlatAttrs returns bound followed by attrs flattened under prefix, leaving bound,
+// which derived handlers share, untouched.
+func bindFlatAttrs(bound []flatAttr, prefix string, attrs []slog.Attr) []flatAttr {
+	bound = bound[:len(bound):len(bound)]
+	for _, a := range attrs {
+		bound = appendFlatAttr(bound, prefix, a)
+	}
+	return bound
+}
+
+// recordAttrs returns the bound attributes and those of r flattened under prefix, by
+// dotted key.
+func recordAttrs(bound []flatAttr, prefix string, r slog.Record) map[string]slog.Value {
+	attrs := make(map[string]slog.Value, len(bound)+r.NumAttrs())
+	for _, a := range bound {
+		attrs[a.key] = a.value
+	}
+	var flat []flatAttr
+	r.Attrs(func(a slog.Attr) bool {
+		flat = appendFlatAttr(flat[:0], prefix, a)
+		for _, fa := range flat {
+			attrs[fa.key] = fa.value
+		}
+		return true
+	})
+	return attrs
+}
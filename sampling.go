@@ -0,0 +1,147 @@
+package slogger
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// SampleRule is the sampling of one level. The counting part keeps the First records
+// with a given message in each tick, then one in Thereafter; the probabilistic part then
+// keeps each remaining record with the given Probability.
+type SampleRule struct {
+	// First is the number of records per message and tick kept before sampling starts.
+	First int
+
+	// Thereafter keeps one in Thereafter records per message beyond First; zero drops
+	// them all. A rule with zero First and Thereafter does no counting.
+	Thereafter int
+
+	// Probability, if between 0 and 1 exclusive, is the chance of keeping a record.
+	Probability float64
+}
+
+// SamplingOptions configures a SamplingHandler.
+type SamplingOptions struct {
+	// Rules are the sampling of each level. Records of a level without a rule, such as
+	// errors in the usual setup, always pass.
+	Rules map[slog.Level]SampleRule
+
+	// Tick is the period over which First and Thereafter count. Defaults to one second.
+	Tick time.Duration
+}
+
+// SamplingHandler drops a share of high-volume records so they don't overwhelm the
+// outputs, keeping the first of each message in every tick so nothing disappears
+// entirely:
+//
+//	h := slogger.NewSamplingHandler(next, slogger.SamplingOptions{
+//		Rules: map[slog.Level]slogger.SampleRule{
+//			slog.LevelDebug: {Probability: 0.01},
+//			slog.LevelInfo:  {First: 100, Thereafter: 100},
+//		},
+//	})
+//
+// Messages are counted in a fixed table of hashed counters, so memory stays bounded
+// whatever the number of distinct messages, at the cost of rare collisions.
+type SamplingHandler struct {
+	next slog.Handler
+	s    *samplingState
+}
+
+// samplingCounters is the number of counters per level.
+const samplingCounters = 4096
+
+// samplingState is the state shared by a SamplingHandler and the handlers derived from it.
+type samplingState struct {
+	opts     SamplingOptions
+	counters map[slog.Level]*[samplingCounters]sampleCounter
+	dropped  atomic.Uint64
+}
+
+// sampleCounter counts the records of one message in the current tick.
+type sampleCounter struct {
+	resetAt atomic.Int64 // end of the current tick, in Unix nanoseconds
+	n       atomic.Uint64
+}
+
+// NewSamplingHandler wraps next with the sampling configured by opts.
+func NewSamplingHandler(next slog.Handler, opts SamplingOptions) *SamplingHandler {
+	if opts.Tick <= 0 {
+		opts.Tick = time.Second
+	}
+	s := &samplingState{opts: opts, counters: make(map[slog.Level]*[samplingCounters]sampleCounter)}
+	for level, rule := range opts.Rules {
+		if rule.First > 0 || rule.Thereafter > 0 {
+			s.counters[level] = new([samplingCounters]sampleCounter)
+		}
+	}
+	return &SamplingHandler{next: next, s: s}
+}
+
+// Dropped returns the number of records dropped by sampling.
+func (h *SamplingHandler) Dropped() uint64 {
+	return h.s.dropped.Load()
+}
+
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.s.keep(r) {
+		h.s.dropped.Add(1)
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &SamplingHandler{next: h.next.WithAttrs(attrs), s: h.s}
+}
+
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SamplingHandler{next: h.next.WithGroup(name), s: h.s}
+}
+
+// keep reports whether r passes the rule of its level.
+func (s *samplingState) keep(r slog.Record) bool {
+	rule, ok := s.opts.Rules[r.Level]
+	if !ok {
+		return true
+	}
+	if counters := s.counters[r.Level]; counters != nil {
+		hash := fnv.New32a()
+		hash.Write([]byte(r.Message))
+		n := counters[hash.Sum32()%samplingCounters].inc(r.Time, s.opts.Tick)
+		if n > uint64(rule.First) {
+			if rule.Thereafter <= 0 || (n-uint64(rule.First))%uint64(rule.Thereafter) != 0 {
+				return false
+			}
+		}
+	}
+	if rule.Probability > 0 && rule.Probability < 1 {
+		return rand.Float64() < rule.Probability
+	}
+	return true
+}
+
+// inc counts a record logged at t and returns its number in the current tick.
+func (c *sampleCounter) inc(t time.Time, tick time.Duration) uint64 {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	now := t.UnixNano()
+	resetAt := c.resetAt.Load()
+	if now > resetAt && c.resetAt.CompareAndSwap(resetAt, now+int64(tick)) {
+		c.n.Store(1)
+		return 1
+	}
+	return c.n.Add(1)
+}
@@ -0,0 +1,355 @@
+package slogger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SentryOptions configures a SentryHandler.
+type SentryOptions struct {
+	// DSN is the project key, as in https://<key>@o0.ingest.sentry.io/<project>.
+	DSN string
+
+	// Level is the minimum level of the records forwarded as events. Defaults to
+	// slog.LevelError.
+	Level slog.Leveler
+
+	// Environment, Release and ServerName describe the application. ServerName defaults
+	// to the host name.
+	Environment string
+	Release     string
+	ServerName  string
+
+	// TagKeys lists attribute keys sent as searchable tags instead of extra data. The
+	// trace-id is always a tag.
+	TagKeys []string
+
+	// FlushInterval, MaxRetries, MaxBuffered, DeadLetter and Breaker tune batching as
+	// documented on WebhookOptions. Rate limits and server errors are retried with backoff.
+	// Spooled events are replayed with DeadLetter.Replay(h.Events()).
+	FlushInterval time.Duration
+	MaxRetries    int
+	MaxBuffered   int
+	DeadLetter    *DeadLetter
+	Breaker       *CircuitBreaker
+
+	// Client sends the requests. Defaults to a client with a ten second timeout.
+	Client *http.Client
+
+	// OnError, if not nil, receives failed deliveries.
+	OnError func(error)
+}
+
+// SentryHandler passes every record to the wrapped handler and additionally forwards
+// records at or above a level to Sentry as events: the message, attributes as extra
+// data, the error and stack trace when present, and the trace-id as a tag. Events are
+// sent from background goroutines; call Close to send the remaining ones.
+type SentryHandler struct {
+	next   slog.Handler
+	client *sentryClient
+	prefix string
+	extra  []flatAttr
+}
+
+// sentryClient is the state shared by a SentryHandler and the handlers derived from it.
+type sentryClient struct {
+	*batcher
+	opts     SentryOptions
+	dsn      string
+	endpoint string
+	auth     string
+	tags     map[string]bool
+}
+
+// NewSentryHandler wraps next with Sentry forwarding.
+func NewSentryHandler(next slog.Handler, opts SentryOptions) (*SentryHandler, error) {
+	u, err := url.Parse(opts.DSN)
+	if err != nil || u.User == nil || u.Host == "" {
+		return nil, fmt.Errorf("slogger: sentry: invalid DSN %q", opts.DSN)
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	i := strings.LastIndexByte(path, '/')
+	project := path[i+1:]
+	if project == "" {
+		return nil, fmt.Errorf("slogger: sentry: no project in DSN %q", opts.DSN)
+	}
+	if opts.Level == nil {
+		opts.Level = slog.LevelError
+	}
+	if opts.ServerName == "" {
+		opts.ServerName = hostname()
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	c := &sentryClient{
+		opts:     opts,
+		dsn:      opts.DSN,
+		endpoint: u.Scheme + "://" + u.Host + path[:i] + "/api/" + project + "/envelope/",
+		auth:     "Sentry sentry_version=7, sentry_client=slogger, sentry_key=" + u.User.Username(),
+		tags:     make(map[string]bool, len(opts.TagKeys)),
+	}
+	for _, k := range opts.TagKeys {
+		c.tags[k] = true
+	}
+	c.batcher = newBatcher(batchOptions{
+		size:        50,
+		interval:    opts.FlushInterval,
+		maxRetries:  opts.MaxRetries,
+		maxBuffered: opts.MaxBuffered,
+		deadLetter:  opts.DeadLetter,
+		breaker:     opts.Breaker,
+		onError:     opts.OnError,
+		send:        c.send,
+	})
+	return &SentryHandler{next: next, client: c}, nil
+}
+
+// Flush sends the queued events and waits for them, or until ctx is done.
+func (h *SentryHandler) Flush(ctx context.Context) error {
+	return h.client.Flush(ctx)
+}
+
+// Events returns the writer queuing encoded events for Sentry, to replay the events
+// spooled to the DeadLetter.
+func (h *SentryHandler) Events() io.Writer {
+	return h.client.batcher
+}
+
+// Close sends the remaining events. The wrapped handler is not closed.
+func (h *SentryHandler) Close() error {
+	return h.client.Close()
+}
+
+func (h *SentryHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level) || level >= h.client.opts.Level.Level()
+}
+
+func (h *SentryHandler) Handle(ctx context.Context, r slog.Record) error {
+	var err error
+	if h.next.Enabled(ctx, r.Level) || dumping(ctx) {
+		err = h.next.Handle(ctx, r)
+	}
+	if r.Level >= h.client.opts.Level.Level() {
+		if b, merr := json.Marshal(h.event(ctx, r)); merr == nil {
+			h.client.Write(b)
+		}
+	}
+	return err
+}
+
+func (h *SentryHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.next = h.next.WithAttrs(attrs)
+	h2.extra = h.extra[:len(h.extra):len(h.extra)]
+	for _, a := range attrs {
+		h2.extra = appendFlatAttr(h2.extra, h.prefix, a)
+	}
+	return &h2
+}
+
+func (h *SentryHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.next = h.next.WithGroup(name)
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+// sentryLevel maps a slog level to a Sentry level.
+func sentryLevel(l slog.Level) string {
+	switch {
+	case l >= LevelFatal:
+		return "fatal"
+	case l >= slog.LevelError:
+		return "error"
+	case l >= slog.LevelWarn:
+		return "warning"
+	case l >= slog.LevelInfo:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+// event builds the Sentry event of a record.
+func (h *SentryHandler) event(ctx context.Context, r slog.Record) map[string]interface{} {
+	o := h.client.opts
+	id := uuid.New()
+	ts := r.Time
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	ev := map[string]interface{}{
+		"event_id":    strings.ReplaceAll(id.String(), "-", ""),
+		"timestamp":   ts.UTC().Format(time.RFC3339Nano),
+		"level":       sentryLevel(r.Level),
+		"platform":    "go",
+		"logger":      "slogger",
+		"server_name": o.ServerName,
+		"message":     map[string]string{"formatted": r.Message},
+	}
+	if o.Environment != "" {
+		ev["environment"] = o.Environment
+	}
+	if o.Release != "" {
+		ev["release"] = o.Release
+	}
+
+	attrs := h.extra
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendFlatAttr(attrs, h.prefix, a)
+		return true
+	})
+	tags := map[string]string{}
+	if id, ok := TraceIDFromContext(ctx); ok {
+		tags["trace_id"] = id.String()
+	}
+	extra := map[string]interface{}{}
+	var exception error
+	for _, a := range attrs {
+		v := a.value.Any()
+		switch {
+		case a.key == TraceIDKey:
+			tags["trace_id"] = fmt.Sprint(v)
+		case a.key == NameKey:
+			ev["logger"] = fmt.Sprint(v)
+		case h.client.tags[a.key]:
+			tags[a.key] = fmt.Sprint(v)
+		default:
+			if err, ok := v.(error); ok {
+				if exception == nil {
+					exception = err
+				}
+				extra[a.key] = err.Error()
+				if st, ok := err.(stackTracer); ok {
+					extra[a.key+".stack_trace"] = st.StackTrace()
+				}
+				continue
+			}
+			if a.value.Kind() == slog.KindTime {
+				v = a.value.Time().Format(time.RFC3339Nano)
+			}
+			extra[a.key] = v
+		}
+	}
+	if len(tags) > 0 {
+		ev["tags"] = tags
+	}
+	if len(extra) > 0 {
+		ev["extra"] = extra
+	}
+
+	stack := sentryStack(r.PC)
+	if exception != nil {
+		ev["exception"] = map[string]interface{}{"values": []map[string]interface{}{{
+			"type":       fmt.Sprintf("%T", exception),
+			"value":      exception.Error(),
+			"stacktrace": stack,
+		}}}
+	} else if stack != nil {
+		ev["threads"] = map[string]interface{}{"values": []map[string]interface{}{{
+			"current":    true,
+			"stacktrace": stack,
+		}}}
+	}
+	return ev
+}
+
+// sentryStack returns the stack of the goroutine from the logging call site outwards,
+// oldest frame first as Sentry expects, or nil without a call site.
+func sentryStack(pc uintptr) map[string]interface{} {
+	if pc == 0 {
+		return nil
+	}
+	site, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+	var frames []map[string]interface{}
+	found := false
+	it := runtime.CallersFrames(pcs)
+	for {
+		f, more := it.Next()
+		if !found && f.Function == site.Function && f.Line == site.Line {
+			found = true
+		}
+		if found {
+			frames = append(frames, sentryFrame(f))
+		}
+		if !more {
+			break
+		}
+	}
+	if !found {
+		// The record was built on another goroutine; only its call site is known.
+		frames = []map[string]interface{}{sentryFrame(site)}
+	}
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return map[string]interface{}{"frames": frames}
+}
+
+// sentryFrame converts a runtime frame. Frames of packages whose import path has no dot,
+// the standard library, are not marked as application code, except for package main.
+func sentryFrame(f runtime.Frame) map[string]interface{} {
+	module, function := f.Function, ""
+	if i := strings.LastIndexByte(module, '/'); i >= 0 {
+		if j := strings.IndexByte(module[i:], '.'); j >= 0 {
+			module, function = module[:i+j], module[i+j+1:]
+		}
+	} else if j := strings.IndexByte(module, '.'); j >= 0 {
+		module, function = module[:j], module[j+1:]
+	}
+	first, _, _ := strings.Cut(module, "/")
+	return map[string]interface{}{
+		"function": function,
+		"module":   module,
+		"filename": f.File[strings.LastIndexByte(f.File, '/')+1:],
+		"abs_path": f.File,
+		"lineno":   f.Line,
+		"in_app":   module == "main" || strings.Contains(first, "."),
+	}
+}
+
+// send posts each event of a batch as its own envelope.
+func (c *sentryClient) send(ctx context.Context, records [][]byte) error {
+	headers := map[string]string{"X-Sentry-Auth": c.auth}
+	for i, rec := range records {
+		var ev struct {
+			EventID string `json:"event_id"`
+		}
+		json.Unmarshal(rec, &ev)
+		header, _ := json.Marshal(map[string]string{
+			"event_id": ev.EventID,
+			"dsn":      c.dsn,
+			"sent_at":  time.Now().UTC().Format(time.RFC3339),
+		})
+		item, _ := json.Marshal(map[string]interface{}{"type": "event", "length": len(rec)})
+		body := make([]byte, 0, len(header)+len(item)+len(rec)+3)
+		body = append(append(append(body, header...), '\n'), item...)
+		body = append(append(append(body, '\n'), rec...), '\n')
+
+		if _, err := httpPost(ctx, c.opts.Client, c.endpoint, "application/x-sentry-envelope", headers, body, false); err != nil {
+			if retry, ok := err.(*retryError); ok {
+				retry.records = records[i:]
+			}
+			return fmt.Errorf("slogger: sentry: %w", err)
+		}
+	}
+	return nil
+}
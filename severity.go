@@ -0,0 +1,45 @@
+package slogger
+
+import (
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// Syslog severities as defined by RFC 5424, shared by the syslog, GELF and journald encodings.
+const (
+	severityCritical = 2
+	severityError    = 3
+	severityWarning  = 4
+	severityNotice   = 5
+	severityInfo     = 6
+	severityDebug    = 7
+)
+
+// syslogSeverity maps a slog level to a syslog severity. Levels between the standard
+// ones round down to the less severe neighbour.
+func syslogSeverity(l slog.Level) int {
+	switch {
+	case l >= LevelFatal:
+		return severityCritical
+	case l >= slog.LevelError:
+		return severityError
+	case l >= slog.LevelWarn:
+		return severityWarning
+	case l > slog.LevelInfo:
+		return severityNotice
+	case l >= slog.LevelInfo:
+		return severityInfo
+	default:
+		return severityDebug
+	}
+}
+
+// hostname returns the host name reported by encodings that carry one, or "-" when unknown.
+var hostname = sync.OnceValue(func() string {
+	name, err := os.Hostname()
+	if err != nil || name == "" {
+		return "-"
+	}
+	return name
+})
@@ -0,0 +1,112 @@
+package slogger
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// Reopener is implemented by sinks that can reopen their underlying files, for example
+// after an external tool such as logrotate moved them away.
+type Reopener interface {
+	Reopen() error
+}
+
+// SignalOptions configures HandleSignals.
+type SignalOptions struct {
+	// Level is adjusted by SIGUSR1 (more verbose) and SIGUSR2 (less verbose).
+	// Defaults to DefaultLevel, the level of the loggers built by New and MakeLogger;
+	// other loggers need theirs passed here, for example Reloader.Level.
+	Level *AtomicLevel
+
+	// Reopen lists the sinks reopened on SIGHUP.
+	Reopen []Reopener
+
+	// OnError, if not nil, receives errors returned while reopening sinks.
+	OnError func(error)
+}
+
+// levelStep is how far one SIGUSR1/SIGUSR2 moves the level: one of the standard levels.
+const levelStep = slog.LevelInfo - slog.LevelDebug
+
+// SignalListener reacts to SIGHUP, SIGUSR1 and SIGUSR2 until it is closed.
+type SignalListener struct {
+	opts SignalOptions
+	ch   chan os.Signal
+	done chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// HandleSignals starts an opt-in signal listener: SIGHUP reopens the configured sinks and
+// SIGUSR1/SIGUSR2 bump the level down/up by one standard level, within Debug and Fatal.
+// On platforms without these signals the listener does nothing. Call Close to stop it.
+func HandleSignals(opts SignalOptions) *SignalListener {
+	if opts.Level == nil {
+		level := DefaultLevel()
+		opts.Level = &level
+	}
+
+	l := &SignalListener{
+		opts: opts,
+		ch:   make(chan os.Signal, 8),
+		done: make(chan struct{}),
+	}
+	if sigs := handledSignals(); len(sigs) > 0 {
+		signal.Notify(l.ch, sigs...)
+	}
+
+	l.wg.Add(1)
+	go l.run()
+	return l
+}
+
+// Close stops listening for signals. It is safe to call more than once.
+func (l *SignalListener) Close() error {
+	l.once.Do(func() {
+		signal.Stop(l.ch)
+		close(l.done)
+		l.wg.Wait()
+	})
+	return nil
+}
+
+func (l *SignalListener) run() {
+	defer l.wg.Done()
+	for {
+		select {
+		case <-l.done:
+			return
+		case sig := <-l.ch:
+			l.handle(sig)
+		}
+	}
+}
+
+func (l *SignalListener) handle(sig os.Signal) {
+	switch sig {
+	case sigReopen:
+		for _, r := range l.opts.Reopen {
+			if err := r.Reopen(); err != nil && l.opts.OnError != nil {
+				l.opts.OnError(err)
+			}
+		}
+	case sigVerbose:
+		l.bump(-levelStep)
+	case sigQuiet:
+		l.bump(levelStep)
+	}
+}
+
+// bump moves the level by delta, keeping it within Debug and Fatal.
+func (l *SignalListener) bump(delta slog.Level) {
+	level := l.opts.Level.Level() + delta
+	if level < slog.LevelDebug {
+		level = slog.LevelDebug
+	}
+	if level > LevelFatal {
+		level = LevelFatal
+	}
+	l.opts.Level.SetLevel(level)
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package slogger
+
+import "os"
+
+// Platforms without SIGHUP/SIGUSR1/SIGUSR2 never deliver these signals.
+var (
+	sigReopen  os.Signal
+	sigVerbose os.Signal
+	sigQuiet   os.Signal
+)
+
+func handledSignals() []os.Signal {
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package slogger
+
+import (
+	"os"
+	"syscall"
+)
+
+var (
+	sigReopen  os.Signal = syscall.SIGHUP
+	sigVerbose os.Signal = syscall.SIGUSR1
+	sigQuiet   os.Signal = syscall.SIGUSR2
+)
+
+func handledSignals() []os.Signal {
+	return []os.Signal{sigReopen, sigVerbose, sigQuiet}
+}
@@ -1,40 +1,67 @@
 package slogger
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
 	"io"
-	"log"
 	"log/slog"
 	"os"
-	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"text/template"
 	"time"
-
-	"github.com/fatih/color"
-	"github.com/google/uuid"
 )
 
 const (
 	LevelFatal = slog.Level(12)
 )
 
+// TraceIDKey is the attribute key under which the trace ID found in the context is logged.
+const TraceIDKey = "trace-id"
+
+// GoroutineIDKey is the attribute key of the goroutine ID, see WithGoroutineID.
+const GoroutineIDKey = "goroutine"
+
 var (
-	Log        *slog.Logger // Log is a global slogger instance used across the application.
+	// Log is a global slogger instance used across the application.
+	//
+	// Deprecated: reading Log races with reconfiguration; use Default instead.
+	Log        *slog.Logger
 	LevelNames = map[slog.Leveler]string{
 		LevelFatal: "FATAL",
 	}
 )
 
 // NewPrettyHandler creates a new PrettyHandler with a given output writer and options.
+// Options are applied in order; see Option.
 func NewPrettyHandler(
 	out io.Writer,
-	opts PrettyHandlerOptions,
+	opts ...Option,
 ) *PrettyHandler {
+	var o PrettyHandlerOptions
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+
 	h := &PrettyHandler{
-		Handler: slog.NewJSONHandler(out, &opts.SlogOpts),
-		l:       log.New(out, "", 0),
+		out:   out,
+		split: o.SplitOutput,
+		mu:    &sync.Mutex{},
+		opts:  o,
+	}
+
+	// Resolve the per-level overrides once, most severe first.
+	for _, lo := range o.Levels {
+		lvl := o
+		lvl.Levels = nil
+		for _, opt := range lo.Options {
+			opt.apply(&lvl)
+		}
+		h.levels = append(h.levels, levelOptions{level: lo.Level, opts: lvl})
 	}
+	sort.SliceStable(h.levels, func(i, j int) bool { return h.levels[i].level > h.levels[j].level })
 
 	return h
 }
@@ -42,99 +69,438 @@ func NewPrettyHandler(
 // PrettyHandlerOptions contains options specific to the PrettyHandler, mainly around slog handling.
 type PrettyHandlerOptions struct {
 	SlogOpts slog.HandlerOptions
+
+	// Format selects the output encoding. Defaults to FormatPretty.
+	Format Format
+
+	// GroupStyle controls how attributes inside groups are rendered. Defaults to GroupNested.
+	GroupStyle GroupStyle
+
+	// TimeFormat is the layout used for timestamps, either a time.Format layout or one of the
+	// TimeFormat shortcuts. Defaults to time.DateTime for pretty and time.RFC3339Nano for JSON output.
+	TimeFormat string
+
+	// Location converts timestamps to the given time zone before formatting. Defaults to
+	// the local time zone of the record.
+	Location *time.Location
+
+	// ZoneSuffix appends the time zone abbreviation, such as "UTC", to the timestamps of the
+	// pretty and text output. Machine formats such as JSON and logfmt are left parseable.
+	ZoneSuffix bool
+
+	// NoColor disables ANSI colors in the pretty output.
+	NoColor bool
+
+	// NoSource omits the "func | file:line" columns.
+	NoSource bool
+
+	// NoIndent renders the attributes of the pretty output on a single line.
+	NoIndent bool
+
+	// Layout, if set, is executed with a LayoutData for every record to produce the pretty
+	// and text output, overriding Segments. See ParseLayout.
+	Layout *template.Template
+
+	// Pattern, if set, renders the pretty and text output from a compact format string,
+	// overriding Segments. See ParsePattern.
+	Pattern *Pattern
+
+	// CEF describes the device reported by FormatCEF.
+	CEF CEFOptions
+
+	// EMF, if set, adds CloudWatch Embedded Metric Format metadata to FormatJSON output.
+	EMF *EMFOptions
+
+	// CSVColumns lists the columns written by FormatCSV, see CSVOptions.
+	CSVColumns []string
+
+	// Syslog configures the header fields of FormatSyslog.
+	Syslog SyslogOptions
+
+	// GCPProjectID is the Google Cloud project used by FormatGCP to build trace resource names.
+	GCPProjectID string
+
+	// Segments lists the columns of the pretty and text output in order. Defaults to DefaultSegments.
+	Segments []Segment
+
+	// Hidden lists segments left out of the pretty and text output, whatever their order.
+	Hidden []Segment
+
+	// Multiline selects how messages and attributes spanning several lines are rendered in
+	// the pretty and text output. Defaults to MultilineFold.
+	Multiline Multiline
+
+	// TreeDepth, if positive, renders the attributes of the pretty output as an indented
+	// tree expanded down to this depth instead of JSON, see WithTree.
+	TreeDepth int
+
+	// FullPath renders the full path of the calling file instead of its base name.
+	FullPath bool
+
+	// Levels overrides the options for records at or above a level, see WithLevelOptions.
+	Levels []LevelOptions
+
+	// SplitOutput, if set, receives the records at or above SplitLevel instead of the
+	// handler output, see WithSplitOutput.
+	SplitOutput io.Writer
+	SplitLevel  slog.Level
+
+	// ContextAttrs lists context values logged as attributes when present, see
+	// WithContextAttrs.
+	ContextAttrs []ContextAttr
+
+	// BaggageKeys lists the baggage members logged as attributes when present, see
+	// WithBaggage.
+	BaggageKeys []string
+
+	// GoroutineID logs the ID of the goroutine logging the record, see WithGoroutineID.
+	GoroutineID bool
+}
+
+// LevelOptions holds options applied on top of the handler options for records at or
+// above Level. When several match, the one with the highest Level wins.
+type LevelOptions struct {
+	Level   slog.Level
+	Options []Option
 }
 
+// Format selects the encoding used by PrettyHandler.
+type Format int
+
+const (
+	// FormatPretty renders the colored "time | level | msg | func | file:line" layout followed by the attributes.
+	FormatPretty Format = iota
+	// FormatJSON renders each record as a single compact JSON object.
+	FormatJSON
+	// FormatText renders exactly the FormatPretty layout without any ANSI escape sequences,
+	// for files and CI logs.
+	FormatText
+	// FormatLogfmt renders each record as a single line of logfmt key=value pairs.
+	FormatLogfmt
+	// FormatECS renders each record as an Elastic Common Schema JSON document.
+	FormatECS
+	// FormatGELF renders each record as a GELF 1.1 message for Graylog.
+	FormatGELF
+	// FormatCEF renders each record as a Common Event Format line, see CEFOptions.
+	FormatCEF
+	// FormatGCP renders each record as Google Cloud Logging structured JSON.
+	FormatGCP
+	// FormatOTLP renders each record as an OpenTelemetry LogRecord in OTLP/JSON, see OTLPExporter.
+	FormatOTLP
+	// FormatCSV renders each record as a CSV row, see CSVColumns.
+	FormatCSV
+	// FormatSyslog renders each record as an RFC 5424 or RFC 3164 syslog message, see SyslogOptions.
+	FormatSyslog
+	// FormatJournal renders each record as a systemd journal native protocol entry, see NewJournalHandler.
+	FormatJournal
+)
+
+// GroupStyle selects how grouped attributes are rendered.
+type GroupStyle int
+
+const (
+	// GroupNested renders groups as nested objects: {"http": {"status": 200}}.
+	GroupNested GroupStyle = iota
+	// GroupDotted renders groups as flat, dot-separated keys: {"http.status": 200}.
+	GroupDotted
+)
+
 // PrettyHandler implements slog.Handler and provides a structured, colored logging output.
+//
+// A PrettyHandler is safe for concurrent use. Each record is formatted without holding
+// any lock and then written to the output with a single Write call, guarded by a mutex
+// shared with all handlers derived from it via WithAttrs and WithGroup.
 type PrettyHandler struct {
-	slog.Handler
-	l *log.Logger
+	out    io.Writer
+	split  io.Writer // receives records at or above opts.SplitLevel, if set
+	mu     *sync.Mutex
+	opts   PrettyHandlerOptions
+	levels []levelOptions
+	goas   []groupOrAttrs
+}
+
+// levelOptions is a resolved LevelOptions.
+type levelOptions struct {
+	level slog.Level
+	opts  PrettyHandlerOptions
 }
 
-// MakeLogger initializes and configures the global slogger instance.
-func MakeLogger(debug bool) {
+// groupOrAttrs holds either a group name or a list of attributes bound with WithGroup or WithAttrs.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// New returns a logger writing pretty output to stdout at DefaultLevel, Info unless
+// changed, with source information. Options are applied on top of these defaults.
+func New(opts ...Option) *slog.Logger {
+	defaults := []Option{
+		WithLevel(defaultLevel),
+		WithSource(true),
+	}
+	return slog.New(NewPrettyHandler(os.Stdout, append(defaults, opts...)...))
+}
+
+// MakeLogger initializes and configures the global slogger instance and returns it.
+// It sets DefaultLevel to Debug or Info; no other constructor of the package changes it.
+func MakeLogger(debug bool) *slog.Logger {
 
 	level := slog.LevelDebug
 	if !debug {
 		level = slog.LevelInfo
 	}
-	opts := PrettyHandlerOptions{
-		SlogOpts: slog.HandlerOptions{
-			Level:     level,
-			AddSource: true,
-		},
-	}
-
-	handler := NewPrettyHandler(os.Stdout, opts)
-	Log = slog.New(handler)
 
+	defaultLevel.SetLevel(level)
+	l := New(WithLevel(defaultLevel))
+	SetDefault(l)
+	return l
 }
 
 // Handle processes a single log record, formats it, and outputs it to the configured io.Writer.
 func (h *PrettyHandler) Handle(ctx context.Context, r slog.Record) error {
-	// Change color based on log level
-	level := r.Level.String()
-
-	customeLevelName, ok := LevelNames[r.Level]
-	if ok {
-		level = customeLevelName
+	if !h.Enabled(ctx, r.Level) && !dumping(ctx) {
+		return nil
 	}
+	h = h.forLevel(r.Level)
 
-	switch r.Level {
-	case slog.LevelDebug:
-		level = color.MagentaString(level)
-	case slog.LevelInfo:
-		level = color.GreenString(level + " ")
-	case slog.LevelWarn:
-		level = color.YellowString(level + " ")
-	case slog.LevelError:
-		level = color.RedString(level)
-	case LevelFatal:
-		level = color.RedString(level)
-
+	// Built-in fields go through ReplaceAttr just like the stdlib handlers.
+	// A zero time or a zero PC, as found in records built with slog.NewRecord, is omitted.
+	var e entry
+	if !r.Time.IsZero() {
+		e.time, _ = h.replace(nil, slog.Time(slog.TimeKey, r.Time))
+	}
+	e.level, _ = h.replace(nil, slog.Any(slog.LevelKey, r.Level))
+	e.message, _ = h.replace(nil, slog.String(slog.MessageKey, r.Message))
+	if r.PC != 0 && !h.opts.NoSource {
+		// Capture the source from runtime call stack
+		fs := runtime.CallersFrames([]uintptr{r.PC})
+		frame, _ := fs.Next()
+		source := &slog.Source{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+		}
+		e.source, _ = h.replace(nil, slog.Any(slog.SourceKey, source))
 	}
 
-	// Collect log attributes
-	fields := make(map[string]interface{}, r.NumAttrs())
+	// Collect log attributes, starting with the ones bound by WithAttrs and WithGroup.
+	// Groups are built from the innermost level outwards so that empty groups are omitted.
+	groups := make([][]string, len(h.goas)+1)
+	for i, goa := range h.goas {
+		groups[i+1] = groups[i]
+		if goa.group != "" {
+			groups[i+1] = append(groups[i][:len(groups[i]):len(groups[i])], goa.group)
+		}
+	}
 
+	attrs := make(fields, 0, r.NumAttrs())
 	r.Attrs(func(a slog.Attr) bool {
-		if a.Key == "err" && a.Value.Any() != nil {
-			err, ok := a.Value.Any().(error)
-			if ok {
-				fields[a.Key] = err.Error()
-			} else {
-				fields[a.Key] = a.Value.Any()
-			}
-		} else {
-			fields[a.Key] = a.Value.Any()
-		}
+		attrs = h.appendField(attrs, groups[len(h.goas)], a, 0)
 		return true
 	})
 
-	// Capture the source from runtime call stack
-	source := make(map[string]interface{}, r.NumAttrs())
-
-	fs := runtime.CallersFrames([]uintptr{r.PC})
-	frame, _ := fs.Next()
-	source["file"] = filepath.Base(frame.File)
-	source["line"] = frame.Line
-	source["func"] = color.CyanString(filepath.Base(frame.Function))
+	for i := len(h.goas) - 1; i >= 0; i-- {
+		goa := h.goas[i]
+		if goa.group != "" {
+			if len(attrs) > 0 {
+				attrs = fields{{key: goa.group, value: attrs}}
+			}
+			continue
+		}
+		var bound fields
+		for _, a := range goa.attrs {
+			bound = h.appendField(bound, groups[i], a, 0)
+		}
+		attrs = append(bound, attrs...)
+	}
 
-	// Format the timestamp
-	timeStr := color.GreenString(r.Time.Format(time.DateTime))
-	msg := color.BlueString(r.Message)
+	if h.opts.GoroutineID {
+		attrs = append(attrs, field{key: GoroutineIDKey, value: callerGoroutineID(ctx)})
+	}
 
 	// Check for a trace ID in the context and add it to the log fields if present
-	traceID, ok := ctx.Value("trace-id").(uuid.UUID)
-	if ok {
-		fields["trace-id"] = traceID
+	if traceID, ok := TraceIDFromContext(ctx); ok {
+		attrs = append(attrs, field{key: TraceIDKey, value: traceID})
+	}
+	if sc, ok := SpanContextFromContext(ctx); ok {
+		attrs = append(attrs,
+			field{key: SpanTraceIDKey, value: sc.TraceIDHex()},
+			field{key: SpanIDKey, value: sc.SpanIDHex()},
+		)
+	}
+	for _, a := range ctxAttrs(ctx) {
+		attrs = h.appendField(attrs, nil, a, 0)
 	}
-	b, err := json.MarshalIndent(fields, "", "  ")
+	for _, ca := range h.opts.ContextAttrs {
+		if v := ctx.Value(ca.Key); v != nil {
+			attrs = h.appendField(attrs, nil, slog.Any(ca.Name, v), 0)
+		}
+	}
+	for _, k := range h.opts.BaggageKeys {
+		if v, ok := BaggageFromContext(ctx, k); ok {
+			attrs = append(attrs, field{key: k, value: v})
+		}
+	}
+	if h.opts.GroupStyle == GroupDotted {
+		attrs = attrs.flatten("")
+	}
+	e.attrs = attrs
+
+	b, err := h.encode(e)
 	if err != nil {
 		return err
 	}
 
-	// Print the formatted log entry
-	h.l.Printf("%v | %v | %v | %v | %v:%v %v", timeStr, level, msg, source["func"], source["file"], source["line"], string(b))
+	// Write the formatted log entry in one call so concurrent records never interleave
+	b = append(b, '\n')
+	out := h.out
+	if h.split != nil && r.Level >= h.opts.SplitLevel {
+		out = h.split
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err = out.Write(b)
+	return err
+}
+
+// forLevel returns h with the per-level options matching level applied, or h itself.
+func (h *PrettyHandler) forLevel(level slog.Level) *PrettyHandler {
+	for _, lo := range h.levels {
+		if level >= lo.level {
+			h2 := *h
+			h2.opts = lo.opts
+			return &h2
+		}
+	}
+	return h
+}
+
+// replace applies the ReplaceAttr option to a, reporting false when the attribute should be dropped.
+func (h *PrettyHandler) replace(groups []string, a slog.Attr) (slog.Attr, bool) {
+	if rep := h.opts.SlogOpts.ReplaceAttr; rep != nil {
+		a = rep(groups, a)
+	}
+	return a, a.Key != ""
+}
+
+// Enabled reports whether the handler emits records at the given level.
+// The minimum level is taken from SlogOpts.Level and defaults to slog.LevelInfo.
+func (h *PrettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.SlogOpts.Level != nil {
+		minLevel = h.opts.SlogOpts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+// WithAttrs returns a new PrettyHandler whose output includes the given attributes.
+func (h *PrettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{attrs: attrs})
+}
 
-	return nil
+// WithGroup returns a new PrettyHandler that nests all subsequent attributes under the given group name.
+func (h *PrettyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{group: name})
+}
+
+// withLeveler returns a copy of h that uses l as its minimum level.
+func (h *PrettyHandler) withLeveler(l slog.Leveler) *PrettyHandler {
+	h2 := *h
+	h2.opts.SlogOpts.Level = l
+	return &h2
+}
+
+// withWriter returns a copy of h writing all records to w, guarded by a lock of its own.
+func (h *PrettyHandler) withWriter(w io.Writer) *PrettyHandler {
+	h2 := *h
+	h2.out = w
+	h2.split = nil
+	h2.mu = &sync.Mutex{}
+	return &h2
+}
+
+func (h *PrettyHandler) withGroupOrAttrs(goa groupOrAttrs) *PrettyHandler {
+	h2 := *h
+	h2.goas = make([]groupOrAttrs, len(h.goas)+1)
+	copy(h2.goas, h.goas)
+	h2.goas[len(h2.goas)-1] = goa
+	return &h2
+}
+
+// maxGroupDepth bounds how deeply groups, including groups produced by slog.LogValuer
+// values, are expanded. It protects against LogValuers that return themselves.
+const maxGroupDepth = 32
+
+// appendField appends a single attribute to fs.
+// Values are resolved, empty attributes are ignored and groups become nested fields.
+func (h *PrettyHandler) appendField(fs fields, groups []string, a slog.Attr, depth int) fields {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fs
+	}
+	if depth > maxGroupDepth {
+		return append(fs, field{key: a.Key, value: "!MAXDEPTH"})
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return fs
+		}
+		if a.Key == "" {
+			for _, ga := range attrs {
+				fs = h.appendField(fs, groups, ga, depth+1)
+			}
+			return fs
+		}
+		group := make(fields, 0, len(attrs))
+		for _, ga := range attrs {
+			group = h.appendField(group, append(groups[:len(groups):len(groups)], a.Key), ga, depth+1)
+		}
+		if len(group) > 0 {
+			fs = append(fs, field{key: a.Key, value: group})
+		}
+		return fs
+	}
+
+	a, ok := h.replace(groups, a)
+	if !ok {
+		return fs
+	}
+	a.Value = a.Value.Resolve()
+
+	// Errors are kept as is; every encoder renders them as their message.
+	return append(fs, field{key: a.Key, value: a.Value.Any()})
+}
+
+// goroutineKey is the context key under which an AsyncHandler passes the ID of the
+// goroutine that logged a record on to the handlers below its worker.
+type goroutineKey struct{}
+
+// callerGoroutineID returns the ID of the goroutine that logged the record handled
+// with ctx: the one recorded by an AsyncHandler, or else the calling goroutine.
+func callerGoroutineID(ctx context.Context) uint64 {
+	if id, ok := ctx.Value(goroutineKey{}).(uint64); ok {
+		return id
+	}
+	return goroutineID()
+}
+
+// goroutineID returns the ID of the calling goroutine, parsed from the header of its
+// stack trace, or 0 if the header can't be parsed.
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
 }
@@ -0,0 +1,76 @@
+package slogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+)
+
+func TestJSONHandlerConformance(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewPrettyHandler(&buf, WithFormat(FormatJSON), WithLevel(slog.LevelDebug))
+	results := func() []map[string]any {
+		var ms []map[string]any
+		for _, line := range bytes.Split(buf.Bytes(), []byte("\n")) {
+			if len(line) == 0 {
+				continue
+			}
+			var m map[string]any
+			if err := json.Unmarshal(line, &m); err != nil {
+				t.Fatalf("invalid JSON line %q: %v", line, err)
+			}
+			ms = append(ms, m)
+		}
+		return ms
+	}
+	if err := slogtest.TestHandler(h, results); err != nil {
+		t.Error(err)
+	}
+}
+
+// parseText parses FormatText records: a "time | level | msg | func | file:line" header,
+// where the time is left out when zero, followed by the JSON of the attributes.
+func parseText(t *testing.T, out []byte) []map[string]any {
+	t.Helper()
+	var ms []map[string]any
+	for rest := out; len(bytes.TrimSpace(rest)) > 0; {
+		i := bytes.IndexByte(rest, '{')
+		if i < 0 {
+			t.Fatalf("record without attributes %q", rest)
+		}
+		header := strings.Split(strings.TrimSpace(string(rest[:i])), " | ")
+		dec := json.NewDecoder(bytes.NewReader(rest[i:]))
+		var m map[string]any
+		if err := dec.Decode(&m); err != nil {
+			t.Fatalf("invalid attributes in %q: %v", rest, err)
+		}
+		rest = rest[i+int(dec.InputOffset()):]
+
+		if _, err := time.ParseInLocation(time.DateTime, header[0], time.Local); err == nil {
+			m[slog.TimeKey] = header[0]
+			header = header[1:]
+		}
+		if len(header) < 2 {
+			t.Fatalf("header %q without level and message", header)
+		}
+		m[slog.LevelKey] = strings.TrimSpace(header[0])
+		m[slog.MessageKey] = header[1]
+		ms = append(ms, m)
+	}
+	return ms
+}
+
+func TestTextHandlerConformance(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewPrettyHandler(&buf, WithFormat(FormatText), WithLevel(slog.LevelDebug))
+	results := func() []map[string]any {
+		return parseText(t, buf.Bytes())
+	}
+	if err := slogtest.TestHandler(h, results); err != nil {
+		t.Error(err)
+	}
+}
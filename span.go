@@ -0,0 +1,89 @@
+package slogger
+
+import (
+	"context"
+	"encoding/hex"
+	"sync/atomic"
+)
+
+// Attribute keys under which the handlers log the span context found in the context of a
+// record, in W3C hex form.
+const (
+	SpanTraceIDKey = "trace_id"
+	SpanIDKey      = "span_id"
+)
+
+// SpanContext identifies a span of a distributed trace, as OpenTelemetry and the W3C
+// traceparent header do.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte // trace flags, bit 0 is sampled
+}
+
+// IsValid reports whether sc has a non-zero trace and span ID.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Sampled reports whether the sampled flag is set.
+func (sc SpanContext) Sampled() bool {
+	return sc.Flags&1 != 0
+}
+
+// TraceIDHex returns the trace ID as 32 lowercase hex characters.
+func (sc SpanContext) TraceIDHex() string {
+	return hex.EncodeToString(sc.TraceID[:])
+}
+
+// SpanIDHex returns the span ID as 16 lowercase hex characters.
+func (sc SpanContext) SpanIDHex() string {
+	return hex.EncodeToString(sc.SpanID[:])
+}
+
+// spanKey is the context key of ContextWithSpanContext.
+type spanKey struct{}
+
+// ContextWithSpanContext returns a copy of ctx carrying sc.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanKey{}, sc)
+}
+
+// spanContextFunc is the function set by SetSpanContextFunc.
+var spanContextFunc atomic.Pointer[func(context.Context) (SpanContext, bool)]
+
+// SetSpanContextFunc registers f to find the active span of a context, so the handlers
+// log its trace and span IDs with every record logged with the context. This package
+// doesn't depend on OpenTelemetry; its span context is one adapter away:
+//
+//	slogger.SetSpanContextFunc(func(ctx context.Context) (slogger.SpanContext, bool) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		return slogger.SpanContext{
+//			TraceID: sc.TraceID(),
+//			SpanID:  sc.SpanID(),
+//			Flags:   byte(sc.TraceFlags()),
+//		}, sc.IsValid()
+//	})
+//
+// Passing nil removes the function.
+func SetSpanContextFunc(f func(ctx context.Context) (SpanContext, bool)) {
+	if f == nil {
+		spanContextFunc.Store(nil)
+		return
+	}
+	spanContextFunc.Store(&f)
+}
+
+// SpanContextFromContext returns the span context carried by ctx: the one stored by
+// ContextWithSpanContext, or else the one found by the function of SetSpanContextFunc.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	if sc, ok := ctx.Value(spanKey{}).(SpanContext); ok && sc.IsValid() {
+		return sc, true
+	}
+	if f := spanContextFunc.Load(); f != nil {
+		if sc, ok := (*f)(ctx); ok && sc.IsValid() {
+			return sc, true
+		}
+	}
+	return SpanContext{}, false
+}
@@ -0,0 +1,114 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SpanEvent is a record attached to the active span of its context by a
+// SpanEventHandler.
+type SpanEvent struct {
+	Name  string // the message of the record
+	Time  time.Time
+	Level slog.Level
+
+	// Attrs are the attributes of the record, including those bound with WithAttrs,
+	// flattened to dotted keys.
+	Attrs []slog.Attr
+
+	// Err is the first error among the attributes, if any, for span.RecordError.
+	Err error
+}
+
+// SpanEventOptions configures a SpanEventHandler.
+type SpanEventOptions struct {
+	// Level is the lowest level attached to spans. Defaults to slog.LevelWarn.
+	Level slog.Leveler
+
+	// AddEvent attaches ev to the active span of ctx. This package doesn't depend on
+	// OpenTelemetry, its span is one adapter away:
+	//
+	//	AddEvent: func(ctx context.Context, ev slogger.SpanEvent) {
+	//		span := trace.SpanFromContext(ctx)
+	//		kvs := make([]attribute.KeyValue, 0, len(ev.Attrs)+1)
+	//		kvs = append(kvs, attribute.String("level", ev.Level.String()))
+	//		for _, a := range ev.Attrs {
+	//			kvs = append(kvs, attribute.String(a.Key, a.Value.String()))
+	//		}
+	//		span.AddEvent(ev.Name, trace.WithTimestamp(ev.Time), trace.WithAttributes(kvs...))
+	//	}
+	AddEvent func(ctx context.Context, ev SpanEvent)
+}
+
+// SpanEventHandler passes records to the wrapped handler and also attaches those at or
+// above Level as events to the active span of their context, so warnings and errors are
+// visible inline in trace waterfalls.
+type SpanEventHandler struct {
+	next   slog.Handler
+	opts   SpanEventOptions
+	prefix string
+	bound  []flatAttr
+}
+
+// NewSpanEventHandler wraps next with span events as configured by opts.
+func NewSpanEventHandler(next slog.Handler, opts SpanEventOptions) *SpanEventHandler {
+	if opts.Level == nil {
+		opts.Level = slog.LevelWarn
+	}
+	return &SpanEventHandler{next: next, opts: opts}
+}
+
+func (h *SpanEventHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.attaches(level) || h.next.Enabled(ctx, level)
+}
+
+// attaches reports whether records of level are attached to spans.
+func (h *SpanEventHandler) attaches(level slog.Level) bool {
+	return h.opts.AddEvent != nil && level >= h.opts.Level.Level()
+}
+
+func (h *SpanEventHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.attaches(r.Level) {
+		h.opts.AddEvent(ctx, h.event(r))
+	}
+	if !h.next.Enabled(ctx, r.Level) && !dumping(ctx) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// event returns the span event of r.
+func (h *SpanEventHandler) event(r slog.Record) SpanEvent {
+	ev := SpanEvent{Name: r.Message, Time: r.Time, Level: r.Level}
+	flat := h.bound[:len(h.bound):len(h.bound)]
+	r.Attrs(func(a slog.Attr) bool {
+		flat = appendFlatAttr(flat, h.prefix, a)
+		return true
+	})
+	ev.Attrs = make([]slog.Attr, len(flat))
+	for i, fa := range flat {
+		ev.Attrs[i] = slog.Attr{Key: fa.key, Value: fa.value}
+		if err, ok := fa.value.Any().(error); ok && ev.Err == nil {
+			ev.Err = err
+		}
+	}
+	return ev
+}
+
+func (h *SpanEventHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.next = h.next.WithAttrs(attrs)
+	h2.bound = bindFlatAttrs(h.bound, h.prefix, attrs)
+	return &h2
+}
+
+func (h *SpanEventHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.next = h.next.WithGroup(name)
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
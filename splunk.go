@@ -0,0 +1,203 @@
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SplunkOptions configures a SplunkSink.
+type SplunkOptions struct {
+	// URL is the base URL of the HTTP Event Collector, as in https://splunk:8088.
+	URL string
+
+	// Token is the HEC token.
+	Token string
+
+	// Index, Source and SourceType are set on every event. Empty values leave the choice
+	// to the token configuration, except SourceType, which defaults to "_json".
+	Index      string
+	Source     string
+	SourceType string
+
+	// Host overrides the host name reported with the events.
+	Host string
+
+	// Ack waits for indexer acknowledgment of every batch, which the token must have
+	// enabled. Batches not acknowledged within AckTimeout, one minute by default, are
+	// sent again, so events may be indexed twice.
+	Ack        bool
+	AckTimeout time.Duration
+
+	// Channel identifies the client to the collector. Defaults to a random GUID; it is
+	// required with Ack.
+	Channel string
+
+	// BatchSize, FlushInterval, MaxInFlight, MaxRetries, MaxBuffered, DeadLetter and Breaker
+	// tune batching as documented on WebhookOptions. A busy collector (503) and other server
+	// errors are retried with backoff.
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxInFlight   int
+	MaxRetries    int
+	MaxBuffered   int
+	DeadLetter    *DeadLetter
+	Breaker       *CircuitBreaker
+
+	// Client sends the requests. Defaults to a client with a ten second timeout.
+	Client *http.Client
+
+	// OnError, if not nil, receives failed deliveries.
+	OnError func(error)
+}
+
+// SplunkSink is an io.Writer sending the JSON records written by a FormatJSON handler to
+// a Splunk HTTP Event Collector. Call Close to send the remaining records.
+type SplunkSink struct {
+	*batcher
+	opts    SplunkOptions
+	headers map[string]string
+}
+
+// NewSplunkHandler creates a JSON handler sending records to Splunk and returns the sink
+// so it can be closed on shutdown. Handler options are applied as for NewPrettyHandler.
+func NewSplunkHandler(sp SplunkOptions, opts ...Option) (*PrettyHandler, *SplunkSink, error) {
+	s, err := NewSplunkSink(sp)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewPrettyHandler(s, append(opts, WithFormat(FormatJSON), WithTimeFormat(TimeFormatRFC3339Nano))...), s, nil
+}
+
+// NewSplunkSink starts a sink with the given options.
+func NewSplunkSink(opts SplunkOptions) (*SplunkSink, error) {
+	if opts.URL == "" {
+		return nil, errors.New("slogger: splunk: no URL")
+	}
+	if opts.Token == "" {
+		return nil, errors.New("slogger: splunk: no token")
+	}
+	opts.URL = strings.TrimSuffix(opts.URL, "/")
+	if opts.SourceType == "" {
+		opts.SourceType = "_json"
+	}
+	if opts.Host == "" {
+		opts.Host = hostname()
+	}
+	if opts.Channel == "" {
+		opts.Channel = uuid.New().String()
+	}
+	if opts.AckTimeout <= 0 {
+		opts.AckTimeout = time.Minute
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	s := &SplunkSink{opts: opts, headers: map[string]string{
+		"Authorization":            "Splunk " + opts.Token,
+		"X-Splunk-Request-Channel": opts.Channel,
+	}}
+	s.batcher = newBatcher(batchOptions{
+		size:        opts.BatchSize,
+		interval:    opts.FlushInterval,
+		maxInFlight: opts.MaxInFlight,
+		maxRetries:  opts.MaxRetries,
+		maxBuffered: opts.MaxBuffered,
+		deadLetter:  opts.DeadLetter,
+		breaker:     opts.Breaker,
+		onError:     opts.OnError,
+		send:        s.send,
+	})
+	return s, nil
+}
+
+// splunkEvent is the envelope of an event sent to the collector.
+type splunkEvent struct {
+	Time       json.Number     `json:"time,omitempty"`
+	Host       string          `json:"host,omitempty"`
+	Source     string          `json:"source,omitempty"`
+	SourceType string          `json:"sourcetype,omitempty"`
+	Index      string          `json:"index,omitempty"`
+	Event      json.RawMessage `json:"event"`
+}
+
+// send posts one batch as concatenated events and waits for its acknowledgment if
+// requested.
+func (s *SplunkSink) send(ctx context.Context, records [][]byte) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, rec := range records {
+		ev := splunkEvent{
+			Host:       s.opts.Host,
+			Source:     s.opts.Source,
+			SourceType: s.opts.SourceType,
+			Index:      s.opts.Index,
+			Event:      rec,
+		}
+		var v struct {
+			Time string `json:"time"`
+		}
+		if json.Unmarshal(rec, &v) == nil {
+			if ts, err := time.Parse(time.RFC3339Nano, v.Time); err == nil {
+				ev.Time = json.Number(strconv.FormatFloat(float64(ts.UnixMicro())/1e6, 'f', 6, 64))
+			}
+		} else {
+			ev.Event, _ = json.Marshal(string(rec))
+		}
+		if err := enc.Encode(ev); err != nil {
+			return err
+		}
+	}
+
+	resp, err := httpPost(ctx, s.opts.Client, s.opts.URL+"/services/collector/event", "application/json", s.headers, body.Bytes(), false)
+	if err != nil {
+		return fmt.Errorf("slogger: splunk: %w", err)
+	}
+	if !s.opts.Ack {
+		return nil
+	}
+	var v struct {
+		AckID *int64 `json:"ackId"`
+	}
+	if err := json.Unmarshal(resp, &v); err != nil || v.AckID == nil {
+		return errors.New("slogger: splunk: no ackId in response; is indexer acknowledgment enabled for the token?")
+	}
+	return s.await(ctx, *v.AckID)
+}
+
+// await polls the acknowledgment endpoint until id is acknowledged. A batch that is not
+// acknowledged in time is retried.
+func (s *SplunkSink) await(ctx context.Context, id int64) error {
+	query, _ := json.Marshal(map[string][]int64{"acks": {id}})
+	key := strconv.FormatInt(id, 10)
+	deadline := time.Now().Add(s.opts.AckTimeout)
+	delay := 100 * time.Millisecond
+	for {
+		resp, err := httpPost(ctx, s.opts.Client, s.opts.URL+"/services/collector/ack", "application/json", s.headers, query, false)
+		if err == nil {
+			var v struct {
+				Acks map[string]bool `json:"acks"`
+			}
+			if json.Unmarshal(resp, &v) == nil && v.Acks[key] {
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			if err == nil {
+				err = errors.New("not acknowledged")
+			}
+			return &retryError{err: fmt.Errorf("slogger: splunk: ack %d: %w", id, err)}
+		}
+		time.Sleep(delay)
+		delay = min(2*delay, 2*time.Second)
+	}
+}
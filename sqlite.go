@@ -0,0 +1,207 @@
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// SQLiteOptions configures a SQLiteSink.
+type SQLiteOptions struct {
+	// Table receives the records. It is created with indices on time and level if it
+	// does not exist. Defaults to "logs".
+	Table string
+
+	// BatchSize, FlushInterval, MaxRetries, MaxBuffered, DeadLetter and Breaker tune
+	// batching as documented on WebhookOptions. Each batch is inserted in one transaction.
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxRetries    int
+	MaxBuffered   int
+	DeadLetter    *DeadLetter
+	Breaker       *CircuitBreaker
+
+	// OnError, if not nil, receives failed inserts.
+	OnError func(error)
+}
+
+// SQLiteSink is an io.Writer inserting the JSON records written by a FormatJSON handler
+// into a SQLite table, so recent logs on a device can be queried with SQL:
+//
+//	SELECT time, msg, json_extract(attrs, '$.user') FROM logs WHERE level = 'ERROR'
+//
+// The table has the columns id, time (UTC, RFC 3339 with nanoseconds), level, msg,
+// source ("file:line") and attrs (a JSON object). Call Close to insert the remaining
+// records; the database is left open.
+type SQLiteSink struct {
+	*batcher
+	db     *sql.DB
+	insert string
+}
+
+// NewSQLiteHandler creates a JSON handler inserting records into db and returns the sink
+// so it can be closed on shutdown. Handler options are applied as for NewPrettyHandler.
+func NewSQLiteHandler(db *sql.DB, sqlite SQLiteOptions, opts ...Option) (*PrettyHandler, *SQLiteSink, error) {
+	s, err := NewSQLiteSink(db, sqlite)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewPrettyHandler(s, append(opts, WithFormat(FormatJSON), WithTimeFormat(TimeFormatRFC3339Nano))...), s, nil
+}
+
+// NewSQLiteSink creates the table if needed and starts a sink writing to it. db is
+// opened with the SQLite driver of your choice, for example:
+//
+//	db, err := sql.Open("sqlite", "file:logs.db?_pragma=journal_mode(WAL)")
+func NewSQLiteSink(db *sql.DB, opts SQLiteOptions) (*SQLiteSink, error) {
+	if opts.Table == "" {
+		opts.Table = "logs"
+	}
+	table := sqlIdent(opts.Table)
+	index := sqlIdent(opts.Table + "_time")
+	levelIndex := sqlIdent(opts.Table + "_level")
+	schema := []string{
+		`CREATE TABLE IF NOT EXISTS ` + table + ` (
+	id INTEGER PRIMARY KEY,
+	time TEXT NOT NULL,
+	level TEXT NOT NULL,
+	msg TEXT NOT NULL,
+	source TEXT,
+	attrs TEXT
+)`,
+		`CREATE INDEX IF NOT EXISTS ` + index + ` ON ` + table + ` (time)`,
+		`CREATE INDEX IF NOT EXISTS ` + levelIndex + ` ON ` + table + ` (level, time)`,
+	}
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, fmt.Errorf("slogger: sqlite schema: %w", err)
+		}
+	}
+
+	s := &SQLiteSink{
+		db:     db,
+		insert: `INSERT INTO ` + table + ` (time, level, msg, source, attrs) VALUES (?, ?, ?, ?, ?)`,
+	}
+	s.batcher = newBatcher(batchOptions{
+		size:        opts.BatchSize,
+		interval:    opts.FlushInterval,
+		maxInFlight: 1,
+		maxRetries:  opts.MaxRetries,
+		maxBuffered: opts.MaxBuffered,
+		deadLetter:  opts.DeadLetter,
+		breaker:     opts.Breaker,
+		onError:     opts.OnError,
+		send:        s.send,
+	})
+	return s, nil
+}
+
+// send inserts one batch in a transaction. A failed transaction is rolled back and
+// retried, so busy databases do not lose records.
+func (s *SQLiteSink) send(ctx context.Context, records [][]byte) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return &retryError{err: fmt.Errorf("slogger: sqlite insert: %w", err)}
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, s.insert)
+	if err != nil {
+		return &retryError{err: fmt.Errorf("slogger: sqlite insert: %w", err)}
+	}
+	defer stmt.Close()
+	for _, rec := range records {
+		row := parseLogRow(rec)
+		if _, err := stmt.ExecContext(ctx, row.time.UTC().Format(sqlTimeFormat), row.level, row.msg, row.source, row.attrs); err != nil {
+			return &retryError{err: fmt.Errorf("slogger: sqlite insert: %w", err)}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return &retryError{err: fmt.Errorf("slogger: sqlite insert: %w", err)}
+	}
+	return nil
+}
+
+// sqlTimeFormat is a fixed-width RFC 3339 layout, so stored times sort as text.
+const sqlTimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// logRow is a JSON record split into table columns.
+type logRow struct {
+	time   time.Time
+	level  string
+	msg    string
+	source sql.NullString
+	attrs  sql.NullString // JSON object of the remaining fields
+}
+
+// parseLogRow splits a JSON record into the built-in fields and the attributes, keeping
+// the attributes in order.
+func parseLogRow(rec []byte) logRow {
+	row := logRow{time: time.Now()}
+	dec := json.NewDecoder(bytes.NewReader(rec))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		row.msg = string(rec)
+		return row
+	}
+
+	var attrs bytes.Buffer
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			break
+		}
+		key, _ := t.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			break
+		}
+		switch key {
+		case slog.TimeKey:
+			var ts string
+			if json.Unmarshal(raw, &ts) == nil {
+				if parsed, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+					row.time = parsed
+					continue
+				}
+			}
+		case slog.LevelKey:
+			if json.Unmarshal(raw, &row.level) == nil {
+				continue
+			}
+		case slog.MessageKey:
+			if json.Unmarshal(raw, &row.msg) == nil {
+				continue
+			}
+		case slog.SourceKey:
+			var src slog.Source
+			if json.Unmarshal(raw, &src) == nil && src.File != "" {
+				row.source = sql.NullString{String: fmt.Sprintf("%s:%d", src.File, src.Line), Valid: true}
+				continue
+			}
+		}
+		if attrs.Len() == 0 {
+			attrs.WriteByte('{')
+		} else {
+			attrs.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		attrs.Write(k)
+		attrs.WriteByte(':')
+		attrs.Write(raw)
+	}
+	if attrs.Len() > 0 {
+		attrs.WriteByte('}')
+		row.attrs = sql.NullString{String: attrs.String(), Valid: true}
+	}
+	return row
+}
+
+// sqlIdent quotes an identifier for use in SQL statements.
+func sqlIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,248 @@
+package slogger
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Syslog facilities, see RFC 5424 section 6.2.1.
+const (
+	FacilityKern   = 0
+	FacilityUser   = 1
+	FacilityDaemon = 3
+	FacilityAuth   = 4
+	FacilityLocal0 = 16
+	FacilityLocal1 = 17
+	FacilityLocal2 = 18
+	FacilityLocal3 = 19
+	FacilityLocal4 = 20
+	FacilityLocal5 = 21
+	FacilityLocal6 = 22
+	FacilityLocal7 = 23
+)
+
+// SyslogProtocol selects the syslog message format.
+type SyslogProtocol int
+
+const (
+	// SyslogRFC5424 produces "<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG".
+	SyslogRFC5424 SyslogProtocol = iota
+	// SyslogRFC3164 produces the legacy BSD format "<PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG",
+	// with the attributes appended to the message as logfmt pairs.
+	SyslogRFC3164
+)
+
+// SyslogOptions configures syslog message formatting.
+type SyslogOptions struct {
+	// Protocol selects the message format. Defaults to SyslogRFC5424.
+	Protocol SyslogProtocol
+
+	// Facility is combined with the severity derived from the level into the PRI part.
+	// The kernel facility is reserved for the kernel, so zero selects FacilityUser.
+	Facility int
+
+	// AppName identifies the application. Defaults to the executable name.
+	AppName string
+
+	// Hostname overrides the host name reported in messages.
+	Hostname string
+
+	// MsgIDKey names the attribute used as MSGID. The attribute is not repeated in the
+	// structured data. Without it MSGID is "-".
+	MsgIDKey string
+
+	// SDID is the SD-ID of the structured data element holding the attributes.
+	// Defaults to "slog@32473".
+	SDID string
+}
+
+// NewSyslogHandler creates a handler producing RFC 5424 or RFC 3164 syslog messages,
+// independent of the transport: out may be a file, a SyslogWriter or another connection.
+// RFC 5424 attributes become structured data parameters. It accepts the same options as
+// NewPrettyHandler.
+func NewSyslogHandler(out io.Writer, syslog SyslogOptions, opts ...Option) *PrettyHandler {
+	return NewPrettyHandler(out, append(opts, WithFormat(FormatSyslog), WithSyslog(syslog))...)
+}
+
+// WithSyslog sets the syslog header fields used by FormatSyslog.
+func WithSyslog(syslog SyslogOptions) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.Syslog = syslog
+	})
+}
+
+// syslogTimestamp is the RFC 5424 TIMESTAMP layout with microsecond precision.
+const syslogTimestamp = "2006-01-02T15:04:05.000000Z07:00"
+
+// priority computes the PRI value of a record with the given level.
+func (o SyslogOptions) priority(v slog.Value) int {
+	facility := o.Facility
+	if facility == FacilityKern {
+		facility = FacilityUser
+	}
+	severity := severityInfo
+	if l, ok := v.Any().(slog.Level); ok {
+		severity = syslogSeverity(l)
+	}
+	return facility*8 + severity
+}
+
+// appName returns the configured APP-NAME or the executable name.
+func (o SyslogOptions) appName() string {
+	if o.AppName != "" {
+		return o.AppName
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// host returns the configured HOSTNAME or the machine host name.
+func (o SyslogOptions) host() string {
+	if o.Hostname != "" {
+		return o.Hostname
+	}
+	return hostname()
+}
+
+// encodeSyslog renders e as an RFC 5424 message:
+//
+//	<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD-ID key="value" ...] MSG
+func (h *PrettyHandler) encodeSyslog(e entry) ([]byte, error) {
+	o := h.opts.Syslog
+	if o.Protocol == SyslogRFC3164 {
+		return h.encodeSyslog3164(e)
+	}
+	sdid := o.SDID
+	if sdid == "" {
+		sdid = "slog@32473"
+	}
+
+	var b strings.Builder
+	b.WriteByte('<')
+	b.WriteString(strconv.Itoa(o.priority(e.level.Value)))
+	b.WriteString(">1 ")
+	if e.time.Key != "" && e.time.Value.Kind() == slog.KindTime {
+		b.WriteString(e.time.Value.Time().Format(syslogTimestamp))
+	} else {
+		b.WriteByte('-')
+	}
+	b.WriteByte(' ')
+	b.WriteString(syslogHeaderField(o.host(), 255))
+	b.WriteByte(' ')
+	b.WriteString(syslogHeaderField(o.appName(), 48))
+	b.WriteByte(' ')
+	b.WriteString(strconv.Itoa(os.Getpid()))
+	b.WriteByte(' ')
+
+	msgID := "-"
+	var params []string
+	if e.source.Key != "" {
+		params = append(params, syslogParam("source", logfmtValue(sourceString(e.source.Value.Any()))))
+	}
+	for _, f := range e.attrs.flatten("") {
+		if o.MsgIDKey != "" && f.key == o.MsgIDKey {
+			msgID = syslogHeaderField(logfmtValue(f.value), 32)
+			continue
+		}
+		params = append(params, syslogParam(f.key, logfmtValue(f.value)))
+	}
+	b.WriteString(msgID)
+	b.WriteByte(' ')
+
+	if len(params) == 0 {
+		b.WriteByte('-')
+	} else {
+		b.WriteByte('[')
+		b.WriteString(sdid)
+		for _, p := range params {
+			b.WriteByte(' ')
+			b.WriteString(p)
+		}
+		b.WriteByte(']')
+	}
+
+	if e.message.Key != "" {
+		b.WriteByte(' ')
+		b.WriteString(e.message.Value.String())
+	}
+	return []byte(b.String()), nil
+}
+
+// syslog3164Timestamp is the RFC 3164 TIMESTAMP layout.
+const syslog3164Timestamp = time.Stamp
+
+// encodeSyslog3164 renders e as an RFC 3164 message:
+//
+//	<PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG key=value ...
+func (h *PrettyHandler) encodeSyslog3164(e entry) ([]byte, error) {
+	o := h.opts.Syslog
+
+	var b strings.Builder
+	b.WriteByte('<')
+	b.WriteString(strconv.Itoa(o.priority(e.level.Value)))
+	b.WriteByte('>')
+	t := time.Now()
+	if e.time.Key != "" && e.time.Value.Kind() == slog.KindTime {
+		t = e.time.Value.Time()
+	}
+	b.WriteString(t.Format(syslog3164Timestamp))
+	b.WriteByte(' ')
+	b.WriteString(syslogHeaderField(o.host(), 255))
+	b.WriteByte(' ')
+	b.WriteString(syslogHeaderField(o.appName(), 32))
+	b.WriteString("[" + strconv.Itoa(os.Getpid()) + "]:")
+
+	if e.message.Key != "" {
+		b.WriteByte(' ')
+		b.WriteString(e.message.Value.String())
+	}
+	if e.source.Key != "" {
+		b.WriteString(" source=" + logfmtQuote(logfmtValue(sourceString(e.source.Value.Any()))))
+	}
+	for _, f := range e.attrs.flatten("") {
+		b.WriteByte(' ')
+		b.WriteString(logfmtQuote(f.key))
+		b.WriteByte('=')
+		b.WriteString(logfmtQuote(logfmtValue(f.value)))
+	}
+	return []byte(b.String()), nil
+}
+
+// syslogHeaderField keeps a header field within its maximum length and the printable
+// ASCII range, using "-" for empty values.
+func syslogHeaderField(s string, max int) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, s)
+	if s == "" {
+		return "-"
+	}
+	if len(s) > max {
+		s = s[:max]
+	}
+	return s
+}
+
+var sdValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// syslogParam renders a structured data parameter. Names are limited to 32 printable
+// characters without '=', ' ', ']' and '"'.
+func syslogParam(name, value string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return name + `="` + sdValueEscaper.Replace(value) + `"`
+}
@@ -0,0 +1,64 @@
+package slogger
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogFormat(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.FixedZone("", -5*3600))
+	fixedTime := WithReplaceAttr(func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey && len(groups) == 0 {
+			return slog.Time(slog.TimeKey, at)
+		}
+		return a
+	})
+	pid := strconv.Itoa(os.Getpid())
+
+	for _, tt := range []struct {
+		name   string
+		syslog SyslogOptions
+		log    func(l *slog.Logger)
+		want   string
+	}{
+		{
+			name:   "rfc5424",
+			syslog: SyslogOptions{AppName: "api", Hostname: "web 1", MsgIDKey: "event", Facility: FacilityLocal0},
+			log: func(l *slog.Logger) {
+				l.Info("user logged in", "event", "login", "user", "bob", "note", `a"b]c\d`, slog.Group("req", "id", 7))
+			},
+			want: `<134>1 2024-01-02T03:04:05.000006-05:00 web_1 api ` + pid + ` login [slog@32473 user="bob" note="a\"b\]c\\d" req.id="7"] user logged in`,
+		},
+		{
+			name:   "rfc5424 without attributes",
+			syslog: SyslogOptions{AppName: "api", Hostname: "web1", SDID: "app@12345"},
+			log:    func(l *slog.Logger) { l.Error("failed") },
+			want:   `<11>1 2024-01-02T03:04:05.000006-05:00 web1 api ` + pid + ` - - failed`,
+		},
+		{
+			name:   "rfc5424 parameter names",
+			syslog: SyslogOptions{AppName: "api", Hostname: "web1", SDID: "app@12345"},
+			log:    func(l *slog.Logger) { l.Warn("odd", "a b=c", 1, `x"]`, 2, strings.Repeat("k", 40), 3) },
+			want:   `<12>1 2024-01-02T03:04:05.000006-05:00 web1 api ` + pid + ` - [app@12345 a_b_c="1" x__="2" ` + strings.Repeat("k", 32) + `="3"] odd`,
+		},
+		{
+			name:   "rfc3164",
+			syslog: SyslogOptions{Protocol: SyslogRFC3164, AppName: "api", Hostname: "web1", Facility: FacilityDaemon},
+			log:    func(l *slog.Logger) { l.Debug("cache miss", "key", "user 42") },
+			want:   `<31>Jan  2 03:04:05 web1 api[` + pid + `]: cache miss key="user 42"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.log(slog.New(NewSyslogHandler(&buf, tt.syslog, fixedTime, WithLevel(slog.LevelDebug), WithSource(false))))
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package slogger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// syslogSockets are the local syslog sockets tried by DialSyslog, in order.
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogWriter is an io.Writer sending each Write as one syslog message to a local or
+// remote syslog server. Failed writes reconnect and are retried once. It is safe for
+// concurrent use.
+//
+// Messages are sent as they are on datagram sockets, with newline framing on local
+// stream sockets and with RFC 6587 octet counting over TCP. Messages still failing are
+// returned as errors; wrap the writer with DeadLetter.Wrap to spool them instead, and with
+// CircuitBreaker.Wrap to stop reconnecting to a server that is down.
+type SyslogWriter struct {
+	network string
+	addr    string
+
+	mu   sync.Mutex
+	conn net.Conn
+	// local is set for the local socket, whose network is picked when connecting.
+	local bool
+}
+
+// NewSyslogWriter connects to a syslog server. network is "udp", "tcp" or a variant such
+// as "udp4"; with an empty network and address it connects to the local syslog socket,
+// such as /dev/log.
+func NewSyslogWriter(network, addr string) (*SyslogWriter, error) {
+	w := &SyslogWriter{network: network, addr: addr, local: network == "" && addr == ""}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// DialSyslog connects to a syslog server with NewSyslogWriter and returns a handler
+// writing syslog messages to it. It accepts the same options as NewPrettyHandler.
+func DialSyslog(network, addr string, syslog SyslogOptions, opts ...Option) (*PrettyHandler, *SyslogWriter, error) {
+	w, err := NewSyslogWriter(network, addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewSyslogHandler(w, syslog, opts...), w, nil
+}
+
+// Write sends p, without its trailing newline, as a single message.
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	msg := bytes.TrimSuffix(p, []byte{'\n'})
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil {
+		if err := w.send(msg); err == nil {
+			return len(p), nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	if err := w.connect(); err != nil {
+		return 0, err
+	}
+	if err := w.send(msg); err != nil {
+		w.conn.Close()
+		w.conn = nil
+		return 0, fmt.Errorf("slogger: syslog write: %w", err)
+	}
+	return len(p), nil
+}
+
+// Close closes the connection.
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// connect (re)establishes the connection. w.mu must be held or w not yet shared.
+func (w *SyslogWriter) connect() error {
+	if !w.local {
+		conn, err := net.Dial(w.network, w.addr)
+		if err != nil {
+			return fmt.Errorf("slogger: connect to syslog: %w", err)
+		}
+		w.conn = conn
+		return nil
+	}
+
+	var errs []error
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, path := range syslogSockets {
+			conn, err := net.Dial(network, path)
+			if err == nil {
+				w.conn = conn
+				return nil
+			}
+			errs = append(errs, err)
+		}
+	}
+	return fmt.Errorf("slogger: connect to local syslog: %w", errors.Join(errs...))
+}
+
+// send writes msg with the framing of the connection.
+func (w *SyslogWriter) send(msg []byte) error {
+	var frame []byte
+	switch w.conn.LocalAddr().Network() {
+	case "tcp", "tcp4", "tcp6":
+		frame = append(strconv.AppendInt(nil, int64(len(msg)), 10), ' ')
+		frame = append(frame, msg...)
+	case "unix":
+		frame = append(msg[:len(msg):len(msg)], '\n')
+	default:
+		frame = msg
+	}
+	_, err := w.conn.Write(frame)
+	return err
+}
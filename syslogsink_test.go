@@ -0,0 +1,98 @@
+package slogger
+
+import (
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSyslogWriterFraming(t *testing.T) {
+	msgs := []string{"<14>1 - host app 1 - - first\n", "<14>1 - host app 1 - - multi\nline"}
+
+	t.Run("tcp", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+		got := readStream(t, ln, func() {
+			writeSyslog(t, "tcp", ln.Addr().String(), msgs)
+		})
+		// RFC 6587 octet counting, so the newline of a message is part of it.
+		if want := "28 <14>1 - host app 1 - - first33 <14>1 - host app 1 - - multi\nline"; got != want {
+			t.Errorf("stream = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("unix", func(t *testing.T) {
+		ln, err := net.Listen("unix", filepath.Join(t.TempDir(), "log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+		got := readStream(t, ln, func() {
+			writeSyslog(t, "unix", ln.Addr().String(), msgs)
+		})
+		if want := "<14>1 - host app 1 - - first\n<14>1 - host app 1 - - multi\nline\n"; got != want {
+			t.Errorf("stream = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("udp", func(t *testing.T) {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer pc.Close()
+		writeSyslog(t, "udp", pc.LocalAddr().String(), msgs)
+		pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		buf := make([]byte, 1024)
+		for _, want := range []string{"<14>1 - host app 1 - - first", "<14>1 - host app 1 - - multi\nline"} {
+			n, _, err := pc.ReadFrom(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(buf[:n]); got != want {
+				t.Errorf("datagram = %q, want %q", got, want)
+			}
+		}
+	})
+}
+
+// writeSyslog sends msgs with a SyslogWriter and closes it.
+func writeSyslog(t *testing.T, network, addr string, msgs []string) {
+	t.Helper()
+	w, err := NewSyslogWriter(network, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range msgs {
+		if n, err := w.Write([]byte(msg)); err != nil || n != len(msg) {
+			t.Fatalf("Write = %d, %v", n, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readStream runs write and returns what the first connection to ln received.
+func readStream(t *testing.T, ln net.Listener, write func()) string {
+	t.Helper()
+	done := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			done <- ""
+			return
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		b, _ := io.ReadAll(conn)
+		done <- string(b)
+	}()
+	write()
+	return <-done
+}
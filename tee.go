@@ -0,0 +1,90 @@
+package slogger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// Tee returns a handler passing every record to each of handlers that is enabled for its
+// level, for example a pretty handler on stdout and a JSON handler writing to a file.
+// Children are isolated from each other: a child that fails or panics doesn't keep the
+// record from the others, and the errors of all failing children are joined.
+func Tee(handlers ...slog.Handler) slog.Handler {
+	return &teeHandler{handlers: append([]slog.Handler(nil), handlers...)}
+}
+
+// Leveled returns h with its own minimum level, typically to give each child of a Tee
+// its threshold:
+//
+//	slogger.Tee(
+//		slogger.Leveled(console, slog.LevelDebug),
+//		slogger.Leveled(file, slog.LevelInfo),
+//		slogger.Leveled(remote, slog.LevelWarn),
+//	)
+//
+// A PrettyHandler gets its level replaced, so it can be made more verbose; other handlers
+// can only be filtered further.
+func Leveled(h slog.Handler, level slog.Leveler) slog.Handler {
+	if ph, ok := h.(*PrettyHandler); ok {
+		return ph.withLeveler(level)
+	}
+	return &levelFilter{Handler: h, leveler: level}
+}
+
+type teeHandler struct {
+	handlers []slog.Handler
+}
+
+func (t *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t.handlers {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for i, h := range t.handlers {
+		if !h.Enabled(ctx, r.Level) && !dumping(ctx) {
+			continue
+		}
+		// Each child gets its own copy, so one adding attributes can't affect the others.
+		if err := handleIsolated(ctx, h, r.Clone()); err != nil {
+			errs = append(errs, fmt.Errorf("slogger: tee handler %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return t.derive(func(h slog.Handler) slog.Handler { return h.WithAttrs(attrs) })
+}
+
+func (t *teeHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return t
+	}
+	return t.derive(func(h slog.Handler) slog.Handler { return h.WithGroup(name) })
+}
+
+func (t *teeHandler) derive(f func(slog.Handler) slog.Handler) *teeHandler {
+	hs := make([]slog.Handler, len(t.handlers))
+	for i, h := range t.handlers {
+		hs[i] = f(h)
+	}
+	return &teeHandler{handlers: hs}
+}
+
+// handleIsolated passes r to h, turning a panic into an error.
+func handleIsolated(ctx context.Context, h slog.Handler, r slog.Record) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panic: %v", v)
+		}
+	}()
+	return h.Handle(ctx, r)
+}
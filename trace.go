@@ -0,0 +1,100 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// ContextKey is the type of the context keys this package exports for callers to set,
+// distinct from the keys of other packages even when they share a name. The keys only
+// the package sets have unexported types.
+type ContextKey string
+
+// TraceIDContextKey is the context key under which ContextWithTraceID stores the trace ID
+// the handlers log as TraceIDKey.
+const TraceIDContextKey ContextKey = "trace-id"
+
+// Context keys of common correlation fields, for WithContextKeys. Each is logged under
+// its own name.
+const (
+	RequestIDContextKey ContextKey = "request-id"
+	SessionIDContextKey ContextKey = "session-id"
+	TenantIDContextKey  ContextKey = "tenant-id"
+	UserIDContextKey    ContextKey = "user-id"
+)
+
+// ContextAttr is a context value logged as an attribute, see WithContextAttrs.
+type ContextAttr struct {
+	// Key is the context key, of any type, as passed to context.WithValue.
+	Key any
+
+	// Name is the attribute key the value is logged under.
+	Name string
+}
+
+// ContextWithTraceID returns a copy of ctx carrying the trace ID id, logged by the
+// handlers of this package with every record logged with the context.
+func ContextWithTraceID(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, TraceIDContextKey, id)
+}
+
+// TraceIDFromContext returns the trace ID carried by ctx, if any. For compatibility, a
+// trace ID stored under the plain string key "trace-id" is also found.
+func TraceIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	if id, ok := ctx.Value(TraceIDContextKey).(uuid.UUID); ok {
+		return id, true
+	}
+	id, ok := ctx.Value(TraceIDKey).(uuid.UUID)
+	return id, ok
+}
+
+// NewTraceContext starts a trace at a request entry point: it returns ctx carrying a new
+// random trace ID and a logger derived from Default logging that ID with every record,
+// including those logged without the context:
+//
+//	ctx, log := slogger.NewTraceContext(r.Context())
+//	log.Info("request received")
+//	process(ctx, ...)
+//
+// If ctx already carries a trace ID, it is kept, so a trace continued from upstream or
+// started by an outer entry point stays one trace.
+func NewTraceContext(ctx context.Context) (context.Context, *slog.Logger) {
+	id, ok := TraceIDFromContext(ctx)
+	if !ok {
+		id = uuid.New()
+		ctx = ContextWithTraceID(ctx, id)
+	}
+	return ctx, slog.New(&traceHandler{next: Default().Handler(), id: id})
+}
+
+// traceHandler gives the records logged with a context lacking a trace ID the trace ID
+// id. It goes through the context rather than an attribute, so records logged with the
+// context don't carry the ID twice.
+type traceHandler struct {
+	next slog.Handler
+	id   uuid.UUID
+}
+
+func (h *traceHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if _, ok := TraceIDFromContext(ctx); !ok {
+		ctx = ContextWithTraceID(ctx, h.id)
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &traceHandler{next: h.next.WithAttrs(attrs), id: h.id}
+}
+
+func (h *traceHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &traceHandler{next: h.next.WithGroup(name), id: h.id}
+}
@@ -0,0 +1,109 @@
+package slogger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+)
+
+// TraceparentHeader is the W3C Trace Context header carrying the span context of a
+// request across services.
+const TraceparentHeader = "traceparent"
+
+var errTraceparent = errors.New("slogger: invalid traceparent")
+
+// ParseTraceparent parses a W3C traceparent header value such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01". As the specification
+// requires, the fields are lowercase hex.
+func ParseTraceparent(s string) (SpanContext, error) {
+	// Later versions may append fields, which are ignored as the specification asks.
+	if len(s) < 55 || (len(s) > 55 && s[55] != '-') || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return SpanContext{}, errTraceparent
+	}
+	for i := 0; i < 55; i++ {
+		if c := s[i]; i != 2 && i != 35 && i != 52 && !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return SpanContext{}, errTraceparent
+		}
+	}
+	version, err := hex.DecodeString(s[:2])
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(s) != 55) {
+		return SpanContext{}, errTraceparent
+	}
+	var sc SpanContext
+	var flags [1]byte
+	if _, err := hex.Decode(sc.TraceID[:], []byte(s[3:35])); err != nil {
+		return SpanContext{}, errTraceparent
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(s[36:52])); err != nil {
+		return SpanContext{}, errTraceparent
+	}
+	if _, err := hex.Decode(flags[:], []byte(s[53:55])); err != nil {
+		return SpanContext{}, errTraceparent
+	}
+	sc.Flags = flags[0]
+	if !sc.IsValid() {
+		return SpanContext{}, errTraceparent
+	}
+	return sc, nil
+}
+
+// Traceparent formats sc as a version 00 traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	return "00-" + sc.TraceIDHex() + "-" + sc.SpanIDHex() + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+// newSpanContext returns the span context of a new span: a child of parent if it is
+// valid, or else the root of a new trace.
+func newSpanContext(parent SpanContext) SpanContext {
+	sc := parent
+	if !parent.IsValid() {
+		rand.Read(sc.TraceID[:])
+		sc.Flags = 0
+	}
+	rand.Read(sc.SpanID[:])
+	return sc
+}
+
+// requestSpanContext returns r with a context carrying the span context of the request:
+// a child of the span of its traceparent header, or the root of a new trace. A request
+// whose context already has a span context, for example from OpenTelemetry middleware
+// running first, keeps it, so the records carry the ID of the real span.
+func requestSpanContext(r *http.Request) *http.Request {
+	if _, ok := SpanContextFromContext(r.Context()); ok {
+		return r
+	}
+	parent, _ := ParseTraceparent(r.Header.Get(TraceparentHeader))
+	return r.WithContext(ContextWithSpanContext(r.Context(), newSpanContext(parent)))
+}
+
+// TraceparentTransport is an http.RoundTripper propagating the span context of the
+// context of each request in its traceparent header, so the services called log the same
+// trace_id:
+//
+//	client := &http.Client{Transport: &slogger.TraceparentTransport{}}
+//	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+//	client.Do(req)
+//
+// Requests already carrying the header, or whose context has no span context, are sent
+// unchanged.
+type TraceparentTransport struct {
+	// Base sends the requests. Defaults to http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *TraceparentTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if r.Header.Get(TraceparentHeader) == "" {
+		if sc, ok := SpanContextFromContext(r.Context()); ok {
+			// A RoundTripper must not modify the request it is given.
+			r = r.Clone(r.Context())
+			r.Header.Set(TraceparentHeader, sc.Traceparent())
+		}
+	}
+	return base.RoundTrip(r)
+}
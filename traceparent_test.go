@@ -0,0 +1,75 @@
+package slogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPMiddlewareKeepsSpanContext(t *testing.T) {
+	sc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	logger := slog.New(NewPrettyHandler(&buf, WithFormat(FormatJSON)))
+	var seen SpanContext
+	h := HTTPMiddleware(HTTPOptions{Logger: logger, Traceparent: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = SpanContextFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(TraceparentHeader, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	h.ServeHTTP(httptest.NewRecorder(), req.WithContext(ContextWithSpanContext(req.Context(), sc)))
+	if seen != sc {
+		t.Errorf("span context of the handler = %s, want %s", seen.Traceparent(), sc.Traceparent())
+	}
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if rec[SpanIDKey] != sc.SpanIDHex() || rec[SpanTraceIDKey] != sc.TraceIDHex() {
+		t.Errorf("logged span %v of trace %v, want %s of %s", rec[SpanIDKey], rec[SpanTraceIDKey], sc.SpanIDHex(), sc.TraceIDHex())
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(TraceparentHeader, sc.Traceparent())
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if seen.TraceID != sc.TraceID || seen.SpanID == sc.SpanID {
+		t.Errorf("span context of a request without one = %s, want a child of %s", seen.Traceparent(), sc.Traceparent())
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want string // Traceparent of the result, or empty for an error
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		{"cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", ""},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00F067AA0BA902B7-01", ""},
+		{"0A-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", ""},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0F", ""},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", ""},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", ""},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", ""},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", ""},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", ""},
+		{"00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", ""},
+		{"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01", ""},
+	} {
+		sc, err := ParseTraceparent(tt.in)
+		got := ""
+		if err == nil {
+			got = sc.Traceparent()
+		}
+		if got != tt.want {
+			t.Errorf("ParseTraceparent(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
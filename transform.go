@@ -0,0 +1,134 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// AttrTransform describes changes applied to the attributes of every record by
+// TransformMiddleware. Keys are dotted for attributes inside groups.
+type AttrTransform struct {
+	// Add are attributes added to every record, such as env=prod.
+	Add []slog.Attr
+
+	// Rename maps keys to new names, such as "err" to "error". The attribute stays in its
+	// group.
+	Rename map[string]string
+
+	// Remove are keys dropped, such as internal bookkeeping attributes.
+	Remove []string
+
+	// Rewrite, if set, is called with every attribute after Remove and Rename, as
+	// ReplaceAttr is by slog.HandlerOptions, and returns its replacement. Returning the
+	// zero Attr drops it.
+	Rewrite func(groups []string, a slog.Attr) slog.Attr
+}
+
+// TransformMiddleware returns a middleware changing attributes as described by t, for
+// both the attributes of records and those bound with With, without touching the call
+// sites:
+//
+//	slogger.TransformMiddleware(slogger.AttrTransform{
+//		Add:    []slog.Attr{slog.String("env", "prod")},
+//		Rename: map[string]string{"err": "error"},
+//		Remove: []string{"internal_id"},
+//	})
+func TransformMiddleware(t AttrTransform) Middleware {
+	remove := make(map[string]bool, len(t.Remove))
+	for _, k := range t.Remove {
+		remove[k] = true
+	}
+	return func(h slog.Handler) slog.Handler {
+		if len(t.Add) > 0 {
+			h = h.WithAttrs(t.Add)
+		}
+		return &transformHandler{next: h, t: t, remove: remove}
+	}
+}
+
+type transformHandler struct {
+	next   slog.Handler
+	t      AttrTransform
+	remove map[string]bool
+	groups []string
+}
+
+func (h *transformHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *transformHandler) Handle(ctx context.Context, r slog.Record) error {
+	r2 := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		if a, ok := h.transform(h.groups, a); ok {
+			r2.AddAttrs(a)
+		}
+		return true
+	})
+	return h.next.Handle(ctx, r2)
+}
+
+func (h *transformHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		if a, ok := h.transform(h.groups, a); ok {
+			out = append(out, a)
+		}
+	}
+	h2 := *h
+	h2.next = h.next.WithAttrs(out)
+	return &h2
+}
+
+func (h *transformHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.next = h.next.WithGroup(name)
+	h2.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &h2
+}
+
+// transform applies the transform to a inside groups, reporting false if it is dropped.
+func (h *transformHandler) transform(groups []string, a slog.Attr) (slog.Attr, bool) {
+	a.Value = a.Value.Resolve()
+	orig, key := a.Key, a.Key
+	if len(groups) > 0 {
+		key = strings.Join(groups, ".") + "." + a.Key
+	}
+	if h.remove[key] {
+		return slog.Attr{}, false
+	}
+	if name, ok := h.t.Rename[key]; ok {
+		a.Key = name
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		// Keys inside are matched as logged, under the name of the group before renaming.
+		inner := groups
+		if orig != "" {
+			inner = append(groups[:len(groups):len(groups)], orig)
+		}
+		members := make([]slog.Attr, 0, len(a.Value.Group()))
+		for _, ga := range a.Value.Group() {
+			if ga, ok := h.transform(inner, ga); ok {
+				members = append(members, ga)
+			}
+		}
+		if len(members) == 0 {
+			return slog.Attr{}, false
+		}
+		a.Value = slog.GroupValue(members...)
+		return a, true
+	}
+
+	if h.t.Rewrite != nil {
+		a = h.t.Rewrite(groups, a)
+		if a.Equal(slog.Attr{}) {
+			return a, false
+		}
+	}
+	return a, true
+}
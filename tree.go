@@ -0,0 +1,179 @@
+package slogger
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// WithTree renders the attributes of the pretty output as an indented, colored key/value
+// tree below the record instead of JSON. Structs, maps and slices are expanded by
+// reflection down to maxDepth levels; deeper values are shown as "…".
+func WithTree(maxDepth int) Option {
+	return optionFunc(func(o *PrettyHandlerOptions) {
+		o.TreeDepth = maxDepth
+	})
+}
+
+// treeWriter renders values as an indented tree.
+type treeWriter struct {
+	h        *PrettyHandler
+	b        strings.Builder
+	maxDepth int
+}
+
+// renderTree renders fs as a tree, one attribute per line, each line starting with a newline.
+func (h *PrettyHandler) renderTree(fs fields) string {
+	t := &treeWriter{h: h, maxDepth: h.opts.TreeDepth}
+	for _, f := range fs {
+		t.entry(1, f.key, reflect.ValueOf(f.value))
+	}
+	return t.b.String()
+}
+
+// entry writes "key: value" at the given depth, expanding composite values below it.
+// Slice elements are written as "- value".
+func (t *treeWriter) entry(depth int, key string, v reflect.Value) {
+	t.b.WriteByte('\n')
+	t.b.WriteString(strings.Repeat("  ", depth))
+	if key == "" {
+		t.b.WriteByte('-')
+	} else {
+		t.b.WriteString(t.h.colorize(color.FgCyan, key))
+		t.b.WriteByte(':')
+	}
+	t.value(depth, v)
+}
+
+// value writes v after its key, either inline or as nested entries.
+func (t *treeWriter) value(depth int, v reflect.Value) {
+	for {
+		if !v.IsValid() || isNil(v) {
+			t.b.WriteString(" " + t.h.colorize(color.FgHiBlack, "nil"))
+			return
+		}
+		if s, ok := scalarString(v); ok {
+			t.scalar(v, s)
+			return
+		}
+		if v.Kind() != reflect.Pointer && v.Kind() != reflect.Interface {
+			break
+		}
+		v = v.Elem()
+	}
+
+	if depth >= t.maxDepth {
+		t.b.WriteString(" …")
+		return
+	}
+
+	if fs, ok := v.Interface().(fields); ok {
+		for _, f := range fs {
+			t.entry(depth+1, f.key, reflect.ValueOf(f.value))
+		}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		typ := v.Type()
+		for i := 0; i < typ.NumField(); i++ {
+			sf := typ.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = sf.Name
+			}
+			t.entry(depth+1, name, v.Field(i))
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, k := range keys {
+			t.entry(depth+1, fmt.Sprint(k), v.MapIndex(k))
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			t.b.WriteString(" []")
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			t.entry(depth+1, "", v.Index(i))
+		}
+	default:
+		t.b.WriteString(" " + fmt.Sprint(v.Interface()))
+	}
+}
+
+// isNil reports whether v is a nil pointer, interface, map or slice.
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+// scalar writes a value rendered on the same line as its key, colored by kind.
+func (t *treeWriter) scalar(v reflect.Value, s string) {
+	attr := color.FgMagenta
+	if v.Kind() == reflect.String {
+		attr = color.FgGreen
+	}
+	t.b.WriteString(" " + t.h.colorize(attr, s))
+}
+
+// scalarString renders values that are shown inline: basic kinds, errors, times and
+// values with a text form. It reports false for values to expand.
+func scalarString(v reflect.Value) (string, bool) {
+	if v.CanInterface() {
+		switch x := v.Interface().(type) {
+		case fields:
+			return "", false
+		case time.Time:
+			return x.Format(time.RFC3339Nano), true
+		case time.Duration:
+			return x.String(), true
+		case error:
+			return strconv.Quote(x.Error()), true
+		case encoding.TextMarshaler:
+			if b, err := x.MarshalText(); err == nil {
+				return strconv.Quote(string(b)), true
+			}
+		case fmt.Stringer:
+			if v.Kind() != reflect.Pointer && v.Kind() != reflect.Struct {
+				return strconv.Quote(x.String()), true
+			}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String()), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), true
+	case reflect.Complex64, reflect.Complex128, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		if !v.CanInterface() {
+			return v.Type().String(), true
+		}
+		return fmt.Sprint(v.Interface()), true
+	}
+	return "", false
+}
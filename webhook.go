@@ -0,0 +1,98 @@
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookOptions configures a WebhookSink.
+type WebhookOptions struct {
+	// URL receives the batches.
+	URL string
+
+	// Headers are added to every request, for example for authentication.
+	Headers map[string]string
+
+	// Gzip compresses request bodies.
+	Gzip bool
+
+	// BatchSize is the largest number of records per request. Defaults to 500.
+	BatchSize int
+
+	// FlushInterval is the longest a record waits before being sent. Defaults to one second.
+	FlushInterval time.Duration
+
+	// MaxInFlight bounds the number of concurrent requests. Defaults to 2.
+	MaxInFlight int
+
+	// MaxRetries is how often a failed request is retried with exponential backoff.
+	// Network errors and 429 and 5xx responses are retried. Defaults to 5; a negative
+	// value disables retries.
+	MaxRetries int
+
+	// MaxBuffered is the number of records queued while requests are failing. Records
+	// beyond it are rejected with ErrBufferFull. Defaults to ten batches.
+	MaxBuffered int
+
+	// DeadLetter, if set, receives the records that still fail after the retries or find
+	// the buffer full, instead of dropping them. See DeadLetter.Replay.
+	DeadLetter *DeadLetter
+
+	// Breaker, if set, stops sending while the endpoint keeps failing, see
+	// CircuitBreaker. It may be shared by several sinks talking to the same backend.
+	Breaker *CircuitBreaker
+
+	// Client sends the requests. Defaults to a client with a ten second timeout.
+	Client *http.Client
+
+	// OnError, if not nil, receives failed deliveries.
+	OnError func(error)
+}
+
+// WebhookSink is an io.Writer posting the JSON records written by a FormatJSON handler
+// to an HTTP endpoint in batches, as a JSON array per request. Records are sent from
+// background goroutines; call Close to send the remaining ones.
+type WebhookSink struct {
+	*batcher
+	opts WebhookOptions
+}
+
+// NewWebhookHandler creates a JSON handler posting records to a webhook and returns the
+// sink so it can be closed on shutdown. Handler options are applied as for NewPrettyHandler.
+func NewWebhookHandler(webhook WebhookOptions, opts ...Option) (*PrettyHandler, *WebhookSink) {
+	s := NewWebhookSink(webhook)
+	return NewPrettyHandler(s, append(opts, WithFormat(FormatJSON))...), s
+}
+
+// NewWebhookSink starts a sink with the given options.
+func NewWebhookSink(opts WebhookOptions) *WebhookSink {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	s := &WebhookSink{opts: opts}
+	s.batcher = newBatcher(batchOptions{
+		size:        opts.BatchSize,
+		interval:    opts.FlushInterval,
+		maxInFlight: opts.MaxInFlight,
+		maxRetries:  opts.MaxRetries,
+		maxBuffered: opts.MaxBuffered,
+		deadLetter:  opts.DeadLetter,
+		breaker:     opts.Breaker,
+		onError:     opts.OnError,
+		send:        s.send,
+	})
+	return s
+}
+
+// send posts records as a JSON array.
+func (s *WebhookSink) send(ctx context.Context, records [][]byte) error {
+	body := append([]byte{'['}, bytes.Join(records, []byte{','})...)
+	body = append(body, ']')
+	if _, err := httpPost(ctx, s.opts.Client, s.opts.URL, "application/json", s.opts.Headers, body, s.opts.Gzip); err != nil {
+		return fmt.Errorf("slogger: webhook: %w", err)
+	}
+	return nil
+}